@@ -3,6 +3,10 @@
 
 package orderspb
 
+import (
+	"time"
+)
+
 // GetOrderRequest is the request for GetOrder
 type GetOrderRequest struct {
 	Id uint64 `json:"id,omitempty"`
@@ -19,6 +23,8 @@ func (x *GetOrderRequest) GetId() uint64 {
 type CreateOrderRequest struct {
 	UserId uint64  `json:"user_id,omitempty"`
 	Total  float64 `json:"total,omitempty"`
+	// Optional; retries with the same key return the order already created
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 func (x *CreateOrderRequest) GetUserId() uint64 {
@@ -35,6 +41,25 @@ func (x *CreateOrderRequest) GetTotal() float64 {
 	return 0
 }
 
+func (x *CreateOrderRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// ReopenOrderRequest is the request for ReopenOrder
+type ReopenOrderRequest struct {
+	Id uint64 `json:"id,omitempty"`
+}
+
+func (x *ReopenOrderRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
 // OrderResponse is the response containing order data
 type OrderResponse struct {
 	Id        uint64  `json:"id,omitempty"`
@@ -42,6 +67,7 @@ type OrderResponse struct {
 	Total     float64 `json:"total,omitempty"`
 	Status    string  `json:"status,omitempty"`
 	CreatedAt string  `json:"created_at,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
 }
 
 func (x *OrderResponse) GetId() uint64 {
@@ -78,3 +104,232 @@ func (x *OrderResponse) GetCreatedAt() string {
 	}
 	return ""
 }
+
+func (x *OrderResponse) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+// PageRequest selects a page of results
+type PageRequest struct {
+	PageSize  int32  `json:"page_size,omitempty"`
+	PageToken string `json:"page_token,omitempty"`
+}
+
+func (x *PageRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *PageRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// PageInfo describes the returned page
+type PageInfo struct {
+	Total         int64  `json:"total,omitempty"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+func (x *PageInfo) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PageInfo) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ListOrdersRequest is the request for ListOrders
+type ListOrdersRequest struct {
+	UserId uint64       `json:"user_id,omitempty"`
+	Page   *PageRequest `json:"page,omitempty"`
+}
+
+func (x *ListOrdersRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListOrdersRequest) GetPage() *PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// ListOrdersResponse is the response for ListOrders
+type ListOrdersResponse struct {
+	Orders []*OrderResponse `json:"orders,omitempty"`
+	Page   *PageInfo        `json:"page,omitempty"`
+}
+
+func (x *ListOrdersResponse) GetOrders() []*OrderResponse {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ListOrdersResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// GetOrderStatsRequest is the request for GetOrderStats
+type GetOrderStatsRequest struct {
+	UserId uint64 `json:"user_id,omitempty"`
+}
+
+func (x *GetOrderStatsRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// OrderStatusStats summarizes the orders in one status
+type OrderStatusStats struct {
+	Status string  `json:"status,omitempty"`
+	Count  int64   `json:"count,omitempty"`
+	Total  float64 `json:"total,omitempty"`
+}
+
+func (x *OrderStatusStats) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *OrderStatusStats) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *OrderStatusStats) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// OrderStatsResponse is the response for GetOrderStats
+type OrderStatsResponse struct {
+	Statuses []*OrderStatusStats `json:"statuses,omitempty"`
+}
+
+func (x *OrderStatsResponse) GetStatuses() []*OrderStatusStats {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+// BatchGetOrdersRequest is the request for BatchGetOrders
+type BatchGetOrdersRequest struct {
+	Ids []uint64 `json:"ids,omitempty"`
+}
+
+func (x *BatchGetOrdersRequest) GetIds() []uint64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+// BatchGetOrdersResponse is the response for BatchGetOrders
+type BatchGetOrdersResponse struct {
+	Orders     []*OrderResponse `json:"orders,omitempty"`
+	MissingIds []uint64         `json:"missing_ids,omitempty"`
+}
+
+func (x *BatchGetOrdersResponse) GetOrders() []*OrderResponse {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *BatchGetOrdersResponse) GetMissingIds() []uint64 {
+	if x != nil {
+		return x.MissingIds
+	}
+	return nil
+}
+
+// GetOrderWindowStatsRequest is the request for GetOrderWindowStats
+type GetOrderWindowStatsRequest struct {
+	Window string `json:"window,omitempty"`
+}
+
+func (x *GetOrderWindowStatsRequest) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+// OrderWindowStatsResponse is the response for GetOrderWindowStats
+type OrderWindowStatsResponse struct {
+	Window  string  `json:"window,omitempty"`
+	Since   string  `json:"since,omitempty"`
+	Count   int64   `json:"count,omitempty"`
+	Revenue float64 `json:"revenue,omitempty"`
+}
+
+func (x *OrderWindowStatsResponse) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *OrderWindowStatsResponse) GetSince() string {
+	if x != nil {
+		return x.Since
+	}
+	return ""
+}
+
+func (x *OrderWindowStatsResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *OrderWindowStatsResponse) GetRevenue() float64 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
+// FormatTime formats a timestamp for responses, in UTC RFC 3339. The zero
+// time, i.e. a timestamp that was never loaded, is "" rather than year 1, so
+// responses leave it out.
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -21,8 +21,9 @@ func (x *GetUserRequest) GetId() uint64 {
 
 // CreateUserRequest is the request for CreateUser
 type CreateUserRequest struct {
-	Name  string `json:"name,omitempty"`
-	Email string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password,omitempty"`
 }
 
 func (x *CreateUserRequest) GetName() string {
@@ -39,12 +40,109 @@ func (x *CreateUserRequest) GetEmail() string {
 	return ""
 }
 
+func (x *CreateUserRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+// VerifyCredentialsRequest is the request for VerifyCredentials
+type VerifyCredentialsRequest struct {
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (x *VerifyCredentialsRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *VerifyCredentialsRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+// IssueRefreshTokenRequest is the request for IssueRefreshToken
+type IssueRefreshTokenRequest struct {
+	UserId uint64 `json:"user_id,omitempty"`
+}
+
+func (x *IssueRefreshTokenRequest) GetUserId() uint64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// RotateRefreshTokenRequest is the request for RotateRefreshToken
+type RotateRefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func (x *RotateRefreshTokenRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+// RevokeRefreshTokenRequest is the request for RevokeRefreshToken
+type RevokeRefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func (x *RevokeRefreshTokenRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+// RefreshTokenResponse is a newly issued refresh token and its user
+type RefreshTokenResponse struct {
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresAt    string        `json:"expires_at,omitempty"`
+	User         *UserResponse `json:"user,omitempty"`
+}
+
+func (x *RefreshTokenResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *RefreshTokenResponse) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *RefreshTokenResponse) GetUser() *UserResponse {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+// RevokeRefreshTokenResponse is the response for RevokeRefreshToken
+type RevokeRefreshTokenResponse struct{}
+
 // UserResponse is the response containing user data
 type UserResponse struct {
 	Id        uint64 `json:"id,omitempty"`
 	Name      string `json:"name,omitempty"`
 	Email     string `json:"email,omitempty"`
 	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	Role      string `json:"role,omitempty"`
+	TenantId  string `json:"tenant_id,omitempty"`
 }
 
 func (x *UserResponse) GetId() uint64 {
@@ -75,7 +173,105 @@ func (x *UserResponse) GetCreatedAt() string {
 	return ""
 }
 
-// Helper to format time
+func (x *UserResponse) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+func (x *UserResponse) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *UserResponse) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+// PageRequest selects a page of results
+type PageRequest struct {
+	PageSize  int32  `json:"page_size,omitempty"`
+	PageToken string `json:"page_token,omitempty"`
+}
+
+func (x *PageRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *PageRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+// PageInfo describes the returned page
+type PageInfo struct {
+	Total         int64  `json:"total,omitempty"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+func (x *PageInfo) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PageInfo) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ListUsersRequest is the request for ListUsers
+type ListUsersRequest struct {
+	Page *PageRequest `json:"page,omitempty"`
+}
+
+func (x *ListUsersRequest) GetPage() *PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// ListUsersResponse is the response for ListUsers
+type ListUsersResponse struct {
+	Users []*UserResponse `json:"users,omitempty"`
+	Page  *PageInfo       `json:"page,omitempty"`
+}
+
+func (x *ListUsersResponse) GetUsers() []*UserResponse {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// FormatTime formats a timestamp for responses, in UTC RFC 3339. The zero
+// time, i.e. a timestamp that was never loaded, is "" rather than year 1, so
+// responses leave it out.
 func FormatTime(t time.Time) string {
-	return t.Format("2006-01-02T15:04:05Z07:00")
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
 }
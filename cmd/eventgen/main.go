@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"go-micro/pkg/config"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/rabbitmq"
+)
+
+// eventgen publishes synthetic events to RabbitMQ so consumers can be
+// exercised without going through the full write path.
+//
+// Usage:
+//
+//	go run ./cmd/eventgen -type user.created -id 1 -name "John Doe" -email john@example.com
+//	go run ./cmd/eventgen -type order.created -id 1 -user-id 1 -total 99.99 -count 5
+//	go run ./cmd/eventgen -type payment.succeeded -order-id 1 -total 99.99
+func main() {
+	eventType := flag.String("type", events.RoutingKeyUserCreated, "event type to publish (user.created, order.created, payment.succeeded)")
+	id := flag.Uint("id", 1, "entity ID of the payload (incremented for each event when count > 1)")
+	name := flag.String("name", "John Doe", "user name (user.created)")
+	email := flag.String("email", "john@example.com", "user email (user.created)")
+	userID := flag.Uint("user-id", 1, "user ID (order.created)")
+	orderID := flag.Uint("order-id", 1, "order ID (payment.succeeded)")
+	total := flag.Float64("total", 99.99, "order total / payment amount (order.created, payment.succeeded)")
+	status := flag.String("status", "pending", "order status (order.created)")
+	traceID := flag.String("trace-id", "", "trace ID to attach (generated per event if empty)")
+	count := flag.Int("count", 1, "number of events to publish")
+	url := flag.String("url", "", "RabbitMQ URL (defaults to RABBITMQ_URL)")
+	flag.Parse()
+
+	cfg := config.Load()
+	if *url != "" {
+		cfg.RabbitMQURL = *url
+	}
+
+	log := logger.New("eventgen", cfg.LogLevel)
+	defer log.Sync()
+
+	var exchange string
+	switch *eventType {
+	case events.RoutingKeyUserCreated:
+		exchange = events.ExchangeUsers
+	case events.RoutingKeyOrderCreated:
+		exchange = events.ExchangeOrders
+	case events.RoutingKeyPaymentSucceeded:
+		exchange = events.ExchangePayments
+	default:
+		log.Error("unsupported event type", zap.String("type", *eventType))
+		os.Exit(2)
+	}
+
+	conn, err := rabbitmq.NewConnection(cfg.RabbitMQURL, "eventgen", log)
+	if err != nil {
+		log.Fatal("failed to connect to RabbitMQ: " + err.Error())
+	}
+	defer conn.Close()
+
+	publisher, err := rabbitmq.NewPublisher(conn, exchange, log)
+	if err != nil {
+		log.Fatal("failed to create publisher: " + err.Error())
+	}
+
+	for i := 0; i < *count; i++ {
+		tid := *traceID
+		if tid == "" {
+			tid = uuid.New().String()
+		}
+		ctx := logger.WithTraceIDContext(context.Background(), tid)
+		entityID := *id + uint(i)
+
+		var event interface{}
+		switch *eventType {
+		case events.RoutingKeyUserCreated:
+			event = events.NewUserCreatedEvent(entityID, *name, *email, time.Now(), tid)
+		case events.RoutingKeyOrderCreated:
+			event = events.NewOrderCreatedEvent(entityID, *userID, *total, *status, time.Now(), tid)
+		case events.RoutingKeyPaymentSucceeded:
+			event = events.NewPaymentSucceededEvent(uuid.New().String(), *orderID+uint(i), *total, time.Now(), tid)
+		}
+
+		if err := publisher.Publish(ctx, *eventType, event); err != nil {
+			log.Fatal("failed to publish event: " + err.Error())
+		}
+
+		log.Info("event published",
+			zap.String("exchange", exchange),
+			zap.String("routing_key", *eventType),
+			zap.Uint("id", entityID),
+			zap.String("trace_id", tid),
+		)
+	}
+}
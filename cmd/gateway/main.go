@@ -29,66 +29,127 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 
 	_ "go-micro/docs/swagger"
 	"go-micro/internal/gateway/clients"
 	"go-micro/internal/gateway/handlers"
+	"go-micro/pkg/auth"
+	"go-micro/pkg/buildinfo"
 	"go-micro/pkg/config"
+	"go-micro/pkg/idcodec"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
 	"go-micro/pkg/middleware"
+	"go-micro/pkg/ratelimit"
+	"go-micro/pkg/stream"
 	pkgtls "go-micro/pkg/tls"
 )
 
+// readinessTimeout bounds how long /health/ready waits for the backends
+const readinessTimeout = 2 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.Load()
 	cfg.ServiceName = "gateway"
 
 	// Initialize logger
-	log := logger.New("gateway", cfg.LogLevel)
+	log := logger.New("gateway", cfg.LogLevel,
+		logger.WithSampling(cfg.LogSamplingInitial, cfg.LogSamplingThereafter),
+	)
 	defer log.Sync()
 
 	log.Info("starting gateway service")
 
 	// Create gRPC clients
-	grpcClients, err := clients.NewClients(cfg)
-	if err != nil {
-		log.Fatal("failed to create gRPC clients: " + err.Error())
-	}
+	grpcClients := clients.NewClients(cfg, log)
 	defer grpcClients.Close()
-	log.Info("connected to backend services via gRPC")
+	log.Info("gRPC clients initialized for backend services")
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Hot-reloadable settings, updated on SIGHUP
+	bodyLogging := &atomic.Bool{}
+	bodyLogging.Store(cfg.LogHTTPBodies)
+	userLimiter := ratelimit.New(cfg.UserRateLimit, cfg.UserRateLimitBurst)
+	anonymousLimiter := ratelimit.New(cfg.AnonymousRateLimit, cfg.AnonymousRateLimitBurst)
+	config.WatchReload(ctx, func(newCfg *config.Config) {
+		log.SetLevel(newCfg.LogLevel)
+		bodyLogging.Store(newCfg.LogHTTPBodies)
+		userLimiter.SetLimits(newCfg.UserRateLimit, newCfg.UserRateLimitBurst)
+		anonymousLimiter.SetLimits(newCfg.AnonymousRateLimit, newCfg.AnonymousRateLimitBurst)
+		log.Info("configuration reloaded",
+			zap.String("log_level", log.Level()),
+			zap.Bool("log_http_bodies", newCfg.LogHTTPBodies),
+			zap.Float64("user_rate_limit", newCfg.UserRateLimit),
+			zap.Float64("anonymous_rate_limit", newCfg.AnonymousRateLimit),
+		)
+	})
+
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(middleware.TraceID())
-	router.Use(middleware.RequestLogger(log))
+	middleware.Routing(router)
+	router.Use(middleware.TraceID(cfg.TrustIncomingTraceID))
+	router.Use(middleware.RequestLogger(log, cfg.LogExcludePaths...))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.SwitchableBodyLogger(log, bodyLogging, cfg.LogHTTPBodyMaxBytes))
 	router.Use(middleware.ErrorHandler(log))
 	router.Use(middleware.CORS())
+	router.Use(middleware.JSONBodyLimit(int64(cfg.MaxJSONBodyBytes), cfg.JSONBodyReadTimeout))
+	router.Use(middleware.HeaderLimit(cfg.MaxHeaderCount, cfg.MaxHeaderValueBytes))
+	router.Use(middleware.ConcurrencyLimit(cfg.MaxConcurrentRequests))
+	router.Use(middleware.ObfuscateIDs(idCodec(cfg, log)))
+	router.Use(middleware.IDsAsString(cfg.JSONIDsAsString))
+	router.Use(middleware.CamelCaseJSON(cfg.JSONCamelCase))
+
+	// Long-lived streams are tracked so shutdown can close them
+	streams := stream.NewRegistry()
 
 	// Register API routes
-	handler := handlers.NewHandler(grpcClients.Users, grpcClients.Orders)
+	handler := handlers.NewHandler(grpcClients.Users, grpcClients.Orders, cfg.AdminAPIKey)
+	handler.SetLogger(log)
+	handler.SetRateLimiters(userLimiter, anonymousLimiter)
+	handler.SetTenantRequired(cfg.TenantRequired)
+	if cfg.JWTSecret != "" {
+		handler.SetTokenIssuer(auth.NewTokenIssuer(cfg.JWTSecret, cfg.JWTTTL))
+	} else {
+		log.Info("login disabled: JWT_SECRET is not set")
+	}
+	// The API routes act for a tenant; health checks and metrics stay outside
 	api := router.Group("/api/v1")
 	handler.RegisterRoutes(api)
 
 	// Swagger documentation
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/swagger/*any", handlers.SwaggerHandler(cfg.SwaggerHost, cfg.SwaggerBasePath))
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Readiness reflects the health of the backends
+	router.GET("/health/ready", handlers.Readiness([]handlers.Backend{
+		{Name: "users", Client: grpcClients.UsersHealth, Required: cfg.UsersBackendRequired},
+		{Name: "orders", Client: grpcClients.OrdersHealth, Required: cfg.OrdersBackendRequired},
+	}, readinessTimeout))
+
+	// Build and runtime information, for finding out what exactly is running
+	router.GET("/info", func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get("gateway", cfg.Features()))
+	})
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// Root redirect to Swagger
 	router.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusTemporaryRedirect, "/swagger/index.html")
@@ -96,13 +157,13 @@ func main() {
 
 	// Start server
 	if cfg.TLSEnabled {
-		startHTTPSServer(cfg, log, router, ctx)
+		startHTTPSServer(cfg, log, router, streams, ctx)
 	} else {
-		startHTTPServer(cfg, log, router, ctx)
+		startHTTPServer(cfg, log, router, streams, ctx)
 	}
 }
 
-func startHTTPServer(cfg *config.Config, log *logger.Logger, router *gin.Engine, ctx context.Context) {
+func startHTTPServer(cfg *config.Config, log *logger.Logger, router *gin.Engine, streams *stream.Registry, ctx context.Context) {
 	server := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
 		Handler:      router,
@@ -118,10 +179,10 @@ func startHTTPServer(cfg *config.Config, log *logger.Logger, router *gin.Engine,
 		}
 	}()
 
-	waitForShutdown(server, log, ctx)
+	waitForShutdown(server, streams, log, ctx)
 }
 
-func startHTTPSServer(cfg *config.Config, log *logger.Logger, router *gin.Engine, ctx context.Context) {
+func startHTTPSServer(cfg *config.Config, log *logger.Logger, router *gin.Engine, streams *stream.Registry, ctx context.Context) {
 	tlsConfig, err := pkgtls.ServerConfig(cfg.TLSCertFile, cfg.TLSKeyFile, "", false)
 	if err != nil {
 		log.Fatal("failed to load TLS config: " + err.Error())
@@ -143,10 +204,22 @@ func startHTTPSServer(cfg *config.Config, log *logger.Logger, router *gin.Engine
 		}
 	}()
 
-	waitForShutdown(server, log, ctx)
+	waitForShutdown(server, streams, log, ctx)
 }
 
-func waitForShutdown(server *http.Server, log *logger.Logger, ctx context.Context) {
+// idCodec returns the codec hiding sequential IDs, or nil when obfuscation is disabled
+func idCodec(cfg *config.Config, log *logger.Logger) *idcodec.Codec {
+	if !cfg.IDObfuscationEnabled {
+		return nil
+	}
+	if cfg.IDObfuscationSalt == "" {
+		log.Fatal("ID_OBFUSCATION_SALT is required when ID obfuscation is enabled")
+	}
+	log.Info("ID obfuscation enabled")
+	return idcodec.New(cfg.IDObfuscationSalt)
+}
+
+func waitForShutdown(server *http.Server, streams *stream.Registry, log *logger.Logger, ctx context.Context) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -156,6 +229,11 @@ func waitForShutdown(server *http.Server, log *logger.Logger, ctx context.Contex
 	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	// Streams never go idle, so they must be closed before the server can drain
+	if err := streams.Shutdown(shutdownCtx); err != nil {
+		log.Error("stream shutdown error: " + err.Error())
+	}
+
 	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Error("server shutdown error: " + err.Error())
 	}
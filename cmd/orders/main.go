@@ -6,27 +6,40 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	orderspb "go-micro/api/gen/orders/v1"
 	"go-micro/internal/orders/adapters"
 	"go-micro/internal/orders/application"
 	"go-micro/internal/orders/infrastructure"
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/buildinfo"
 	"go-micro/pkg/config"
 	"go-micro/pkg/db"
 	"go-micro/pkg/events"
 	grpcpkg "go-micro/pkg/grpc"
+	"go-micro/pkg/lifecycle"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
 	"go-micro/pkg/middleware"
 	"go-micro/pkg/rabbitmq"
+	"go-micro/pkg/shutdown"
 	"go-micro/pkg/tls"
 )
 
+// readinessTimeout bounds how long /health/ready waits for the database
+const readinessTimeout = 2 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.LoadForService("ORDERS")
@@ -37,7 +50,9 @@ func main() {
 	cfg.HTTPPort = getEnvOrDefault("ORDERS_HTTP_PORT", "8082")
 
 	// Initialize logger
-	log := logger.New("orders-service", cfg.LogLevel)
+	log := logger.New("orders-service", cfg.LogLevel,
+		logger.WithSampling(cfg.LogSamplingInitial, cfg.LogSamplingThereafter),
+	)
 	defer log.Sync()
 
 	log.Info("starting orders service")
@@ -51,37 +66,54 @@ func main() {
 		DBName:   cfg.DBName,
 		SSLMode:  cfg.DBSSLMode,
 		Timeout:  cfg.DBTimeout,
+
+		StatementTimeout: cfg.DBStatementTimeout,
+
+		Logger:        log,
+		SlowThreshold: cfg.DBSlowQuery,
 	})
 	if err != nil {
 		log.Fatal("failed to connect to database: " + err.Error())
 	}
 	log.Info("connected to database")
 
+	if err := db.RegisterPoolMetrics(dbConn, "orders", cfg.DBName); err != nil {
+		log.Warn("failed to register database pool metrics: " + err.Error())
+	}
+
 	// Initialize repository and run migrations
 	repo := adapters.NewPostgresOrderRepository(dbConn)
 	if err := repo.Migrate(); err != nil {
 		log.Fatal("failed to migrate database: " + err.Error())
 	}
 
+	// The user read-model is always kept up to date so async validation can
+	// be switched on without waiting for it to fill
+	userReadModel := adapters.NewPostgresUserReadModel(dbConn)
+	if err := userReadModel.Migrate(); err != nil {
+		log.Fatal("failed to migrate user read-model: " + err.Error())
+	}
+
 	// Connect to users service via gRPC
 	var userClient *adapters.GRPCUserClient
 	userClient, err = adapters.NewGRPCUserClient(cfg)
 	if err != nil {
 		log.Warn("failed to connect to users service: " + err.Error())
 	} else {
-		defer userClient.Close()
 		log.Info("connected to users service")
 	}
 
+	// Background jobs and consumers are stopped together after the servers
+	// drain and before the connections they depend on are closed
+	background := lifecycle.NewManager(log)
+
 	// Connect to RabbitMQ
 	var publisher *adapters.RabbitMQPublisher
 	var rabbitConn *rabbitmq.Connection
-	rabbitConn, err = rabbitmq.NewConnection(cfg.RabbitMQURL, log)
+	rabbitConn, err = rabbitmq.NewConnectionWithRetry(cfg.RabbitMQURL, "orders-service", cfg.RabbitMQConnectAttempts, cfg.RabbitMQConnectBackoff, log)
 	if err != nil {
 		log.Warn("failed to connect to RabbitMQ, events will be disabled: " + err.Error())
 	} else {
-		defer rabbitConn.Close()
-
 		// Setup publisher
 		pub, err := rabbitmq.NewPublisher(rabbitConn, events.ExchangeOrders, log)
 		if err != nil {
@@ -91,34 +123,104 @@ func main() {
 		}
 
 		// Setup consumer for UserCreated events
-		consumer, err := adapters.NewUserCreatedConsumer(rabbitConn, log)
+		consumer, err := adapters.NewUserCreatedConsumer(rabbitConn, cfg.RabbitMQQueuePrefix, userReadModel, log)
 		if err != nil {
 			log.Warn("failed to create UserCreated consumer: " + err.Error())
 		} else {
-			ctx := context.Background()
-			if err := consumer.Start(ctx); err != nil {
-				log.Warn("failed to start consumer: " + err.Error())
-			}
+			consumer.SetDeduplicator(rabbitmq.NewDeduplicator(cfg.ConsumerDedupCacheSize))
+			background.Go("user created consumer", consumer.Run)
 		}
 	}
 
 	// Initialize use case
-	useCase := application.NewOrderUseCase(repo, publisher, userClient, log)
+	// Pass nil interfaces, not typed nil pointers, for missing dependencies
+	var orderPublisher ports.EventPublisher
+	if publisher != nil {
+		orderPublisher = publisher
+	}
+	var users ports.UserClient
+	if userClient != nil {
+		users = userClient
+	}
+	useCase := application.NewOrderUseCase(repo, orderPublisher, users, log)
+	useCase.SetUserReadModel(userReadModel)
+	useCase.SetReopenWindow(cfg.OrderReopenWindow)
+	useCase.SetMaxBatchSize(cfg.MaxBatchSize)
+	useCase.SetMinorUnits(cfg.OrderMinorUnits)
+	deletionPolicy, err := application.ParseUserDeletionPolicy(cfg.UserDeletionPolicy)
+	if err != nil {
+		log.Fatal("invalid ORDERS_USER_DELETION_POLICY: " + err.Error())
+	}
+	useCase.SetUserDeletionPolicy(deletionPolicy)
+	if cfg.OrdersAsyncUserValidation {
+		useCase.EnableAsyncUserValidation(userReadModel)
+		log.Info("async user validation enabled")
+	}
+
+	// Setup consumer for PaymentSucceeded events (confirms orders)
+	if rabbitConn != nil {
+		paymentConsumer, err := adapters.NewPaymentSucceededConsumer(rabbitConn, cfg.RabbitMQQueuePrefix, useCase, log)
+		if err != nil {
+			log.Warn("failed to create PaymentSucceeded consumer: " + err.Error())
+		} else {
+			paymentConsumer.SetDeduplicator(rabbitmq.NewDeduplicator(cfg.ConsumerDedupCacheSize))
+			background.Go("payment succeeded consumer", paymentConsumer.Run)
+		}
+
+		// Setup consumer for UserDeleted events (erases the user's data)
+		deletedConsumer, err := adapters.NewUserDeletedConsumer(rabbitConn, cfg.RabbitMQQueuePrefix, useCase, log)
+		if err != nil {
+			log.Warn("failed to create UserDeleted consumer: " + err.Error())
+		} else {
+			deletedConsumer.SetDeduplicator(rabbitmq.NewDeduplicator(cfg.ConsumerDedupCacheSize))
+			background.Go("user deleted consumer", deletedConsumer.Run)
+		}
+	}
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Start background job that cancels stale pending orders
+	staleJob := infrastructure.NewStaleOrderJob(useCase, log, cfg.StaleOrderCheckInterval, cfg.StaleOrderThreshold, cfg.StaleOrderBatchSize)
+	background.Go("stale order job", staleJob.Run)
+
+	// Start background job that archives old confirmed orders
+	archiveJob := infrastructure.NewOrderArchiveJob(useCase, log, cfg.OrderArchiveInterval, cfg.OrderArchiveRetention, cfg.OrderArchiveBatchSize)
+	background.Go("order archive job", archiveJob.Run)
+
+	// Start background job that reconciles orders created with async user validation
+	if cfg.OrdersAsyncUserValidation {
+		reconcileJob := infrastructure.NewUserReconciliationJob(useCase, log, cfg.UserReconcileInterval, cfg.UserReconcileBatchSize)
+		background.Go("user reconciliation job", reconcileJob.Run)
+	}
+
+	// Readiness gate, set once every startup step has completed
+	ready := &atomic.Bool{}
+
+	// Hot-reloadable settings, updated on SIGHUP
+	bodyLogging := &atomic.Bool{}
+	bodyLogging.Store(cfg.LogHTTPBodies)
+	limiter := grpcpkg.NewRateLimiter(cfg.GRPCRateLimit, cfg.GRPCRateLimitBurst, cfg.GRPCRateLimitPerMethod)
+	if cfg.GRPCRateLimit > 0 {
+		log.Info("gRPC rate limit enabled")
+	}
+
 	// Start HTTP server
-	httpHandler := infrastructure.NewHTTPHandler(useCase)
+	httpHandler := infrastructure.NewHTTPHandler(useCase, cfg.AdminAPIKey)
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(middleware.TraceID())
-	router.Use(middleware.RequestLogger(log))
+	middleware.Routing(router)
+	router.Use(middleware.TraceID(cfg.TrustIncomingTraceID))
+	router.Use(middleware.RequestLogger(log, cfg.LogExcludePaths...))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.SwitchableBodyLogger(log, bodyLogging, cfg.LogHTTPBodyMaxBytes))
 	router.Use(middleware.ErrorHandler(log))
 	router.Use(middleware.CORS())
+	router.Use(middleware.JSONBodyLimit(int64(cfg.MaxJSONBodyBytes), cfg.JSONBodyReadTimeout))
 
-	api := router.Group("/api/v1")
+	// Health checks and metrics stay outside the tenant requirement
+	api := router.Group("/api/v1", middleware.Tenant(cfg.TenantRequired))
 	httpHandler.RegisterRoutes(api)
 
 	// Health check
@@ -126,6 +228,32 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Readiness check: 503 until startup has completed and again once shutdown
+	// starts, and while the database can't serve requests
+	router.GET("/health/ready", func(c *gin.Context) {
+		if !ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+		if err := db.Probe(ctx, dbConn, cfg.DBReadinessQuery); err != nil {
+			log.WithContext(ctx).Warn("readiness database check failed", zap.Error(err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "database": "unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+
+	// Build and runtime information, for finding out what exactly is running
+	router.GET("/info", func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get("orders", cfg.Features()))
+	})
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
 		Handler:      router,
@@ -141,7 +269,7 @@ func main() {
 	}()
 
 	// Start gRPC server
-	grpcServer := setupGRPCServer(cfg, log, useCase)
+	grpcServer, healthServer := setupGRPCServer(cfg, log, useCase, limiter)
 
 	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 	if err != nil {
@@ -155,6 +283,24 @@ func main() {
 		}
 	}()
 
+	// Everything is up: start accepting traffic
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	ready.Store(true)
+	log.Info("service ready")
+
+	// Apply the hot-reloadable subset of the configuration on SIGHUP
+	config.WatchReload(ctx, func(newCfg *config.Config) {
+		log.SetLevel(newCfg.LogLevel)
+		bodyLogging.Store(newCfg.LogHTTPBodies)
+		limiter.SetLimits(newCfg.GRPCRateLimit, newCfg.GRPCRateLimitBurst)
+		log.Info("configuration reloaded",
+			zap.String("log_level", log.Level()),
+			zap.Bool("log_http_bodies", newCfg.LogHTTPBodies),
+			zap.Float64("grpc_rate_limit", newCfg.GRPCRateLimit),
+			zap.Int("grpc_rate_limit_burst", newCfg.GRPCRateLimitBurst),
+		)
+	})
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -162,23 +308,60 @@ func main() {
 
 	log.Info("shutting down servers...")
 
-	// Graceful shutdown
-	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 10*time.Second)
+	// Graceful shutdown: drain servers first, then stop background work,
+	// and only then close the outbound connections they depend on
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
-	grpcServer.GracefulStop()
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Error("HTTP shutdown error: " + err.Error())
+	seq := shutdown.NewSequence(log)
+	// Report NOT_SERVING first so the gateway stops routing here while we drain
+	seq.Add("grpc health", func(ctx context.Context) error {
+		ready.Store(false)
+		healthServer.Shutdown()
+		return nil
+	})
+	seq.Add("grpc server", func(ctx context.Context) error {
+		grpcServer.GracefulStop()
+		return nil
+	})
+	seq.Add("http server", func(ctx context.Context) error {
+		return httpServer.Shutdown(ctx)
+	})
+	seq.Add("background tasks", background.Stop)
+	if userClient != nil {
+		seq.Add("users client", func(ctx context.Context) error {
+			return userClient.Close()
+		})
+	}
+	if rabbitConn != nil {
+		seq.Add("rabbitmq", func(ctx context.Context) error {
+			return rabbitConn.Close()
+		})
 	}
+	seq.Run(shutdownCtx)
 
 	log.Info("servers stopped")
 }
 
-func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *application.OrderUseCase) *grpc.Server {
+func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *application.OrderUseCase, limiter *grpcpkg.RateLimiter) (*grpc.Server, *health.Server) {
 	var opts []grpc.ServerOption
 
-	// Add interceptors
-	opts = append(opts, grpc.UnaryInterceptor(grpcpkg.UnaryServerInterceptor(log, cfg.GRPCTimeout)))
+	// Add interceptors; the rate limiter runs inside the logging interceptor
+	// so rejected calls are logged and mapped to ResourceExhausted, and the
+	// metrics interceptor outside it so it records the mapped codes
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		grpcpkg.UnaryServerMetricsInterceptor(),
+		grpcpkg.UnaryServerInterceptor(log, cfg.GRPCTimeout),
+		grpcpkg.UnaryServerRateLimitInterceptor(limiter),
+	))
+	if cfg.GRPCPayloadMetrics {
+		opts = append(opts, grpc.ChainUnaryInterceptor(grpcpkg.UnaryServerPayloadSizeInterceptor()))
+	}
+	if cfg.GRPCReflection {
+		opts = append(opts, grpc.ChainStreamInterceptor(
+			grpcpkg.StreamServerReflectionInterceptor(cfg.GRPCReflectionRequireClientCert),
+		))
+	}
 
 	// Configure mTLS if enabled
 	if cfg.GRPCMTLSEnabled {
@@ -198,7 +381,20 @@ func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *applicatio
 	server := grpc.NewServer(opts...)
 	orderspb.RegisterOrderServiceServer(server, infrastructure.NewGRPCServer(useCase))
 
-	return server
+	// Standard gRPC health service, checked by the gateway's readiness probe
+	// Starts NOT_SERVING and switches to SERVING once startup has completed
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if cfg.GRPCReflection {
+		reflection.Register(server)
+		if !cfg.GRPCMTLSEnabled && cfg.GRPCReflectionRequireClientCert {
+			log.Warn("gRPC reflection enabled but unusable without mTLS: it requires a client certificate")
+		}
+	}
+
+	return server, healthServer
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -6,27 +6,39 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	userspb "go-micro/api/gen/users/v1"
 	"go-micro/internal/users/adapters"
 	"go-micro/internal/users/application"
+	"go-micro/internal/users/domain"
 	"go-micro/internal/users/infrastructure"
+	"go-micro/pkg/buildinfo"
 	"go-micro/pkg/config"
 	"go-micro/pkg/db"
 	"go-micro/pkg/events"
 	grpcpkg "go-micro/pkg/grpc"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
 	"go-micro/pkg/middleware"
 	"go-micro/pkg/rabbitmq"
+	"go-micro/pkg/shutdown"
 	"go-micro/pkg/tls"
 )
 
+// readinessTimeout bounds how long /health/ready waits for the database
+const readinessTimeout = 2 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.LoadForService("USERS")
@@ -37,7 +49,9 @@ func main() {
 	cfg.HTTPPort = getEnvOrDefault("USERS_HTTP_PORT", "8081") // Puerto diferente al gateway
 
 	// Initialize logger
-	log := logger.New("users-service", cfg.LogLevel)
+	log := logger.New("users-service", cfg.LogLevel,
+		logger.WithSampling(cfg.LogSamplingInitial, cfg.LogSamplingThereafter),
+	)
 	defer log.Sync()
 
 	log.Info("starting users service")
@@ -51,25 +65,37 @@ func main() {
 		DBName:   cfg.DBName,
 		SSLMode:  cfg.DBSSLMode,
 		Timeout:  cfg.DBTimeout,
+
+		StatementTimeout: cfg.DBStatementTimeout,
+
+		Logger:        log,
+		SlowThreshold: cfg.DBSlowQuery,
 	})
 	if err != nil {
 		log.Fatal("failed to connect to database: " + err.Error())
 	}
 	log.Info("connected to database")
 
+	if err := db.RegisterPoolMetrics(dbConn, "users", cfg.DBName); err != nil {
+		log.Warn("failed to register database pool metrics: " + err.Error())
+	}
+
 	// Initialize repository and run migrations
 	repo := adapters.NewPostgresUserRepository(dbConn)
 	if err := repo.Migrate(); err != nil {
 		log.Fatal("failed to migrate database: " + err.Error())
 	}
+	refreshTokens := adapters.NewPostgresRefreshTokenRepository(dbConn)
+	if err := refreshTokens.Migrate(); err != nil {
+		log.Fatal("failed to migrate database: " + err.Error())
+	}
 
 	// Connect to RabbitMQ
 	var publisher *adapters.RabbitMQPublisher
-	rabbitConn, err := rabbitmq.NewConnection(cfg.RabbitMQURL, log)
+	rabbitConn, err := rabbitmq.NewConnectionWithRetry(cfg.RabbitMQURL, "users-service", cfg.RabbitMQConnectAttempts, cfg.RabbitMQConnectBackoff, log)
 	if err != nil {
 		log.Warn("failed to connect to RabbitMQ, events will be disabled: " + err.Error())
 	} else {
-		defer rabbitConn.Close()
 		pub, err := rabbitmq.NewPublisher(rabbitConn, events.ExchangeUsers, log)
 		if err != nil {
 			log.Warn("failed to create publisher: " + err.Error())
@@ -80,21 +106,42 @@ func main() {
 
 	// Initialize use case
 	useCase := application.NewUserUseCase(repo, publisher, log)
+	useCase.SetEmailDomainPolicy(domain.EmailDomainPolicy{
+		Allow: cfg.EmailDomainAllowlist,
+		Block: cfg.EmailDomainBlocklist,
+	})
+	useCase.SetRefreshTokens(refreshTokens, cfg.RefreshTokenTTL)
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Readiness gate, set once every startup step has completed
+	ready := &atomic.Bool{}
+
+	// Hot-reloadable settings, updated on SIGHUP
+	bodyLogging := &atomic.Bool{}
+	bodyLogging.Store(cfg.LogHTTPBodies)
+	limiter := grpcpkg.NewRateLimiter(cfg.GRPCRateLimit, cfg.GRPCRateLimitBurst, cfg.GRPCRateLimitPerMethod)
+	if cfg.GRPCRateLimit > 0 {
+		log.Info("gRPC rate limit enabled")
+	}
+
 	// Start HTTP server
-	httpHandler := infrastructure.NewHTTPHandler(useCase)
+	httpHandler := infrastructure.NewHTTPHandler(useCase, cfg.AdminAPIKey)
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
-	router.Use(middleware.TraceID())
-	router.Use(middleware.RequestLogger(log))
+	middleware.Routing(router)
+	router.Use(middleware.TraceID(cfg.TrustIncomingTraceID))
+	router.Use(middleware.RequestLogger(log, cfg.LogExcludePaths...))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.SwitchableBodyLogger(log, bodyLogging, cfg.LogHTTPBodyMaxBytes))
 	router.Use(middleware.ErrorHandler(log))
 	router.Use(middleware.CORS())
+	router.Use(middleware.JSONBodyLimit(int64(cfg.MaxJSONBodyBytes), cfg.JSONBodyReadTimeout))
 
-	api := router.Group("/api/v1")
+	// Health checks and metrics stay outside the tenant requirement
+	api := router.Group("/api/v1", middleware.Tenant(cfg.TenantRequired))
 	httpHandler.RegisterRoutes(api)
 
 	// Health check
@@ -102,6 +149,32 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{"status": "ok"})
 	})
 
+	// Readiness check: 503 until startup has completed and again once shutdown
+	// starts, and while the database can't serve requests
+	router.GET("/health/ready", func(c *gin.Context) {
+		if !ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+		if err := db.Probe(ctx, dbConn, cfg.DBReadinessQuery); err != nil {
+			log.WithContext(ctx).Warn("readiness database check failed", zap.Error(err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "database": "unavailable"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+
+	// Build and runtime information, for finding out what exactly is running
+	router.GET("/info", func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get("users", cfg.Features()))
+	})
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.HTTPPort,
 		Handler:      router,
@@ -117,7 +190,7 @@ func main() {
 	}()
 
 	// Start gRPC server
-	grpcServer := setupGRPCServer(cfg, log, useCase)
+	grpcServer, healthServer := setupGRPCServer(cfg, log, useCase, limiter)
 
 	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 	if err != nil {
@@ -131,6 +204,24 @@ func main() {
 		}
 	}()
 
+	// Everything is up: start accepting traffic
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	ready.Store(true)
+	log.Info("service ready")
+
+	// Apply the hot-reloadable subset of the configuration on SIGHUP
+	config.WatchReload(ctx, func(newCfg *config.Config) {
+		log.SetLevel(newCfg.LogLevel)
+		bodyLogging.Store(newCfg.LogHTTPBodies)
+		limiter.SetLimits(newCfg.GRPCRateLimit, newCfg.GRPCRateLimitBurst)
+		log.Info("configuration reloaded",
+			zap.String("log_level", log.Level()),
+			zap.Bool("log_http_bodies", newCfg.LogHTTPBodies),
+			zap.Float64("grpc_rate_limit", newCfg.GRPCRateLimit),
+			zap.Int("grpc_rate_limit_burst", newCfg.GRPCRateLimitBurst),
+		)
+	})
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -138,23 +229,53 @@ func main() {
 
 	log.Info("shutting down servers...")
 
-	// Graceful shutdown
+	// Graceful shutdown: drain servers before closing outbound connections
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer shutdownCancel()
 
-	grpcServer.GracefulStop()
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		log.Error("HTTP shutdown error: " + err.Error())
+	seq := shutdown.NewSequence(log)
+	// Report NOT_SERVING first so the gateway stops routing here while we drain
+	seq.Add("grpc health", func(ctx context.Context) error {
+		ready.Store(false)
+		healthServer.Shutdown()
+		return nil
+	})
+	seq.Add("grpc server", func(ctx context.Context) error {
+		grpcServer.GracefulStop()
+		return nil
+	})
+	seq.Add("http server", func(ctx context.Context) error {
+		return httpServer.Shutdown(ctx)
+	})
+	if rabbitConn != nil {
+		seq.Add("rabbitmq", func(ctx context.Context) error {
+			return rabbitConn.Close()
+		})
 	}
+	seq.Run(shutdownCtx)
 
 	log.Info("servers stopped")
 }
 
-func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *application.UserUseCase) *grpc.Server {
+func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *application.UserUseCase, limiter *grpcpkg.RateLimiter) (*grpc.Server, *health.Server) {
 	var opts []grpc.ServerOption
 
-	// Add interceptors
-	opts = append(opts, grpc.UnaryInterceptor(grpcpkg.UnaryServerInterceptor(log, cfg.GRPCTimeout)))
+	// Add interceptors; the rate limiter runs inside the logging interceptor
+	// so rejected calls are logged and mapped to ResourceExhausted, and the
+	// metrics interceptor outside it so it records the mapped codes
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		grpcpkg.UnaryServerMetricsInterceptor(),
+		grpcpkg.UnaryServerInterceptor(log, cfg.GRPCTimeout),
+		grpcpkg.UnaryServerRateLimitInterceptor(limiter),
+	))
+	if cfg.GRPCPayloadMetrics {
+		opts = append(opts, grpc.ChainUnaryInterceptor(grpcpkg.UnaryServerPayloadSizeInterceptor()))
+	}
+	if cfg.GRPCReflection {
+		opts = append(opts, grpc.ChainStreamInterceptor(
+			grpcpkg.StreamServerReflectionInterceptor(cfg.GRPCReflectionRequireClientCert),
+		))
+	}
 
 	// Configure mTLS if enabled
 	if cfg.GRPCMTLSEnabled {
@@ -174,7 +295,20 @@ func setupGRPCServer(cfg *config.Config, log *logger.Logger, useCase *applicatio
 	server := grpc.NewServer(opts...)
 	userspb.RegisterUserServiceServer(server, infrastructure.NewGRPCServer(useCase))
 
-	return server
+	// Standard gRPC health service, checked by the gateway's readiness probe
+	// Starts NOT_SERVING and switches to SERVING once startup has completed
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if cfg.GRPCReflection {
+		reflection.Register(server)
+		if !cfg.GRPCMTLSEnabled && cfg.GRPCReflectionRequireClientCert {
+			log.Warn("gRPC reflection enabled but unusable without mTLS: it requires a client certificate")
+		}
+	}
+
+	return server, healthServer
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
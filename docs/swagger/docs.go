@@ -224,7 +224,8 @@ const docTemplate = `{
                 "id": {"type": "integer", "example": 1},
                 "name": {"type": "string", "example": "John Doe"},
                 "email": {"type": "string", "example": "john@example.com"},
-                "created_at": {"type": "string", "example": "2024-01-15T10:30:00Z"}
+                "created_at": {"type": "string", "example": "2024-01-15T10:30:00Z"},
+                "updated_at": {"type": "string", "example": "2024-01-15T10:30:00Z"}
             }
         },
         "CreateOrderRequest": {
@@ -242,7 +243,8 @@ const docTemplate = `{
                 "user_id": {"type": "integer", "example": 1},
                 "total": {"type": "number", "example": 99.99},
                 "status": {"type": "string", "example": "pending"},
-                "created_at": {"type": "string", "example": "2024-01-15T10:30:00Z"}
+                "created_at": {"type": "string", "example": "2024-01-15T10:30:00Z"},
+                "updated_at": {"type": "string", "example": "2024-01-15T10:30:00Z"}
             }
         },
         "SuccessResponse": {
@@ -264,7 +266,8 @@ const docTemplate = `{
             "properties": {
                 "code": {"type": "string", "example": "VALIDATION_ERROR"},
                 "message": {"type": "string", "example": "Invalid request body"},
-                "details": {"type": "object"}
+                "details": {"type": "object"},
+                "dependency": {"type": "string", "example": "users-service"}
             }
         }
     }
@@ -1,48 +1,151 @@
 package clients
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
 	"go-micro/pkg/config"
 	grpcpkg "go-micro/pkg/grpc"
+	"go-micro/pkg/logger"
 	"go-micro/pkg/tls"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	orderspb "go-micro/api/gen/orders/v1"
 	userspb "go-micro/api/gen/users/v1"
 )
 
-// Clients holds all gRPC clients for the gateway
+const (
+	// retryBudgetCapacity bounds the burst of retries allowed at once
+	retryBudgetCapacity = 10
+	// retryBackoff is the base delay between retries, multiplied by the attempt number
+	retryBackoff = 50 * time.Millisecond
+)
+
+// idempotentMethods are the backend methods safe to retry: they only read.
+// Creates, reopens and refresh token calls are never retried, as a call that
+// failed may still have taken effect.
+var idempotentMethods = []string{
+	"/users.v1.UserService/GetUser",
+	"/users.v1.UserService/ListUsers",
+	"/users.v1.UserService/VerifyCredentials",
+	"/orders.v1.OrderService/GetOrder",
+	"/orders.v1.OrderService/ListOrders",
+	"/orders.v1.OrderService/BatchGetOrders",
+	"/orders.v1.OrderService/GetOrderStats",
+	"/orders.v1.OrderService/GetOrderWindowStats",
+	"/orders.v1.OrderService/ListArchivedOrders",
+}
+
+// Clients holds all gRPC clients for the gateway.
+// A client is nil when its backend could not be initialized.
 type Clients struct {
 	Users  userspb.UserServiceClient
 	Orders orderspb.OrderServiceClient
 
+	// Health clients for the same connections, nil alongside their backend
+	UsersHealth  healthpb.HealthClient
+	OrdersHealth healthpb.HealthClient
+
 	usersConn  *grpc.ClientConn
 	ordersConn *grpc.ClientConn
 }
 
-// NewClients creates all gRPC clients for the gateway
-func NewClients(cfg *config.Config) (*Clients, error) {
+// DependencyName is how error responses name the backend called name
+func DependencyName(name string) string {
+	return name + "-service"
+}
+
+// NewClients creates all gRPC clients for the gateway.
+// A backend that cannot be initialized is logged as degraded and left nil
+// so the gateway can keep serving the healthy ones.
+func NewClients(cfg *config.Config, log *logger.Logger) *Clients {
+	clients := &Clients{}
+
 	// Create users client
-	usersConn, err := createConnection(cfg, cfg.UsersGRPCAddr)
-	if err != nil {
-		return nil, err
+	if usersConn := connectBackend(cfg, log, "users", cfg.UsersGRPCAddr, cfg.UsersBackendRequired); usersConn != nil {
+		clients.Users = userspb.NewUserServiceClient(usersConn)
+		clients.UsersHealth = healthpb.NewHealthClient(usersConn)
+		clients.usersConn = usersConn
 	}
 
 	// Create orders client
-	ordersConn, err := createConnection(cfg, cfg.OrdersGRPCAddr)
+	if ordersConn := connectBackend(cfg, log, "orders", cfg.OrdersGRPCAddr, cfg.OrdersBackendRequired); ordersConn != nil {
+		clients.Orders = orderspb.NewOrderServiceClient(ordersConn)
+		clients.OrdersHealth = healthpb.NewHealthClient(ordersConn)
+		clients.ordersConn = ordersConn
+	}
+
+	return clients
+}
+
+// connectBackend creates the connection to a backend and logs the outcome.
+// Connections are established in the background unless cfg.GRPCDialBlock is
+// set, in which case it waits up to cfg.GRPCDialTimeout for the backend and
+// exits if a required one can't be reached. An unreachable optional backend
+// keeps connecting in the background.
+func connectBackend(cfg *config.Config, log *logger.Logger, name, addr string, required bool) *grpc.ClientConn {
+	conn, err := createConnection(cfg, name, addr)
 	if err != nil {
-		usersConn.Close()
-		return nil, err
+		log.Error(name+" backend degraded: failed to create gRPC client",
+			zap.String("addr", addr),
+			zap.Error(err),
+		)
+		return nil
 	}
 
-	return &Clients{
-		Users:      userspb.NewUserServiceClient(usersConn),
-		Orders:     orderspb.NewOrderServiceClient(ordersConn),
-		usersConn:  usersConn,
-		ordersConn: ordersConn,
-	}, nil
+	if !cfg.GRPCDialBlock {
+		log.Info(name+" backend gRPC client created, connecting in the background",
+			zap.String("addr", addr),
+		)
+		return conn
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPCDialTimeout)
+	defer cancel()
+	if err := waitForReady(ctx, conn); err != nil {
+		if required {
+			log.Fatal(name+" backend unreachable at startup",
+				zap.String("addr", addr),
+				zap.Error(err),
+			)
+		}
+		log.Warn(name+" backend unreachable at startup, connecting in the background",
+			zap.String("addr", addr),
+			zap.Error(err),
+		)
+		return conn
+	}
+
+	log.Info(name+" backend connected",
+		zap.String("addr", addr),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return conn
+}
+
+// waitForReady starts connecting conn and waits until it is ready or ctx ends.
+// It replaces the blocking grpc.WithBlock dial, which grpc.NewClient drops.
+func waitForReady(ctx context.Context, conn *grpc.ClientConn) error {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return nil
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("connection not ready (last state %s): %w", state, ctx.Err())
+		}
+	}
 }
 
 // Close closes all gRPC connections
@@ -56,11 +159,24 @@ func (c *Clients) Close() error {
 	return nil
 }
 
-func createConnection(cfg *config.Config, addr string) (*grpc.ClientConn, error) {
+func createConnection(cfg *config.Config, name, addr string) (*grpc.ClientConn, error) {
 	var opts []grpc.DialOption
 
-	// Add client interceptor
-	opts = append(opts, grpc.WithUnaryInterceptor(grpcpkg.UnaryClientInterceptor(cfg.GRPCTimeout)))
+	// Add client interceptors. Each backend gets its own retry budget and
+	// circuit breaker so an outage in one doesn't affect calls to the other.
+	// The breaker sits outside the retries so a retried call counts once.
+	// Failures of the backend name it as the dependency in error responses.
+	budget := grpcpkg.NewRetryBudget(cfg.GRPCRetryBudgetRatio, retryBudgetCapacity)
+	var breaker *grpcpkg.CircuitBreaker
+	if cfg.GRPCBreakerFailureThreshold > 0 {
+		breaker = grpcpkg.NewCircuitBreaker(cfg.GRPCBreakerFailureThreshold, cfg.GRPCBreakerCooldown)
+	}
+	opts = append(opts, grpc.WithChainUnaryInterceptor(
+		grpcpkg.UnaryClientDependencyInterceptor(DependencyName(name)),
+		grpcpkg.UnaryClientInterceptor(cfg.GRPCTimeout),
+		grpcpkg.UnaryClientCircuitBreakerInterceptor(breaker),
+		grpcpkg.UnaryClientRetryInterceptor(budget, cfg.GRPCRetryMaxAttempts, retryBackoff, idempotentMethods),
+	))
 
 	// Configure TLS/mTLS
 	if cfg.GRPCMTLSEnabled {
@@ -77,5 +193,13 @@ func createConnection(cfg *config.Config, addr string) (*grpc.ClientConn, error)
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	// Bound each connection attempt; failed attempts are retried with backoff
+	if cfg.GRPCDialTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: cfg.GRPCDialTimeout,
+		}))
+	}
+
 	return grpc.Dial(addr, opts...)
 }
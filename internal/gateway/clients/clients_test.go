@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"go-micro/pkg/grpc/grpctest"
+)
+
+func TestWaitForReady(t *testing.T) {
+	t.Run("reachable backend", func(t *testing.T) {
+		// Arrange
+		server := grpctest.NewServer(t, func(s *grpc.Server) {})
+		conn, err := grpc.Dial(grpctest.Target, append(server.DialOptions(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Act
+		err = waitForReady(ctx, conn)
+
+		// Assert
+		if err != nil {
+			t.Errorf("expected the connection to become ready, got %v", err)
+		}
+	})
+
+	t.Run("unreachable backend", func(t *testing.T) {
+		// Arrange: a dialer that always fails, as a backend that is down
+		conn, err := grpc.Dial("unreachable",
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return nil, errors.New("connection refused")
+			}),
+		)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// Act
+		err = waitForReady(ctx, conn)
+
+		// Assert
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline error, got %v", err)
+		}
+	})
+}
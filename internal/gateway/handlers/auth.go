@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	userspb "go-micro/api/gen/users/v1"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/middleware"
+)
+
+// tokenType is how clients present the issued token: Authorization: Bearer <token>
+const tokenType = "Bearer"
+
+// LoginRequest represents the request body for logging in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"john@example.com"`
+	Password string `json:"password" binding:"required" example:"correct horse battery"`
+}
+
+// RefreshRequest represents the request body for refreshing or revoking tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse represents an issued access token and the refresh token that
+// replaces it once it expires
+type TokenResponse struct {
+	AccessToken      string       `json:"access_token"`
+	TokenType        string       `json:"token_type" example:"Bearer"`
+	ExpiresAt        string       `json:"expires_at" example:"2024-01-15T11:30:00Z"`
+	RefreshToken     string       `json:"refresh_token"`
+	RefreshExpiresAt string       `json:"refresh_expires_at" example:"2024-02-14T10:30:00Z"`
+	User             UserResponse `json:"user"`
+}
+
+// Login verifies a user's credentials and issues an access and a refresh token
+// @Summary Log in
+// @Description Exchange an email and password for a signed access token (HS256 JWT) and a single-use refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Param request body LoginRequest true "Credentials"
+// @Success 200 {object} SuccessResponse{data=TokenResponse} "Logged in successfully"
+// @Failure 400 {object} ErrorResponse "Validation error"
+// @Failure 401 {object} ErrorResponse "Invalid email or password"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users service unavailable"
+// @Router /api/v1/auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	user, err := h.usersClient.VerifyCredentials(c.Request.Context(), &userspb.VerifyCredentialsRequest{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	refresh, err := h.usersClient.IssueRefreshToken(c.Request.Context(), &userspb.IssueRefreshTokenRequest{
+		UserId: user.GetId(),
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	h.respondTokens(c, refresh)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token works once; replaying a used one revokes every token issued since the login it came from
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} SuccessResponse{data=TokenResponse} "Tokens refreshed successfully"
+// @Failure 400 {object} ErrorResponse "Validation error"
+// @Failure 401 {object} ErrorResponse "Invalid, expired, used or revoked refresh token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users service unavailable"
+// @Router /api/v1/auth/refresh [post]
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	refresh, err := h.usersClient.RotateRefreshToken(c.Request.Context(), &userspb.RotateRefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	h.respondTokens(c, refresh)
+}
+
+// Logout revokes a refresh token and every token rotated from the same login
+// @Summary Log out
+// @Description Revoke a refresh token and every refresh token issued since the login it came from. Access tokens already issued stay valid until they expire
+// @Tags auth
+// @Accept json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 204 "Logged out"
+// @Failure 400 {object} ErrorResponse "Validation error"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users service unavailable"
+// @Router /api/v1/auth/logout [post]
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	_, err := h.usersClient.RevokeRefreshToken(c.Request.Context(), &userspb.RevokeRefreshTokenRequest{
+		RefreshToken: req.RefreshToken,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// respondTokens issues an access token for the user of refresh and writes
+// both tokens
+func (h *Handler) respondTokens(c *gin.Context, refresh *userspb.RefreshTokenResponse) {
+	user := refresh.GetUser()
+	token, expiresAt, err := h.tokens.IssueForTenant(strconv.FormatUint(user.GetId(), 10), user.GetRole(), user.GetTenantId())
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, TokenResponse{
+		AccessToken:      token,
+		TokenType:        tokenType,
+		ExpiresAt:        userspb.FormatTime(expiresAt),
+		RefreshToken:     refresh.GetRefreshToken(),
+		RefreshExpiresAt: refresh.GetExpiresAt(),
+		User: UserResponse{
+			ID:        uint(user.GetId()),
+			Name:      user.GetName(),
+			Email:     user.GetEmail(),
+			CreatedAt: user.GetCreatedAt(),
+			UpdatedAt: user.GetUpdatedAt(),
+		},
+	})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	orderspb "go-micro/api/gen/orders/v1"
+	userspb "go-micro/api/gen/users/v1"
+	"go-micro/internal/gateway/clients"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/middleware"
+)
+
+// dashboardRecentOrders is how many of a user's latest orders the dashboard shows
+const dashboardRecentOrders = 5
+
+// Dashboard sections that may be missing, as named in UserDashboard.Errors
+const (
+	sectionRecentOrders = "recent_orders"
+	sectionOrderStats   = "order_stats"
+)
+
+// cancelledStatus is excluded from an order summary's total spent
+const cancelledStatus = "cancelled"
+
+// OrderStatsResponse summarizes a user's orders
+type OrderStatsResponse struct {
+	TotalOrders int64 `json:"total_orders" example:"3"`
+	// TotalSpent sums the totals of the orders that weren't cancelled
+	TotalSpent float64          `json:"total_spent" example:"149.97"`
+	ByStatus   map[string]int64 `json:"by_status"`
+}
+
+// SectionError explains why a dashboard section is missing
+type SectionError struct {
+	Code    string `json:"code" example:"SERVICE_UNAVAILABLE"`
+	Message string `json:"message" example:"orders service is unavailable"`
+	// Dependency names the backend that failed, if the failure was its own
+	Dependency string `json:"dependency,omitempty" example:"orders-service"`
+}
+
+// UserDashboard combines a user's profile with an overview of their orders.
+// Order sections that could not be loaded are null and explained in Errors.
+type UserDashboard struct {
+	User         UserResponse            `json:"user"`
+	RecentOrders []OrderResponse         `json:"recent_orders"`
+	OrderStats   *OrderStatsResponse     `json:"order_stats"`
+	Errors       map[string]SectionError `json:"errors,omitempty"`
+}
+
+// GetUserDashboard returns a user's profile, latest orders and order summary
+// @Summary Get a user's dashboard
+// @Description Retrieve a user's profile together with their latest orders and a summary of their orders by status (the user or an admin only). The order sections come from the orders service: if it fails they are null and the reason is given under errors, while the profile is still returned.
+// @Tags users
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse{data=UserDashboard} "Dashboard retrieved, possibly with missing order sections"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is neither the user nor an admin"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users service unavailable"
+// @Router /api/v1/users/{id}/dashboard [get]
+func (h *Handler) GetUserDashboard(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		middleware.RespondError(c, errors.NewValidation("invalid user id", nil))
+		return
+	}
+
+	start := time.Now()
+	dashboard := UserDashboard{Errors: map[string]SectionError{}}
+
+	// The sections are fetched concurrently with the request context, which
+	// carries the trace ID to both services. The profile is required: if it
+	// fails the dashboard fails and the order calls are cancelled. The order
+	// sections fail on their own and are reported in Errors.
+	var (
+		user                *userspb.UserResponse
+		recentErr, statsErr error
+	)
+	g, ctx := errgroup.WithContext(c.Request.Context())
+	g.Go(func() error {
+		var err error
+		user, err = h.usersClient.GetUser(ctx, &userspb.GetUserRequest{Id: id})
+		return err
+	})
+	if h.ordersClient != nil {
+		g.Go(func() error {
+			dashboard.RecentOrders, recentErr = h.recentOrders(ctx, id)
+			return nil
+		})
+		g.Go(func() error {
+			dashboard.OrderStats, statsErr = h.orderStats(ctx, id)
+			return nil
+		})
+	} else {
+		recentErr = errors.New(errors.CodeUnavailable, "orders service is unavailable",
+			errors.WithDependency(clients.DependencyName("orders")))
+		statsErr = recentErr
+	}
+	if err := g.Wait(); err != nil {
+		h.logDashboard(c, id, start, err, nil)
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	var failed []string
+	for _, section := range []struct {
+		name string
+		err  error
+	}{{sectionRecentOrders, recentErr}, {sectionOrderStats, statsErr}} {
+		if section.err == nil {
+			continue
+		}
+		dashboard.Errors[section.name] = newSectionError(section.err)
+		failed = append(failed, section.name)
+	}
+	h.logDashboard(c, id, start, nil, failed)
+
+	dashboard.User = UserResponse{
+		ID:        uint(user.GetId()),
+		Name:      user.GetName(),
+		Email:     user.GetEmail(),
+		CreatedAt: user.GetCreatedAt(),
+		UpdatedAt: user.GetUpdatedAt(),
+	}
+	middleware.RespondSuccess(c, http.StatusOK, dashboard)
+}
+
+// newSectionError describes err for a client
+func newSectionError(err error) SectionError {
+	appErr := errors.FromGRPCStatus(err)
+	return SectionError{Code: appErr.Code, Message: appErr.Message, Dependency: appErr.Dependency}
+}
+
+// recentOrders returns a user's latest orders, newest first
+func (h *Handler) recentOrders(ctx context.Context, userID uint64) ([]OrderResponse, error) {
+	resp, err := h.ordersClient.ListOrders(ctx, &orderspb.ListOrdersRequest{
+		UserId: userID,
+		Page:   &orderspb.PageRequest{PageSize: dashboardRecentOrders},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]OrderResponse, len(resp.GetOrders()))
+	for i, order := range resp.GetOrders() {
+		orders[i] = OrderResponse{
+			ID:        uint(order.GetId()),
+			UserID:    uint(order.GetUserId()),
+			Total:     order.GetTotal(),
+			Status:    order.GetStatus(),
+			CreatedAt: order.GetCreatedAt(),
+			UpdatedAt: order.GetUpdatedAt(),
+		}
+	}
+	return orders, nil
+}
+
+// orderStats summarizes a user's orders from their per-status stats
+func (h *Handler) orderStats(ctx context.Context, userID uint64) (*OrderStatsResponse, error) {
+	resp, err := h.ordersClient.GetOrderStats(ctx, &orderspb.GetOrderStatsRequest{UserId: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &OrderStatsResponse{ByStatus: map[string]int64{}}
+	for _, status := range resp.GetStatuses() {
+		stats.TotalOrders += status.GetCount()
+		stats.ByStatus[status.GetStatus()] = status.GetCount()
+		if status.GetStatus() != cancelledStatus {
+			stats.TotalSpent += status.GetTotal()
+		}
+	}
+	return stats, nil
+}
+
+// logDashboard logs how long the whole dashboard took to assemble, and which
+// sections failed or the error that failed it
+func (h *Handler) logDashboard(c *gin.Context, userID uint64, start time.Time, err error, failedSections []string) {
+	if h.log == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Uint64("user_id", userID),
+		zap.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		h.log.WithContext(c.Request.Context()).Warn("user dashboard failed", append(fields, zap.Error(err))...)
+		return
+	}
+	if len(failedSections) > 0 {
+		h.log.WithContext(c.Request.Context()).Warn("user dashboard partially assembled",
+			append(fields, zap.Strings("failed_sections", failedSections))...)
+		return
+	}
+	h.log.WithContext(c.Request.Context()).Info("user dashboard assembled", fields...)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+
+	orderspb "go-micro/api/gen/orders/v1"
+	userspb "go-micro/api/gen/users/v1"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/middleware"
+	"go-micro/pkg/pagination"
+)
+
+// exportFilename is the name suggested to clients saving a user export. It
+// does not include the user ID, which may be obfuscated for clients.
+const exportFilename = "user-export.json"
+
+// UserExport holds everything stored about a user, for data-subject requests
+type UserExport struct {
+	ExportedAt string          `json:"exported_at" example:"2024-01-15T10:30:00Z"`
+	User       UserResponse    `json:"user"`
+	Orders     []OrderResponse `json:"orders"`
+	// ArchivedOrders are old confirmed orders moved to the archive
+	ArchivedOrders []OrderResponse `json:"archived_orders"`
+}
+
+// ExportUser returns a user's profile and all their orders, archived ones
+// included, as one document
+// @Summary Export a user's data
+// @Description Retrieve a user's profile and all their orders, archived ones included, as a downloadable JSON document, for data-subject access requests (the user themself or an admin)
+// @Tags users
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} SuccessResponse{data=UserExport} "User data exported successfully"
+// @Header 200 {string} Content-Disposition "attachment; filename=user-export.json"
+// @Failure 400 {object} ErrorResponse "Invalid user ID"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is neither the user nor an admin"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users or orders service unavailable"
+// @Router /api/v1/users/{id}/export [get]
+func (h *Handler) ExportUser(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		middleware.RespondError(c, errors.NewValidation("invalid user id", nil))
+		return
+	}
+
+	// The profile and the orders come from different services: fetch them
+	// concurrently and fail the export if any part is incomplete
+	var (
+		user           *userspb.UserResponse
+		orders         []OrderResponse
+		archivedOrders []OrderResponse
+	)
+	g, ctx := errgroup.WithContext(c.Request.Context())
+	g.Go(func() error {
+		var err error
+		user, err = h.usersClient.GetUser(ctx, &userspb.GetUserRequest{Id: id})
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		orders, err = exportOrders(ctx, h.ordersClient.ListOrders, id)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		archivedOrders, err = exportOrders(ctx, h.ordersClient.ListArchivedOrders, id)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+exportFilename+`"`)
+	middleware.RespondSuccess(c, http.StatusOK, UserExport{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		User: UserResponse{
+			ID:        uint(user.GetId()),
+			Name:      user.GetName(),
+			Email:     user.GetEmail(),
+			CreatedAt: user.GetCreatedAt(),
+			UpdatedAt: user.GetUpdatedAt(),
+		},
+		Orders:         orders,
+		ArchivedOrders: archivedOrders,
+	})
+}
+
+// listOrdersFunc is the signature shared by ListOrders and ListArchivedOrders
+type listOrdersFunc func(ctx context.Context, in *orderspb.ListOrdersRequest, opts ...grpc.CallOption) (*orderspb.ListOrdersResponse, error)
+
+// exportOrders pages through all of a user's orders returned by list. The
+// result is never nil, so an empty list serializes as [].
+func exportOrders(ctx context.Context, list listOrdersFunc, userID uint64) ([]OrderResponse, error) {
+	orders := []OrderResponse{}
+	pageToken := ""
+	for {
+		resp, err := list(ctx, &orderspb.ListOrdersRequest{
+			UserId: userID,
+			Page: &orderspb.PageRequest{
+				PageSize:  pagination.MaxPageSize,
+				PageToken: pageToken,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range resp.GetOrders() {
+			orders = append(orders, OrderResponse{
+				ID:        uint(order.GetId()),
+				UserID:    uint(order.GetUserId()),
+				Total:     order.GetTotal(),
+				Status:    order.GetStatus(),
+				CreatedAt: order.GetCreatedAt(),
+				UpdatedAt: order.GetUpdatedAt(),
+			})
+		}
+		if pageToken = resp.GetPage().GetNextPageToken(); pageToken == "" {
+			return orders, nil
+		}
+	}
+}
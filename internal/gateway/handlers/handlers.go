@@ -8,38 +8,138 @@ import (
 
 	orderspb "go-micro/api/gen/orders/v1"
 	userspb "go-micro/api/gen/users/v1"
+	"go-micro/internal/gateway/clients"
+	"go-micro/pkg/auth"
 	"go-micro/pkg/errors"
+	"go-micro/pkg/logger"
 	"go-micro/pkg/middleware"
+	"go-micro/pkg/ratelimit"
 )
 
+// idempotencyKeyHeader carries the client's key for retrying order creation
+const idempotencyKeyHeader = "Idempotency-Key"
+
 // Handler handles all gateway HTTP requests
 type Handler struct {
 	usersClient  userspb.UserServiceClient
 	ordersClient orderspb.OrderServiceClient
+	adminAPIKey  string
+
+	// tokens issues login tokens; login is disabled when nil
+	tokens *auth.TokenIssuer
+
+	// log records aggregated requests such as the user dashboard; may be nil
+	log *logger.Logger
+
+	// userLimiter and anonymousLimiter rate limit authenticated and anonymous
+	// callers; nil leaves them unlimited
+	userLimiter      *ratelimit.Limiter
+	anonymousLimiter *ratelimit.Limiter
+
+	// tenantRequired rejects API requests that act for no tenant
+	tenantRequired bool
 }
 
 // NewHandler creates a new gateway handler
-func NewHandler(usersClient userspb.UserServiceClient, ordersClient orderspb.OrderServiceClient) *Handler {
+func NewHandler(usersClient userspb.UserServiceClient, ordersClient orderspb.OrderServiceClient, adminAPIKey string) *Handler {
 	return &Handler{
 		usersClient:  usersClient,
 		ordersClient: ordersClient,
+		adminAPIKey:  adminAPIKey,
 	}
 }
 
+// SetTokenIssuer enables the /auth endpoints, which issue access tokens with
+// issuer; call it before RegisterRoutes
+func (h *Handler) SetTokenIssuer(issuer *auth.TokenIssuer) {
+	h.tokens = issuer
+}
+
+// SetLogger sets the logger used for handlers that aggregate several backend
+// calls; without one they don't log
+func (h *Handler) SetLogger(log *logger.Logger) {
+	h.log = log
+}
+
+// SetRateLimiters limits each authenticated caller with users and each
+// anonymous client IP with anonymous; call it before RegisterRoutes
+func (h *Handler) SetRateLimiters(users, anonymous *ratelimit.Limiter) {
+	h.userLimiter = users
+	h.anonymousLimiter = anonymous
+}
+
+// SetTenantRequired makes every API request act for a tenant, from the
+// caller's token or the X-Tenant-ID header; call it before RegisterRoutes
+func (h *Handler) SetTenantRequired(required bool) {
+	h.tenantRequired = required
+}
+
 // RegisterRoutes registers all gateway routes
 func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
+	// Callers authenticate with an access token or the admin API key, act
+	// for the tenant their token belongs to, and are rate limited as who
+	// they authenticated as
+	r.Use(middleware.Authenticate(h.tokens, h.adminAPIKey))
+	r.Use(middleware.Tenant(h.tenantRequired))
+	r.Use(middleware.RateLimit(h.userLimiter, h.anonymousLimiter))
+	requireAdmin := middleware.RequireRole(auth.RoleAdmin)
+	// Listing orders needs the admin role, unless callers list their own
+	requireOwnOrdersOrAdmin := middleware.RequireSubjectOrRole(auth.RoleAdmin, func(c *gin.Context) string {
+		return c.Query("user_id")
+	})
+	// A user's dashboard and data export are for that user and admins
+	requireSameUserOrAdmin := middleware.RequireSubjectOrRole(auth.RoleAdmin, func(c *gin.Context) string {
+		return c.Param("id")
+	})
+
+	// Auth endpoints
+	if h.tokens != nil {
+		authGroup := r.Group("/auth")
+		middleware.CORSGroup(authGroup, http.MethodPost)
+		authGroup.Use(h.requireBackend("users", h.usersClient != nil))
+		{
+			authGroup.POST("/login", h.Login)
+			authGroup.POST("/refresh", h.Refresh)
+			authGroup.POST("/logout", h.Logout)
+		}
+	}
+
 	// Users endpoints
 	users := r.Group("/users")
+	middleware.CORSGroup(users, http.MethodGet, http.MethodPost)
+	users.Use(h.requireBackend("users", h.usersClient != nil))
 	{
 		users.POST("", h.CreateUser)
+		users.GET("", requireAdmin, h.ListUsers)
 		users.GET("/:id", h.GetUser)
+		users.GET("/:id/dashboard", requireSameUserOrAdmin, h.GetUserDashboard)
+		users.GET("/:id/export", requireSameUserOrAdmin,
+			h.requireBackend("orders", h.ordersClient != nil), h.ExportUser)
 	}
 
 	// Orders endpoints
 	orders := r.Group("/orders")
+	middleware.CORSGroup(orders, http.MethodGet, http.MethodPost)
+	orders.Use(h.requireBackend("orders", h.ordersClient != nil))
 	{
 		orders.POST("", h.CreateOrder)
+		orders.GET("", requireOwnOrdersOrAdmin, h.ListOrders)
 		orders.GET("/:id", h.GetOrder)
+		orders.POST("/batch-get", h.BatchGetOrders)
+		orders.GET("/stats", requireAdmin, h.GetOrderWindowStats)
+		orders.POST("/:id/reopen", requireAdmin, h.ReopenOrder)
+	}
+}
+
+// requireBackend rejects requests with 503 when the backend client is unavailable
+func (h *Handler) requireBackend(name string, available bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !available {
+			middleware.RespondError(c, errors.New(errors.CodeUnavailable, name+" service is unavailable",
+				errors.WithDependency(clients.DependencyName(name))))
+			return
+		}
+		c.Next()
 	}
 }
 
@@ -51,6 +151,8 @@ func (h *Handler) RegisterRoutes(r *gin.RouterGroup) {
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required" example:"John Doe"`
 	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+	// Password is optional; users without one can't log in
+	Password string `json:"password" example:"correct horse battery"`
 }
 
 // UserResponse represents a user in responses
@@ -58,7 +160,8 @@ type UserResponse struct {
 	ID        uint   `json:"id" example:"1"`
 	Name      string `json:"name" example:"John Doe"`
 	Email     string `json:"email" example:"john@example.com"`
-	CreatedAt string `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	CreatedAt string `json:"created_at,omitempty" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt string `json:"updated_at,omitempty" example:"2024-01-15T10:30:00Z"`
 }
 
 // CreateOrderRequest represents the request body for creating an order
@@ -73,10 +176,58 @@ type OrderResponse struct {
 	UserID    uint    `json:"user_id" example:"1"`
 	Total     float64 `json:"total" example:"99.99"`
 	Status    string  `json:"status" example:"pending"`
-	CreatedAt string  `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	CreatedAt string  `json:"created_at,omitempty" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt string  `json:"updated_at,omitempty" example:"2024-01-15T10:30:00Z"`
+}
+
+// BatchGetOrdersRequest represents the request body for getting several orders
+type BatchGetOrdersRequest struct {
+	IDs []uint64 `json:"ids" binding:"required,min=1" example:"1,2,3"`
+}
+
+// BatchGetOrdersResponse represents the orders found and the requested IDs
+// that weren't, each in request order
+type BatchGetOrdersResponse struct {
+	Orders     []OrderResponse `json:"orders"`
+	MissingIDs []uint64        `json:"missing_ids" example:"3"`
+}
+
+// PageQuery represents the pagination query parameters
+type PageQuery struct {
+	PageSize  int32  `form:"page_size" example:"20"`
+	PageToken string `form:"page_token" example:""`
+}
+
+// ListOrdersQuery represents the query parameters for listing orders
+type ListOrdersQuery struct {
+	PageQuery
+	UserID uint64 `form:"user_id" example:"1"`
+}
+
+// OrderWindowStatsQuery selects the rolling window of the order stats
+type OrderWindowStatsQuery struct {
+	// Window is one of 24h, 7d or 30d; the orders service defaults it to 24h
+	Window string `form:"window" example:"7d"`
+}
+
+// OrderWindowStatsResponse summarizes the orders created within a window
+type OrderWindowStatsResponse struct {
+	Window string `json:"window" example:"7d"`
+	// Since is when the window starts
+	Since string `json:"since" example:"2024-01-08T10:30:00Z"`
+	Count int64  `json:"count" example:"42"`
+	// Revenue sums the totals of the orders that weren't cancelled
+	Revenue float64 `json:"revenue" example:"1249.58"`
 }
 
-// SuccessResponse is the standard success response
+// ListResponse represents a page of items in responses
+type ListResponse struct {
+	Items         interface{} `json:"items"`
+	Total         int64       `json:"total" example:"42"`
+	NextPageToken string      `json:"next_page_token,omitempty" example:"cGFnZToy"`
+}
+
+// SuccessResponse documents the envelope written by middleware.RespondSuccess
 type SuccessResponse struct {
 	Data    interface{} `json:"data"`
 	TraceID string      `json:"trace_id" example:"550e8400-e29b-41d4-a716-446655440000"`
@@ -93,6 +244,8 @@ type ErrorBody struct {
 	Code    string      `json:"code" example:"VALIDATION_ERROR"`
 	Message string      `json:"message" example:"Invalid request body"`
 	Details interface{} `json:"details,omitempty"`
+	// Dependency names the backend whose failure caused the error
+	Dependency string `json:"dependency,omitempty" example:"users-service"`
 }
 
 // =============================================================================
@@ -101,40 +254,41 @@ type ErrorBody struct {
 
 // CreateUser creates a new user
 // @Summary Create a new user
-// @Description Create a new user with name and email
+// @Description Create a new user with name and email, and optionally a password to log in with
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
 // @Param request body CreateUserRequest true "User creation request"
 // @Success 201 {object} SuccessResponse{data=UserResponse} "User created successfully"
+// @Header 201 {string} Location "URL of the created user"
 // @Failure 400 {object} ErrorResponse "Validation error"
 // @Failure 409 {object} ErrorResponse "Email already exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/users [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	var req CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(errors.NewValidation("invalid request body", err.Error()))
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
 	resp, err := h.usersClient.CreateUser(c.Request.Context(), &userspb.CreateUserRequest{
-		Name:  req.Name,
-		Email: req.Email,
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
 	})
 	if err != nil {
-		c.Error(errors.FromGRPCStatus(err))
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, SuccessResponse{
-		Data: UserResponse{
-			ID:        uint(resp.GetId()),
-			Name:      resp.GetName(),
-			Email:     resp.GetEmail(),
-			CreatedAt: resp.GetCreatedAt(),
-		},
-		TraceID: c.GetString(middleware.TraceIDKey),
+	middleware.RespondCreated(c, resp.GetId(), UserResponse{
+		ID:        uint(resp.GetId()),
+		Name:      resp.GetName(),
+		Email:     resp.GetEmail(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
 	})
 }
 
@@ -144,8 +298,11 @@ func (h *Handler) CreateUser(c *gin.Context) {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
 // @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} SuccessResponse{data=UserResponse} "User retrieved successfully"
+// @Success 304 "User not modified"
 // @Failure 400 {object} ErrorResponse "Invalid user ID"
 // @Failure 404 {object} ErrorResponse "User not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -154,7 +311,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		c.Error(errors.NewValidation("invalid user id", nil))
+		middleware.RespondError(c, errors.NewValidation("invalid user id", nil))
 		return
 	}
 
@@ -162,18 +319,72 @@ func (h *Handler) GetUser(c *gin.Context) {
 		Id: id,
 	})
 	if err != nil {
-		c.Error(errors.FromGRPCStatus(err))
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{
-		Data: UserResponse{
-			ID:        uint(resp.GetId()),
-			Name:      resp.GetName(),
-			Email:     resp.GetEmail(),
-			CreatedAt: resp.GetCreatedAt(),
+	user := UserResponse{
+		ID:        uint(resp.GetId()),
+		Name:      resp.GetName(),
+		Email:     resp.GetEmail(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
+	}
+	if middleware.NotModified(c, user) {
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, user)
+}
+
+// ListUsers retrieves a page of users
+// @Summary List users
+// @Description Retrieve a page of users (admin only)
+// @Tags users
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Security ApiKeyAuth
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param page_token query string false "Token of the page to retrieve"
+// @Success 200 {object} SuccessResponse{data=ListResponse{items=[]UserResponse}} "Users retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is not an admin"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/users [get]
+func (h *Handler) ListUsers(c *gin.Context) {
+	var query PageQuery
+	if err := middleware.BindQuery(c, &query); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	resp, err := h.usersClient.ListUsers(c.Request.Context(), &userspb.ListUsersRequest{
+		Page: &userspb.PageRequest{
+			PageSize:  query.PageSize,
+			PageToken: query.PageToken,
 		},
-		TraceID: c.GetString(middleware.TraceIDKey),
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	items := make([]UserResponse, len(resp.GetUsers()))
+	for i, user := range resp.GetUsers() {
+		items[i] = UserResponse{
+			ID:        uint(user.GetId()),
+			Name:      user.GetName(),
+			Email:     user.GetEmail(),
+			CreatedAt: user.GetCreatedAt(),
+			UpdatedAt: user.GetUpdatedAt(),
+		}
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, ListResponse{
+		Items:         items,
+		Total:         resp.GetPage().GetTotal(),
+		NextPageToken: resp.GetPage().GetNextPageToken(),
 	})
 }
 
@@ -187,36 +398,40 @@ func (h *Handler) GetUser(c *gin.Context) {
 // @Tags orders
 // @Accept json
 // @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
 // @Param request body CreateOrderRequest true "Order creation request"
-// @Success 201 {object} SuccessResponse{data=OrderResponse} "Order created successfully"
+// @Param Idempotency-Key header string false "Retries with the same key return the order already created instead of another one"
+// @Success 201 {object} SuccessResponse{data=OrderResponse} "Order created successfully, or already created with the idempotency key"
+// @Header 201 {string} Location "URL of the created order"
 // @Failure 400 {object} ErrorResponse "Validation error (including user not found)"
+// @Failure 409 {object} ErrorResponse "Idempotency key already used for a different order"
 // @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Users service timed out or is unavailable"
 // @Router /api/v1/orders [post]
 func (h *Handler) CreateOrder(c *gin.Context) {
 	var req CreateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(errors.NewValidation("invalid request body", err.Error()))
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
 	resp, err := h.ordersClient.CreateOrder(c.Request.Context(), &orderspb.CreateOrderRequest{
-		UserId: uint64(req.UserID),
-		Total:  req.Total,
+		UserId:         uint64(req.UserID),
+		Total:          req.Total,
+		IdempotencyKey: c.GetHeader(idempotencyKeyHeader),
 	})
 	if err != nil {
-		c.Error(errors.FromGRPCStatus(err))
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, SuccessResponse{
-		Data: OrderResponse{
-			ID:        uint(resp.GetId()),
-			UserID:    uint(resp.GetUserId()),
-			Total:     resp.GetTotal(),
-			Status:    resp.GetStatus(),
-			CreatedAt: resp.GetCreatedAt(),
-		},
-		TraceID: c.GetString(middleware.TraceIDKey),
+	middleware.RespondCreated(c, resp.GetId(), OrderResponse{
+		ID:        uint(resp.GetId()),
+		UserID:    uint(resp.GetUserId()),
+		Total:     resp.GetTotal(),
+		Status:    resp.GetStatus(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
 	})
 }
 
@@ -226,8 +441,11 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 // @Tags orders
 // @Accept json
 // @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
 // @Param id path int true "Order ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} SuccessResponse{data=OrderResponse} "Order retrieved successfully"
+// @Success 304 "Order not modified"
 // @Failure 400 {object} ErrorResponse "Invalid order ID"
 // @Failure 404 {object} ErrorResponse "Order not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -236,7 +454,7 @@ func (h *Handler) GetOrder(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		c.Error(errors.NewValidation("invalid order id", nil))
+		middleware.RespondError(c, errors.NewValidation("invalid order id", nil))
 		return
 	}
 
@@ -244,18 +462,219 @@ func (h *Handler) GetOrder(c *gin.Context) {
 		Id: id,
 	})
 	if err != nil {
-		c.Error(errors.FromGRPCStatus(err))
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	order := OrderResponse{
+		ID:        uint(resp.GetId()),
+		UserID:    uint(resp.GetUserId()),
+		Total:     resp.GetTotal(),
+		Status:    resp.GetStatus(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
+	}
+	if middleware.NotModified(c, order) {
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, order)
+}
+
+// BatchGetOrders retrieves several orders in one call
+// @Summary Get several orders by ID
+// @Description Retrieve up to MAX_BATCH_SIZE orders in one request instead of one request per order. Orders that don't exist are listed under missing_ids rather than failing the request, and so are other users' orders unless the caller is an admin.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Param request body BatchGetOrdersRequest true "Order IDs"
+// @Success 200 {object} SuccessResponse{data=BatchGetOrdersResponse} "Orders retrieved"
+// @Failure 400 {object} ErrorResponse "Validation error (no IDs or too many)"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/orders/batch-get [post]
+func (h *Handler) BatchGetOrders(c *gin.Context) {
+	claims := middleware.Claims(c)
+	if claims == nil {
+		middleware.RespondError(c, errors.NewUnauthorized("invalid or missing credentials"))
+		return
+	}
+
+	var req BatchGetOrdersRequest
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	resp, err := h.ordersClient.BatchGetOrders(c.Request.Context(), &orderspb.BatchGetOrdersRequest{
+		Ids: req.IDs,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	// Other users' orders are reported as missing, so callers can't tell
+	// them apart from orders that don't exist
+	orders := make([]OrderResponse, 0, len(resp.GetOrders()))
+	missing := append([]uint64{}, resp.GetMissingIds()...)
+	for _, order := range resp.GetOrders() {
+		if claims.Role != auth.RoleAdmin && !isSubject(claims, order.GetUserId()) {
+			missing = append(missing, order.GetId())
+			continue
+		}
+		orders = append(orders, OrderResponse{
+			ID:        uint(order.GetId()),
+			UserID:    uint(order.GetUserId()),
+			Total:     order.GetTotal(),
+			Status:    order.GetStatus(),
+			CreatedAt: order.GetCreatedAt(),
+			UpdatedAt: order.GetUpdatedAt(),
+		})
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, BatchGetOrdersResponse{
+		Orders:     orders,
+		MissingIDs: missing,
+	})
+}
+
+// isSubject reports whether userID is the caller's own user ID
+func isSubject(claims *auth.Claims, userID uint64) bool {
+	subject, err := strconv.ParseUint(claims.Subject, 10, 64)
+	return err == nil && subject == userID
+}
+
+// GetOrderWindowStats counts and sums the orders created within a window
+// @Summary Get recent order stats
+// @Description Count the orders created in the last 24 hours, 7 days or 30 days, and sum the revenue of those that weren't cancelled (admin only)
+// @Tags orders
+// @Produce json
+// @Security ApiKeyAuth
+// @Param window query string false "Rolling window: 24h (default), 7d or 30d"
+// @Success 200 {object} SuccessResponse{data=OrderWindowStatsResponse} "Stats retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Unknown window"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is not an admin"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/orders/stats [get]
+func (h *Handler) GetOrderWindowStats(c *gin.Context) {
+	var query OrderWindowStatsQuery
+	if err := middleware.BindQuery(c, &query); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	resp, err := h.ordersClient.GetOrderWindowStats(c.Request.Context(), &orderspb.GetOrderWindowStatsRequest{
+		Window: query.Window,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, OrderWindowStatsResponse{
+		Window:  resp.GetWindow(),
+		Since:   resp.GetSince(),
+		Count:   resp.GetCount(),
+		Revenue: resp.GetRevenue(),
+	})
+}
+
+// ReopenOrder moves a cancelled order back to pending
+// @Summary Reopen a cancelled order
+// @Description Reinstate an order cancelled by mistake (admin only). Only orders cancelled within the reopen window can be reopened.
+// @Tags orders
+// @Produce json
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Security ApiKeyAuth
+// @Param id path int true "Order ID"
+// @Success 200 {object} SuccessResponse{data=OrderResponse} "Order reopened successfully"
+// @Failure 400 {object} ErrorResponse "Invalid order ID"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is not an admin"
+// @Failure 404 {object} ErrorResponse "Order not found"
+// @Failure 409 {object} ErrorResponse "Order is not cancelled or was cancelled too long ago"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/orders/{id}/reopen [post]
+func (h *Handler) ReopenOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		middleware.RespondError(c, errors.NewValidation("invalid order id", nil))
+		return
+	}
+
+	resp, err := h.ordersClient.ReopenOrder(c.Request.Context(), &orderspb.ReopenOrderRequest{
+		Id: id,
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{
-		Data: OrderResponse{
-			ID:        uint(resp.GetId()),
-			UserID:    uint(resp.GetUserId()),
-			Total:     resp.GetTotal(),
-			Status:    resp.GetStatus(),
-			CreatedAt: resp.GetCreatedAt(),
+	middleware.RespondSuccess(c, http.StatusOK, OrderResponse{
+		ID:        uint(resp.GetId()),
+		UserID:    uint(resp.GetUserId()),
+		Total:     resp.GetTotal(),
+		Status:    resp.GetStatus(),
+		CreatedAt: resp.GetCreatedAt(),
+		UpdatedAt: resp.GetUpdatedAt(),
+	})
+}
+
+// ListOrders retrieves a page of orders
+// @Summary List orders
+// @Description Retrieve a page of orders, newest first, optionally filtered by user. Callers may list their own orders (user_id set to their user ID); listing other users' orders, or every user's, requires the admin role.
+// @Tags orders
+// @Produce json
+// @Security ApiKeyAuth
+// @Param ids_as_string query bool false "Serialize IDs as strings"
+// @Param user_id query int false "Filter by user ID"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param page_token query string false "Token of the page to retrieve"
+// @Success 200 {object} SuccessResponse{data=ListResponse{items=[]OrderResponse}} "Orders retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid credentials"
+// @Failure 403 {object} ErrorResponse "Caller is not an admin and user_id is not theirs"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/orders [get]
+func (h *Handler) ListOrders(c *gin.Context) {
+	var query ListOrdersQuery
+	if err := middleware.BindQuery(c, &query); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	resp, err := h.ordersClient.ListOrders(c.Request.Context(), &orderspb.ListOrdersRequest{
+		UserId: query.UserID,
+		Page: &orderspb.PageRequest{
+			PageSize:  query.PageSize,
+			PageToken: query.PageToken,
 		},
-		TraceID: c.GetString(middleware.TraceIDKey),
+	})
+	if err != nil {
+		middleware.RespondError(c, errors.FromGRPCStatus(err))
+		return
+	}
+
+	items := make([]OrderResponse, len(resp.GetOrders()))
+	for i, order := range resp.GetOrders() {
+		items[i] = OrderResponse{
+			ID:        uint(order.GetId()),
+			UserID:    uint(order.GetUserId()),
+			Total:     order.GetTotal(),
+			Status:    order.GetStatus(),
+			CreatedAt: order.GetCreatedAt(),
+			UpdatedAt: order.GetUpdatedAt(),
+		}
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, ListResponse{
+		Items:         items,
+		Total:         resp.GetPage().GetTotal(),
+		NextPageToken: resp.GetPage().GetNextPageToken(),
 	})
 }
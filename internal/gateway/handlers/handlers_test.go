@@ -0,0 +1,867 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	orderspb "go-micro/api/gen/orders/v1"
+	userspb "go-micro/api/gen/users/v1"
+	"go-micro/pkg/auth"
+	grpcpkg "go-micro/pkg/grpc"
+	"go-micro/pkg/grpc/grpctest"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/middleware"
+	"go-micro/pkg/testutil"
+)
+
+// unavailableUserServer fails every GetUser as an unreachable backend would
+type unavailableUserServer struct {
+	userspb.UnimplementedUserServiceServer
+	calls int
+}
+
+func (s *unavailableUserServer) GetUser(ctx context.Context, req *userspb.GetUserRequest) (*userspb.UserResponse, error) {
+	s.calls++
+	return nil, status.Error(codes.Unavailable, "connection refused")
+}
+
+// staticUserServer answers GetUser with a fixed user
+type staticUserServer struct {
+	userspb.UnimplementedUserServiceServer
+	user *userspb.UserResponse
+}
+
+func (s *staticUserServer) GetUser(ctx context.Context, req *userspb.GetUserRequest) (*userspb.UserResponse, error) {
+	return s.user, nil
+}
+
+// credentialsUserServer accepts a single email and password, and refresh
+// tokens "refresh-1", "refresh-2", ... each exchangeable once
+type credentialsUserServer struct {
+	userspb.UnimplementedUserServiceServer
+	user     *userspb.UserResponse
+	password string
+
+	issued  int
+	revoked []string
+}
+
+func (s *credentialsUserServer) VerifyCredentials(ctx context.Context, req *userspb.VerifyCredentialsRequest) (*userspb.UserResponse, error) {
+	if req.GetEmail() != s.user.GetEmail() || req.GetPassword() != s.password {
+		return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+	}
+	return s.user, nil
+}
+
+func (s *credentialsUserServer) IssueRefreshToken(ctx context.Context, req *userspb.IssueRefreshTokenRequest) (*userspb.RefreshTokenResponse, error) {
+	s.issued++
+	return &userspb.RefreshTokenResponse{
+		RefreshToken: "refresh-" + strconv.Itoa(s.issued),
+		ExpiresAt:    "2024-02-14T10:30:00Z",
+		User:         s.user,
+	}, nil
+}
+
+func (s *credentialsUserServer) RotateRefreshToken(ctx context.Context, req *userspb.RotateRefreshTokenRequest) (*userspb.RefreshTokenResponse, error) {
+	if req.GetRefreshToken() != "refresh-"+strconv.Itoa(s.issued) {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	return s.IssueRefreshToken(ctx, &userspb.IssueRefreshTokenRequest{UserId: s.user.GetId()})
+}
+
+func (s *credentialsUserServer) RevokeRefreshToken(ctx context.Context, req *userspb.RevokeRefreshTokenRequest) (*userspb.RevokeRefreshTokenResponse, error) {
+	s.revoked = append(s.revoked, req.GetRefreshToken())
+	return &userspb.RevokeRefreshTokenResponse{}, nil
+}
+
+// newUsersConn serves srv in process and returns a client connection to it
+func newUsersConn(t *testing.T, srv userspb.UserServiceServer, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	server := grpctest.NewServer(t, func(s *grpc.Server) {
+		userspb.RegisterUserServiceServer(s, srv)
+	})
+	opts = append(server.DialOptions(), append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	conn, err := grpc.Dial(grpctest.Target, opts...)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// pagedOrderServer lists its orders and archived orders one per page
+type pagedOrderServer struct {
+	orderspb.UnimplementedOrderServiceServer
+	orders   []*orderspb.OrderResponse
+	archived []*orderspb.OrderResponse
+}
+
+func (s *pagedOrderServer) ListOrders(ctx context.Context, req *orderspb.ListOrdersRequest) (*orderspb.ListOrdersResponse, error) {
+	return listOnePerPage(s.orders, req), nil
+}
+
+func (s *pagedOrderServer) ListArchivedOrders(ctx context.Context, req *orderspb.ListOrdersRequest) (*orderspb.ListOrdersResponse, error) {
+	return listOnePerPage(s.archived, req), nil
+}
+
+func listOnePerPage(all []*orderspb.OrderResponse, req *orderspb.ListOrdersRequest) *orderspb.ListOrdersResponse {
+	i, _ := strconv.Atoi(req.GetPage().GetPageToken())
+	page := &orderspb.PageInfo{Total: int64(len(all))}
+	if i+1 < len(all) {
+		page.NextPageToken = strconv.Itoa(i + 1)
+	}
+	var orders []*orderspb.OrderResponse
+	if i < len(all) {
+		orders = all[i : i+1]
+	}
+	return &orderspb.ListOrdersResponse{Orders: orders, Page: page}
+}
+
+// batchOrderServer answers BatchGetOrders from a fixed set of orders
+type batchOrderServer struct {
+	orderspb.UnimplementedOrderServiceServer
+	orders map[uint64]*orderspb.OrderResponse
+}
+
+func (s *batchOrderServer) BatchGetOrders(ctx context.Context, req *orderspb.BatchGetOrdersRequest) (*orderspb.BatchGetOrdersResponse, error) {
+	resp := &orderspb.BatchGetOrdersResponse{}
+	for _, id := range req.GetIds() {
+		if order, ok := s.orders[id]; ok {
+			resp.Orders = append(resp.Orders, order)
+		} else {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+// windowStatsServer answers GetOrderWindowStats with fixed stats for the
+// window asked for, and rejects windows other than 24h and 7d
+type windowStatsServer struct {
+	orderspb.UnimplementedOrderServiceServer
+}
+
+func (s *windowStatsServer) GetOrderWindowStats(ctx context.Context, req *orderspb.GetOrderWindowStatsRequest) (*orderspb.OrderWindowStatsResponse, error) {
+	switch req.GetWindow() {
+	case "", "24h":
+		return &orderspb.OrderWindowStatsResponse{Window: "24h", Since: "2024-01-14T10:30:00Z", Count: 3, Revenue: 30}, nil
+	case "7d":
+		return &orderspb.OrderWindowStatsResponse{Window: "7d", Since: "2024-01-08T10:30:00Z", Count: 12, Revenue: 149.97}, nil
+	default:
+		return nil, status.Error(codes.InvalidArgument, "window must be 24h, 7d or 30d")
+	}
+}
+
+// traceRecorder records the trace ID metadata of the calls a fake server receives
+type traceRecorder struct {
+	mu       sync.Mutex
+	traceIDs []string
+}
+
+func (r *traceRecorder) record(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.traceIDs = append(r.traceIDs, md.Get(grpcpkg.TraceIDMetadataKey)...)
+}
+
+// dashboardUserServer answers GetUser with a fixed user, recording trace IDs
+type dashboardUserServer struct {
+	staticUserServer
+	*traceRecorder
+}
+
+func (s *dashboardUserServer) GetUser(ctx context.Context, req *userspb.GetUserRequest) (*userspb.UserResponse, error) {
+	s.record(ctx)
+	return s.staticUserServer.GetUser(ctx, req)
+}
+
+// dashboardOrderServer answers the dashboard's order calls with fixed data,
+// or fails them all with err, recording trace IDs
+type dashboardOrderServer struct {
+	orderspb.UnimplementedOrderServiceServer
+	*traceRecorder
+	orders []*orderspb.OrderResponse
+	stats  []*orderspb.OrderStatusStats
+	err    error
+}
+
+func (s *dashboardOrderServer) ListOrders(ctx context.Context, req *orderspb.ListOrdersRequest) (*orderspb.ListOrdersResponse, error) {
+	s.record(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &orderspb.ListOrdersResponse{Orders: s.orders, Page: &orderspb.PageInfo{Total: int64(len(s.orders))}}, nil
+}
+
+func (s *dashboardOrderServer) GetOrderStats(ctx context.Context, req *orderspb.GetOrderStatsRequest) (*orderspb.OrderStatsResponse, error) {
+	s.record(ctx)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &orderspb.OrderStatsResponse{Statuses: s.stats}, nil
+}
+
+// newOrdersConn serves srv in process and returns a client connection to it
+func newOrdersConn(t *testing.T, srv orderspb.OrderServiceServer, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+
+	server := grpctest.NewServer(t, func(s *grpc.Server) {
+		orderspb.RegisterOrderServiceServer(s, srv)
+	})
+	conn, err := grpc.Dial(grpctest.Target, append(server.DialOptions(),
+		append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))...)...)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGetUser_IncludesTimestamps(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+	conn := newUsersConn(t, &staticUserServer{user: &userspb.UserResponse{
+		Id:        1,
+		Name:      "John Doe",
+		Email:     "john@example.com",
+		CreatedAt: userspb.FormatTime(created),
+		UpdatedAt: userspb.FormatTime(created.Add(time.Hour)),
+	}})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:id", NewHandler(userspb.NewUserServiceClient(conn), nil, "").GetUser)
+
+	// Act
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data UserResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if body.Data.CreatedAt != "2024-01-15T09:30:00Z" || body.Data.UpdatedAt != "2024-01-15T10:30:00Z" {
+		t.Errorf("expected UTC RFC 3339 timestamps, got created_at=%q updated_at=%q", body.Data.CreatedAt, body.Data.UpdatedAt)
+	}
+}
+
+func TestGetUser_CircuitOpen(t *testing.T) {
+	// Arrange: a users client wired like the gateway's, with a breaker that
+	// opens on the first failure
+	backend := &unavailableUserServer{}
+	conn := newUsersConn(t, backend, grpc.WithChainUnaryInterceptor(
+		grpcpkg.UnaryClientInterceptor(time.Second),
+		grpcpkg.UnaryClientCircuitBreakerInterceptor(grpcpkg.NewCircuitBreaker(1, time.Minute)),
+	))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logger.New("test", "error")))
+	h := NewHandler(userspb.NewUserServiceClient(conn), nil, "")
+	router.GET("/users/:id", h.GetUser)
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		return w
+	}
+
+	// Act
+	get() // trips the breaker
+	w := get()
+
+	// Assert
+	if backend.calls != 1 {
+		t.Errorf("expected the open circuit to skip the backend, got %d calls", backend.calls)
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details struct {
+				RetryAfterSeconds int `json:"retry_after_seconds"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if body.Error.Code != "SERVICE_UNAVAILABLE" || body.Error.Details.RetryAfterSeconds != 60 {
+		t.Errorf("unexpected error body: %s", w.Body.String())
+	}
+}
+
+func TestExportUser(t *testing.T) {
+	// Arrange
+	usersConn := newUsersConn(t, &staticUserServer{user: &userspb.UserResponse{
+		Id: 1, Name: "John Doe", Email: "john@example.com",
+	}})
+	ordersConn := newOrdersConn(t, &pagedOrderServer{orders: []*orderspb.OrderResponse{
+		{Id: 10, UserId: 1, Total: 5, Status: "pending"},
+		{Id: 11, UserId: 1, Total: 7.5, Status: "confirmed"},
+	}, archived: []*orderspb.OrderResponse{
+		{Id: 3, UserId: 1, Total: 2, Status: "confirmed"},
+		{Id: 4, UserId: 1, Total: 4, Status: "confirmed"},
+	}})
+	h := NewHandler(userspb.NewUserServiceClient(usersConn), orderspb.NewOrderServiceClient(ordersConn), "secret")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	unauthorized := testutil.Do(t, router, http.MethodGet, "/api/v1/users/1/export", nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1/export", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp := testutil.Serve(t, router, req)
+
+	// Assert
+	if unauthorized.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", unauthorized.Code)
+	}
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if got := resp.Header().Get("Content-Disposition"); got != `attachment; filename="user-export.json"` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+
+	var export UserExport
+	resp.Data(&export)
+	if export.User.ID != 1 || export.User.Email != "john@example.com" {
+		t.Errorf("unexpected user %+v", export.User)
+	}
+	if len(export.Orders) != 2 || export.Orders[0].ID != 10 || export.Orders[1].ID != 11 {
+		t.Errorf("expected both pages of orders, got %+v", export.Orders)
+	}
+	if len(export.ArchivedOrders) != 2 || export.ArchivedOrders[0].ID != 3 || export.ArchivedOrders[1].ID != 4 {
+		t.Errorf("expected both pages of archived orders, got %+v", export.ArchivedOrders)
+	}
+	if export.ExportedAt == "" {
+		t.Error("expected exported_at to be set")
+	}
+}
+
+func TestExportUser_RequiresSameUserOrAdmin(t *testing.T) {
+	// Arrange
+	usersConn := newUsersConn(t, &staticUserServer{user: &userspb.UserResponse{Id: 2, Name: "Jane Doe"}})
+	ordersConn := newOrdersConn(t, &pagedOrderServer{})
+	h := NewHandler(userspb.NewUserServiceClient(usersConn), orderspb.NewOrderServiceClient(ordersConn), "secret")
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h.SetTokenIssuer(issuer)
+	userToken, _, _ := issuer.Issue("2", "user")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"own data", "/api/v1/users/2/export", userToken, http.StatusOK},
+		{"another user's data", "/api/v1/users/3/export", userToken, http.StatusForbidden},
+		{"admin", "/api/v1/users/2/export", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp := testutil.Serve(t, router, req)
+
+			// Assert
+			if resp.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireBackend_NamesDependency(t *testing.T) {
+	// Arrange: the users backend could not be initialized
+	h := NewHandler(nil, nil, "")
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	resp := testutil.Do(t, router, http.MethodGet, "/api/v1/users/1", nil)
+
+	// Assert
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if got := resp.Error().Error.Dependency; got != "users-service" {
+		t.Errorf("expected dependency users-service, got %q", got)
+	}
+}
+
+// newBatchGetRequest builds a batch-get request for ids, authenticated with
+// token unless it is empty
+func newBatchGetRequest(t *testing.T, token string, ids []uint64) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{"ids": ids})
+	if err != nil {
+		t.Fatalf("failed to marshal request body: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders/batch-get", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	// Arrange
+	ordersConn := newOrdersConn(t, &batchOrderServer{orders: map[uint64]*orderspb.OrderResponse{
+		1: {Id: 1, UserId: 1, Total: 5, Status: "pending"},
+		2: {Id: 2, UserId: 1, Total: 7.5, Status: "confirmed"},
+	}})
+	h := NewHandler(nil, orderspb.NewOrderServiceClient(ordersConn), "secret")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	resp := testutil.Serve(t, router, newBatchGetRequest(t, "secret", []uint64{2, 3, 1}))
+	empty := testutil.Serve(t, router, newBatchGetRequest(t, "secret", []uint64{}))
+
+	// Assert
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var got BatchGetOrdersResponse
+	resp.Data(&got)
+	if len(got.Orders) != 2 || got.Orders[0].ID != 2 || got.Orders[1].ID != 1 {
+		t.Errorf("expected orders 2 and 1 in request order, got %+v", got.Orders)
+	}
+	if !reflect.DeepEqual(got.MissingIDs, []uint64{3}) {
+		t.Errorf("expected missing_ids [3], got %v", got.MissingIDs)
+	}
+	if empty.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without ids, got %d", empty.Code)
+	}
+}
+
+func TestBatchGetOrders_OnlyOwnOrders(t *testing.T) {
+	// Arrange: order 1 is user 2's, order 2 is user 3's
+	ordersConn := newOrdersConn(t, &batchOrderServer{orders: map[uint64]*orderspb.OrderResponse{
+		1: {Id: 1, UserId: 2, Total: 5, Status: "pending"},
+		2: {Id: 2, UserId: 3, Total: 7.5, Status: "confirmed"},
+	}})
+	h := NewHandler(nil, orderspb.NewOrderServiceClient(ordersConn), "secret")
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h.SetTokenIssuer(issuer)
+	userToken, _, _ := issuer.Issue("2", "user")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	anonymous := testutil.Serve(t, router, newBatchGetRequest(t, "", []uint64{1, 2}))
+	resp := testutil.Serve(t, router, newBatchGetRequest(t, userToken, []uint64{1, 2}))
+
+	// Assert: another user's order looks like one that doesn't exist
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for anonymous callers, got %d", anonymous.Code)
+	}
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var got BatchGetOrdersResponse
+	resp.Data(&got)
+	if len(got.Orders) != 1 || got.Orders[0].ID != 1 {
+		t.Errorf("expected only the caller's order 1, got %+v", got.Orders)
+	}
+	if !reflect.DeepEqual(got.MissingIDs, []uint64{2}) {
+		t.Errorf("expected another user's order under missing_ids, got %v", got.MissingIDs)
+	}
+}
+
+func TestListOrders_RequiresOwnUserOrAdmin(t *testing.T) {
+	// Arrange
+	ordersConn := newOrdersConn(t, &pagedOrderServer{orders: []*orderspb.OrderResponse{
+		{Id: 10, UserId: 2, Total: 5, Status: "pending"},
+	}})
+	h := NewHandler(nil, orderspb.NewOrderServiceClient(ordersConn), "secret")
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h.SetTokenIssuer(issuer)
+	userToken, _, _ := issuer.Issue("2", "user")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+	get := func(path, token string) *testutil.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return testutil.Serve(t, router, req)
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"anonymous", "/api/v1/orders?user_id=2", "", http.StatusUnauthorized},
+		{"own orders", "/api/v1/orders?user_id=2", userToken, http.StatusOK},
+		{"another user's orders", "/api/v1/orders?user_id=3", userToken, http.StatusForbidden},
+		{"every user's orders", "/api/v1/orders", userToken, http.StatusForbidden},
+		{"admin lists every user's orders", "/api/v1/orders", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			resp := get(tt.path, tt.token)
+
+			// Assert
+			if resp.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetOrderWindowStats(t *testing.T) {
+	// Arrange
+	ordersConn := newOrdersConn(t, &windowStatsServer{})
+	h := NewHandler(nil, orderspb.NewOrderServiceClient(ordersConn), "secret")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+	get := func(path string) *testutil.Response {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		return testutil.Serve(t, router, req)
+	}
+
+	// Act
+	unauthorized := testutil.Do(t, router, http.MethodGet, "/api/v1/orders/stats", nil)
+	defaulted := get("/api/v1/orders/stats")
+	week := get("/api/v1/orders/stats?window=7d")
+	unknown := get("/api/v1/orders/stats?window=1y")
+
+	// Assert
+	if unauthorized.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d", unauthorized.Code)
+	}
+	for _, tt := range []struct {
+		resp *testutil.Response
+		want OrderWindowStatsResponse
+	}{
+		{defaulted, OrderWindowStatsResponse{Window: "24h", Since: "2024-01-14T10:30:00Z", Count: 3, Revenue: 30}},
+		{week, OrderWindowStatsResponse{Window: "7d", Since: "2024-01-08T10:30:00Z", Count: 12, Revenue: 149.97}},
+	} {
+		if tt.resp.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", tt.resp.Code, tt.resp.Body.String())
+		}
+		var got OrderWindowStatsResponse
+		tt.resp.Data(&got)
+		if got != tt.want {
+			t.Errorf("expected %+v, got %+v", tt.want, got)
+		}
+	}
+	if unknown.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown window, got %d", unknown.Code)
+	}
+}
+
+func TestGetUserDashboard(t *testing.T) {
+	user := &userspb.UserResponse{Id: 1, Name: "John Doe", Email: "john@example.com"}
+	orders := []*orderspb.OrderResponse{
+		{Id: 12, UserId: 1, Total: 20, Status: "cancelled"},
+		{Id: 11, UserId: 1, Total: 7.5, Status: "confirmed"},
+		{Id: 10, UserId: 1, Total: 5, Status: "pending"},
+	}
+	stats := []*orderspb.OrderStatusStats{
+		{Status: "cancelled", Count: 1, Total: 20},
+		{Status: "confirmed", Count: 1, Total: 7.5},
+		{Status: "pending", Count: 1, Total: 5},
+	}
+
+	tests := []struct {
+		name       string
+		ordersErr  error
+		wantErrors map[string]SectionError
+	}{
+		{
+			name: "all sections",
+		},
+		{
+			name:      "orders service failing",
+			ordersErr: status.Error(codes.Unavailable, "connection refused"),
+			wantErrors: map[string]SectionError{
+				"recent_orders": {Code: "SERVICE_UNAVAILABLE", Message: "connection refused"},
+				"order_stats":   {Code: "SERVICE_UNAVAILABLE", Message: "connection refused"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: clients propagate trace IDs like the gateway's
+			traces := &traceRecorder{}
+			tracing := grpc.WithUnaryInterceptor(grpcpkg.UnaryClientInterceptor(time.Second))
+			usersConn := newUsersConn(t, &dashboardUserServer{staticUserServer{user: user}, traces}, tracing)
+			ordersConn := newOrdersConn(t, &dashboardOrderServer{
+				traceRecorder: traces, orders: orders, stats: stats, err: tt.ordersErr,
+			}, tracing)
+			h := NewHandler(userspb.NewUserServiceClient(usersConn), orderspb.NewOrderServiceClient(ordersConn), "")
+			h.SetLogger(logger.New("test", "error"))
+			issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+			h.SetTokenIssuer(issuer)
+			token, _, _ := issuer.Issue("1", "user")
+
+			router := testutil.NewRouter()
+			h.RegisterRoutes(router.Group("/api/v1"))
+
+			// Act: users may see their own dashboard
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1/dashboard", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp := testutil.Serve(t, router, req)
+
+			// Assert: the profile is always there, failed sections are null
+			if resp.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+			}
+			var dashboard UserDashboard
+			traceID := resp.Data(&dashboard)
+			if dashboard.User.ID != 1 || dashboard.User.Email != "john@example.com" {
+				t.Errorf("unexpected user %+v", dashboard.User)
+			}
+			if !reflect.DeepEqual(dashboard.Errors, tt.wantErrors) {
+				t.Errorf("expected section errors %+v, got %+v", tt.wantErrors, dashboard.Errors)
+			}
+
+			if tt.ordersErr != nil {
+				if dashboard.RecentOrders != nil || dashboard.OrderStats != nil {
+					t.Errorf("expected failed sections to be null, got %+v", dashboard)
+				}
+			} else {
+				if len(dashboard.RecentOrders) != 3 || dashboard.RecentOrders[0].ID != 12 {
+					t.Errorf("unexpected recent orders %+v", dashboard.RecentOrders)
+				}
+				wantStats := &OrderStatsResponse{
+					TotalOrders: 3,
+					TotalSpent:  12.5,
+					ByStatus:    map[string]int64{"cancelled": 1, "confirmed": 1, "pending": 1},
+				}
+				if !reflect.DeepEqual(dashboard.OrderStats, wantStats) {
+					t.Errorf("expected stats %+v, got %+v", wantStats, dashboard.OrderStats)
+				}
+			}
+
+			// Every backend call carried the request's trace ID
+			if len(traces.traceIDs) != 3 {
+				t.Fatalf("expected 3 traced backend calls, got %v", traces.traceIDs)
+			}
+			for _, id := range traces.traceIDs {
+				if id != traceID {
+					t.Errorf("expected trace ID %q on every call, got %v", traceID, traces.traceIDs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGetUserDashboard_UserUnavailable(t *testing.T) {
+	// Arrange
+	usersConn := newUsersConn(t, &unavailableUserServer{})
+	h := NewHandler(userspb.NewUserServiceClient(usersConn), nil, "secret")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1/dashboard", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp := testutil.Serve(t, router, req)
+
+	// Assert: without the profile there is no dashboard
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestGetUserDashboard_RequiresSameUserOrAdmin(t *testing.T) {
+	// Arrange
+	usersConn := newUsersConn(t, &staticUserServer{user: &userspb.UserResponse{Id: 2, Name: "Jane Doe"}})
+	h := NewHandler(userspb.NewUserServiceClient(usersConn), nil, "secret")
+	h.SetLogger(logger.New("test", "error"))
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h.SetTokenIssuer(issuer)
+	userToken, _, _ := issuer.Issue("2", "user")
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"anonymous", "/api/v1/users/2/dashboard", "", http.StatusUnauthorized},
+		{"own dashboard", "/api/v1/users/2/dashboard", userToken, http.StatusOK},
+		{"another user's dashboard", "/api/v1/users/3/dashboard", userToken, http.StatusForbidden},
+		{"admin", "/api/v1/users/2/dashboard", "secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp := testutil.Serve(t, router, req)
+
+			// Assert
+			if resp.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	// Arrange
+	usersConn := newUsersConn(t, &credentialsUserServer{
+		user:     &userspb.UserResponse{Id: 7, Name: "John Doe", Email: "john@example.com", TenantId: "acme"},
+		password: "correct horse",
+	})
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h := NewHandler(userspb.NewUserServiceClient(usersConn), nil, "")
+	h.SetTokenIssuer(issuer)
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	resp := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/login", LoginRequest{
+		Email: "john@example.com", Password: "correct horse",
+	})
+	wrong := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/login", LoginRequest{
+		Email: "john@example.com", Password: "wrong horse",
+	})
+
+	// Assert
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	var login TokenResponse
+	resp.Data(&login)
+	claims, err := issuer.Verify(login.AccessToken)
+	if err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+	if claims.Subject != "7" || login.TokenType != "Bearer" || login.User.ID != 7 || login.RefreshToken != "refresh-1" {
+		t.Errorf("unexpected login response %+v with claims %+v", login, claims)
+	}
+	if claims.Tenant != "acme" {
+		t.Errorf("expected the token to carry the user's tenant, got %q", claims.Tenant)
+	}
+
+	if wrong.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong password, got %d", wrong.Code)
+	}
+}
+
+func TestLogin_DisabledWithoutTokenIssuer(t *testing.T) {
+	// Arrange
+	h := NewHandler(userspb.NewUserServiceClient(newUsersConn(t, &credentialsUserServer{})), nil, "")
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	resp := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/login", LoginRequest{
+		Email: "john@example.com", Password: "correct horse",
+	})
+
+	// Assert
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when login is disabled, got %d", resp.Code)
+	}
+}
+
+func TestRefreshAndLogout(t *testing.T) {
+	// Arrange
+	backend := &credentialsUserServer{
+		user:     &userspb.UserResponse{Id: 7, Name: "John Doe", Email: "john@example.com"},
+		password: "correct horse",
+	}
+	issuer := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	h := NewHandler(userspb.NewUserServiceClient(newUsersConn(t, backend)), nil, "")
+	h.SetTokenIssuer(issuer)
+
+	router := testutil.NewRouter()
+	h.RegisterRoutes(router.Group("/api/v1"))
+
+	testutil.Do(t, router, http.MethodPost, "/api/v1/auth/login", LoginRequest{
+		Email: "john@example.com", Password: "correct horse",
+	})
+
+	// Act
+	refreshed := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/refresh", RefreshRequest{RefreshToken: "refresh-1"})
+	replayed := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/refresh", RefreshRequest{RefreshToken: "refresh-1"})
+	logout := testutil.Do(t, router, http.MethodPost, "/api/v1/auth/logout", RefreshRequest{RefreshToken: "refresh-2"})
+
+	// Assert
+	if refreshed.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", refreshed.Code, refreshed.Body.String())
+	}
+	var tokens TokenResponse
+	refreshed.Data(&tokens)
+	if tokens.RefreshToken != "refresh-2" {
+		t.Errorf("expected the rotated refresh token, got %q", tokens.RefreshToken)
+	}
+	if _, err := issuer.Verify(tokens.AccessToken); err != nil {
+		t.Errorf("expected a valid access token, got %v", err)
+	}
+
+	if replayed.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a used refresh token, got %d", replayed.Code)
+	}
+
+	if logout.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", logout.Code)
+	}
+	if len(backend.revoked) != 1 || backend.revoked[0] != "refresh-2" {
+		t.Errorf("expected refresh-2 to be revoked, got %v", backend.revoked)
+	}
+}
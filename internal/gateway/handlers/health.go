@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Backend is a downstream service checked by the readiness probe
+type Backend struct {
+	Name string
+	// Client is nil when the backend's connection could not be created
+	Client healthpb.HealthClient
+	// Required backends make the gateway unready when they are down;
+	// optional ones are only reported
+	Required bool
+}
+
+// BackendStatus is the readiness of a single backend
+type BackendStatus struct {
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the body of GET /health/ready
+type ReadinessResponse struct {
+	Status   string                   `json:"status"`
+	Backends map[string]BackendStatus `json:"backends"`
+}
+
+// Readiness statuses
+const (
+	statusReady    = "ready"
+	statusNotReady = "not_ready"
+)
+
+// Readiness checks every backend with the gRPC health protocol, in parallel
+// and within timeout, and answers 503 when a required backend is not SERVING
+func Readiness(backends []Backend, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		statuses := make([]BackendStatus, len(backends))
+		var wg sync.WaitGroup
+		for i, backend := range backends {
+			wg.Add(1)
+			go func(i int, backend Backend) {
+				defer wg.Done()
+				statuses[i] = checkBackend(ctx, backend)
+			}(i, backend)
+		}
+		wg.Wait()
+
+		resp := ReadinessResponse{
+			Status:   statusReady,
+			Backends: make(map[string]BackendStatus, len(backends)),
+		}
+		for i, backend := range backends {
+			resp.Backends[backend.Name] = statuses[i]
+			if backend.Required && statuses[i].Status != healthpb.HealthCheckResponse_SERVING.String() {
+				resp.Status = statusNotReady
+			}
+		}
+
+		code := http.StatusOK
+		if resp.Status != statusReady {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, resp)
+	}
+}
+
+// checkBackend asks backend for its overall health
+func checkBackend(ctx context.Context, backend Backend) BackendStatus {
+	status := BackendStatus{Required: backend.Required}
+
+	if backend.Client == nil {
+		status.Status = healthpb.HealthCheckResponse_UNKNOWN.String()
+		status.Error = "backend client is not initialized"
+		return status
+	}
+
+	resp, err := backend.Client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		status.Status = healthpb.HealthCheckResponse_UNKNOWN.String()
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Status = resp.GetStatus().String()
+	return status
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// fakeHealthClient answers Check with a fixed status or error
+type fakeHealthClient struct {
+	healthpb.HealthClient
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+}
+
+func (f *fakeHealthClient) Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &healthpb.HealthCheckResponse{Status: f.status}, nil
+}
+
+func TestReadiness(t *testing.T) {
+	serving := &fakeHealthClient{status: healthpb.HealthCheckResponse_SERVING}
+	notServing := &fakeHealthClient{status: healthpb.HealthCheckResponse_NOT_SERVING}
+	unreachable := &fakeHealthClient{err: status.Error(codes.Unavailable, "connection refused")}
+
+	tests := []struct {
+		name       string
+		backends   []Backend
+		wantStatus int
+		wantOrders string
+	}{
+		{
+			"all serving",
+			[]Backend{{"users", serving, true}, {"orders", serving, true}},
+			http.StatusOK, "SERVING",
+		},
+		{
+			"required backend not serving",
+			[]Backend{{"users", serving, true}, {"orders", notServing, true}},
+			http.StatusServiceUnavailable, "NOT_SERVING",
+		},
+		{
+			"required backend unreachable",
+			[]Backend{{"users", serving, true}, {"orders", unreachable, true}},
+			http.StatusServiceUnavailable, "UNKNOWN",
+		},
+		{
+			"optional backend down",
+			[]Backend{{"users", serving, true}, {"orders", unreachable, false}},
+			http.StatusOK, "UNKNOWN",
+		},
+		{
+			"required backend not initialized",
+			[]Backend{{"users", serving, true}, {"orders", nil, true}},
+			http.StatusServiceUnavailable, "UNKNOWN",
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.GET("/health/ready", Readiness(tt.backends, time.Second))
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			var resp ReadinessResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if got := resp.Backends["orders"].Status; got != tt.wantOrders {
+				t.Errorf("expected orders status %s, got %s", tt.wantOrders, got)
+			}
+			if got := resp.Backends["users"].Status; got != "SERVING" {
+				t.Errorf("expected users status SERVING, got %s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/swaggo/swag"
+
+	"go-micro/pkg/errors"
+	"go-micro/pkg/middleware"
+)
+
+// SwaggerHandler serves Swagger UI under /swagger/*any. The spec's host and
+// schemes are rewritten per request so "Try it out" targets the address the
+// client actually used, whatever the deployment's host, port or TLS setup.
+// A non-empty host or basePath overrides the request-derived value (e.g.
+// behind a proxy that rewrites paths).
+func SwaggerHandler(host, basePath string) gin.HandlerFunc {
+	ui := ginSwagger.WrapHandler(swaggerFiles.Handler)
+
+	return func(c *gin.Context) {
+		if c.Param("any") != "/doc.json" {
+			ui(c)
+			return
+		}
+
+		doc, err := swag.ReadDoc()
+		if err != nil {
+			middleware.RespondError(c, errors.NewInternal("failed to read swagger spec", err))
+			return
+		}
+
+		specHost := host
+		if specHost == "" {
+			specHost = requestHost(c)
+		}
+
+		spec, err := rewriteSpec([]byte(doc), specHost, requestScheme(c), basePath)
+		if err != nil {
+			middleware.RespondError(c, errors.NewInternal("failed to rewrite swagger spec", err))
+			return
+		}
+
+		c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+	}
+}
+
+// rewriteSpec sets the host and schemes of a Swagger 2.0 spec, and its
+// basePath when one is given
+func rewriteSpec(doc []byte, host, scheme, basePath string) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, err
+	}
+
+	spec["host"] = host
+	spec["schemes"] = []string{scheme}
+	if basePath != "" {
+		spec["basePath"] = basePath
+	}
+
+	return json.Marshal(spec)
+}
+
+// requestHost returns the host the client addressed, preferring the one a
+// reverse proxy reports
+func requestHost(c *gin.Context) string {
+	if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return c.Request.Host
+}
+
+// requestScheme returns the scheme the client used, preferring the one a
+// reverse proxy reports
+func requestScheme(c *gin.Context) string {
+	if forwarded := c.GetHeader("X-Forwarded-Proto"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRewriteSpec(t *testing.T) {
+	doc := []byte(`{"swagger":"2.0","host":"localhost:8443","basePath":"/","schemes":["https","http"],"paths":{}}`)
+
+	tests := []struct {
+		name         string
+		basePath     string
+		wantBasePath string
+	}{
+		{"keeps base path", "", "/"},
+		{"overrides base path", "/gateway", "/gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			out, err := rewriteSpec(doc, "api.example.com:9000", "http", tt.basePath)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			var spec struct {
+				Host     string   `json:"host"`
+				BasePath string   `json:"basePath"`
+				Schemes  []string `json:"schemes"`
+			}
+			if err := json.Unmarshal(out, &spec); err != nil {
+				t.Fatalf("failed to unmarshal spec: %v", err)
+			}
+			if spec.Host != "api.example.com:9000" {
+				t.Errorf("expected host api.example.com:9000, got %s", spec.Host)
+			}
+			if len(spec.Schemes) != 1 || spec.Schemes[0] != "http" {
+				t.Errorf("expected schemes [http], got %v", spec.Schemes)
+			}
+			if spec.BasePath != tt.wantBasePath {
+				t.Errorf("expected basePath %s, got %s", tt.wantBasePath, spec.BasePath)
+			}
+		})
+	}
+}
+
+func TestRequestHostAndScheme(t *testing.T) {
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantHost   string
+		wantScheme string
+	}{
+		{"direct request", nil, "gateway:8080", "http"},
+		{"behind proxy", map[string]string{"X-Forwarded-Host": "api.example.com, proxy", "X-Forwarded-Proto": "https"}, "api.example.com", "https"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := httptest.NewRequest(http.MethodGet, "http://gateway:8080/swagger/doc.json", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			// Act & Assert
+			if got := requestHost(c); got != tt.wantHost {
+				t.Errorf("expected host %s, got %s", tt.wantHost, got)
+			}
+			if got := requestScheme(c); got != tt.wantScheme {
+				t.Errorf("expected scheme %s, got %s", tt.wantScheme, got)
+			}
+		})
+	}
+}
@@ -6,24 +6,30 @@ import (
 
 	"go.uber.org/zap"
 
+	"go-micro/internal/orders/ports"
 	"go-micro/pkg/events"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
 	"go-micro/pkg/rabbitmq"
 )
 
-// UserCreatedConsumer consumes UserCreated events
+// UserCreatedConsumer consumes UserCreated events and keeps the local user
+// read-model up to date
 type UserCreatedConsumer struct {
-	consumer *rabbitmq.Consumer
-	log      *logger.Logger
+	consumer  *rabbitmq.Consumer
+	readModel ports.UserReadModel
+	log       *logger.Logger
 }
 
-// NewUserCreatedConsumer creates a new consumer for UserCreated events
-func NewUserCreatedConsumer(conn *rabbitmq.Connection, log *logger.Logger) (*UserCreatedConsumer, error) {
+// NewUserCreatedConsumer creates a new consumer for UserCreated events.
+// A nil readModel only logs the events.
+func NewUserCreatedConsumer(conn *rabbitmq.Connection, queuePrefix string, readModel ports.UserReadModel, log *logger.Logger) (*UserCreatedConsumer, error) {
 	consumer, err := rabbitmq.NewConsumer(
 		conn,
+		queuePrefix,
 		"orders.user-created", // queue name
 		events.ExchangeUsers,  // exchange
-		[]string{events.RoutingKeyUserCreated},
+		[]string{events.BindingKey(events.RoutingKeyUserCreated)},
 		log,
 	)
 	if err != nil {
@@ -31,14 +37,21 @@ func NewUserCreatedConsumer(conn *rabbitmq.Connection, log *logger.Logger) (*Use
 	}
 
 	return &UserCreatedConsumer{
-		consumer: consumer,
-		log:      log,
+		consumer:  consumer,
+		readModel: readModel,
+		log:       log,
 	}, nil
 }
 
-// Start starts consuming UserCreated events
-func (c *UserCreatedConsumer) Start(ctx context.Context) error {
-	return c.consumer.Consume(ctx, c.handleMessage)
+// SetDeduplicator skips redelivered events; call it before Run
+func (c *UserCreatedConsumer) SetDeduplicator(d *rabbitmq.Deduplicator) {
+	c.consumer.SetDeduplicator(d)
+}
+
+// Run consumes UserCreated events until ctx is cancelled, returning once the
+// event in flight has been handled
+func (c *UserCreatedConsumer) Run(ctx context.Context) error {
+	return c.consumer.Run(ctx, c.handleMessage)
 }
 
 func (c *UserCreatedConsumer) handleMessage(ctx context.Context, body []byte) error {
@@ -50,7 +63,6 @@ func (c *UserCreatedConsumer) handleMessage(ctx context.Context, body []byte) er
 		return err
 	}
 
-	// Demo: just log the event
 	c.log.WithContext(ctx).Info("received UserCreated event",
 		zap.Uint("user_id", event.Payload.ID),
 		zap.String("user_name", event.Payload.Name),
@@ -58,10 +70,68 @@ func (c *UserCreatedConsumer) handleMessage(ctx context.Context, body []byte) er
 		zap.String("trace_id", event.TraceID),
 	)
 
-	// In a real application, you might:
-	// - Cache the user info
-	// - Update a local read model
-	// - Trigger some business logic
+	if c.readModel == nil {
+		return nil
+	}
+
+	user := &ports.UserInfo{
+		ID:        event.Payload.ID,
+		Name:      event.Payload.Name,
+		Email:     event.Payload.Email,
+		UpdatedAt: event.Timestamp,
+		Sequence:  event.Sequence,
+	}
 
+	// Events without an ID can't be tracked and are applied every time;
+	// the ordering check still keeps redeliveries from going backwards
+	if event.EventID == "" {
+		stored, err := c.readModel.Upsert(ctx, user)
+		if err != nil {
+			return err
+		}
+		if !stored {
+			c.logOutOfOrder(ctx, &event)
+		}
+		return nil
+	}
+
+	var processed, stored bool
+	err := c.readModel.Transaction(ctx, func(readModel ports.UserReadModel) error {
+		fresh, err := readModel.MarkProcessed(ctx, event.EventID)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			processed = true
+			return nil
+		}
+		stored, err = readModel.Upsert(ctx, user)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if processed {
+		metrics.DuplicateEvents.Inc()
+		c.log.WithContext(ctx).Info("ignored already processed UserCreated event",
+			zap.Uint("user_id", event.Payload.ID),
+			zap.String("event_id", event.EventID),
+		)
+		return nil
+	}
+	if !stored {
+		c.logOutOfOrder(ctx, &event)
+	}
 	return nil
 }
+
+// logOutOfOrder notes an event that lost to a newer one already stored. It is
+// acknowledged anyway: the newer event won.
+func (c *UserCreatedConsumer) logOutOfOrder(ctx context.Context, event *events.UserCreatedEvent) {
+	c.log.WithContext(ctx).Info("ignored out-of-order UserCreated event",
+		zap.Uint("user_id", event.Payload.ID),
+		zap.String("event_id", event.EventID),
+		zap.Time("event_timestamp", event.Timestamp),
+		zap.Uint64("event_sequence", event.Sequence),
+	)
+}
@@ -0,0 +1,102 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+)
+
+// deliverUserCreated hands each event to the consumer in order
+func deliverUserCreated(t *testing.T, consumer *UserCreatedConsumer, evts ...*events.UserCreatedEvent) {
+	t.Helper()
+
+	for _, event := range evts {
+		body, err := json.Marshal(event)
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		if err := consumer.handleMessage(context.Background(), body); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestUserCreatedConsumer_IgnoresOutOfOrderEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		// skew adjusts the events after they are built
+		skew func(older, newer *events.UserCreatedEvent)
+	}{
+		{
+			name: "sequenced",
+			skew: func(older, newer *events.UserCreatedEvent) {},
+		},
+		{
+			// The producer's clock stepped back between the two events
+			name: "sequenced with clock skew",
+			skew: func(older, newer *events.UserCreatedEvent) {
+				newer.Timestamp = older.Timestamp.Add(-time.Minute)
+			},
+		},
+		{
+			// Producers that predate sequences are ordered by timestamp
+			name: "unsequenced",
+			skew: func(older, newer *events.UserCreatedEvent) {
+				older.Sequence, newer.Sequence = 0, 0
+				older.Timestamp = newer.Timestamp.Add(-time.Minute)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			readModel := &inMemoryUserReadModel{users: map[uint]*ports.UserInfo{}}
+			consumer := &UserCreatedConsumer{readModel: readModel, log: logger.New("test", "debug")}
+
+			older := events.NewUserCreatedEvent(1, "Old Name", "old@example.com", time.Now(), "")
+			newer := events.NewUserCreatedEvent(1, "New Name", "new@example.com", time.Now(), "")
+			tt.skew(older, newer)
+
+			// Act: the newer event arrives first, then a redelivery of the older one
+			deliverUserCreated(t, consumer, newer, older)
+
+			// Assert
+			user := readModel.users[1]
+			if user == nil {
+				t.Fatal("expected user to be stored")
+			}
+			if user.Name != "New Name" || user.Email != "new@example.com" {
+				t.Errorf("expected newer event to win, got %+v", user)
+			}
+		})
+	}
+}
+
+func TestUserCreatedConsumer_SkipsProcessedEventsAfterRestart(t *testing.T) {
+	// Arrange: the read-model outlives the consumer, as the database does
+	readModel := &inMemoryUserReadModel{users: map[uint]*ports.UserInfo{}}
+	log := logger.New("test", "debug")
+	event := events.NewUserCreatedEvent(1, "John Doe", "john@example.com", time.Now(), "")
+
+	first := &UserCreatedConsumer{readModel: readModel, log: log}
+	deliverUserCreated(t, first, event)
+
+	// Act: a new consumer, with empty in-memory deduplication, gets the
+	// event redelivered
+	restarted := &UserCreatedConsumer{readModel: readModel, log: log}
+	deliverUserCreated(t, restarted, event)
+
+	// Assert
+	if readModel.upserts != 1 {
+		t.Errorf("expected the event to be applied once, got %d upserts", readModel.upserts)
+	}
+	if !readModel.processed[event.EventID] {
+		t.Errorf("expected event %s to be recorded as processed", event.EventID)
+	}
+}
@@ -0,0 +1,113 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"go-micro/internal/orders/application"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/rabbitmq"
+)
+
+// maxPaymentRetries caps the retries of a payment whose order is not found.
+// The payment may overtake the order's creation, but an order still missing
+// after this many retries, a second apart, is not coming.
+const maxPaymentRetries = 10
+
+// PaymentSucceededConsumer consumes PaymentSucceeded events and confirms
+// orders. Payments that cannot confirm their order are dead-lettered to
+// rabbitmq.DeadLetterQueueName of its queue, to be refunded.
+type PaymentSucceededConsumer struct {
+	consumer *rabbitmq.Consumer
+	useCase  *application.OrderUseCase
+	log      *logger.Logger
+}
+
+// NewPaymentSucceededConsumer creates a new consumer for PaymentSucceeded events
+func NewPaymentSucceededConsumer(conn *rabbitmq.Connection, queuePrefix string, useCase *application.OrderUseCase, log *logger.Logger) (*PaymentSucceededConsumer, error) {
+	// Declare the payments exchange so the queue can be bound even before
+	// the payments producer has started.
+	if _, err := rabbitmq.NewPublisher(conn, events.ExchangePayments, log); err != nil {
+		return nil, err
+	}
+
+	consumer, err := rabbitmq.NewConsumer(
+		conn,
+		queuePrefix,
+		"orders.payment-succeeded", // queue name
+		events.ExchangePayments,    // exchange
+		[]string{events.BindingKey(events.RoutingKeyPaymentSucceeded)},
+		log,
+		rabbitmq.WithMaxRetries(maxPaymentRetries),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentSucceededConsumer{
+		consumer: consumer,
+		useCase:  useCase,
+		log:      log,
+	}, nil
+}
+
+// SetDeduplicator skips redelivered events; call it before Run
+func (c *PaymentSucceededConsumer) SetDeduplicator(d *rabbitmq.Deduplicator) {
+	c.consumer.SetDeduplicator(d)
+}
+
+// Run consumes PaymentSucceeded events until ctx is cancelled, returning once the
+// event in flight has been handled
+func (c *PaymentSucceededConsumer) Run(ctx context.Context) error {
+	return c.consumer.Run(ctx, c.handleMessage)
+}
+
+func (c *PaymentSucceededConsumer) handleMessage(ctx context.Context, body []byte) error {
+	var event events.PaymentSucceededEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.log.WithContext(ctx).Error("failed to unmarshal PaymentSucceededEvent",
+			zap.Error(err),
+		)
+		// Redelivering a malformed event cannot fix it
+		return rabbitmq.Permanent(err)
+	}
+
+	_, err := c.useCase.ConfirmOrder(ctx, application.ConfirmOrderInput{
+		ID: event.Payload.OrderID,
+	})
+	if err != nil {
+		// The payment may arrive before the order is persisted; returning the
+		// error retries the message, up to maxPaymentRetries times.
+		if errors.Is(err, errors.CodeNotFound) {
+			c.log.WithContext(ctx).Warn("order not found for payment, retrying",
+				zap.Uint("order_id", event.Payload.OrderID),
+				zap.String("payment_id", event.Payload.PaymentID),
+			)
+			return err
+		}
+
+		// A non-pending order (e.g. cancelled) can never be confirmed, and
+		// the customer paid for it: dead-letter the payment to be refunded
+		if errors.Is(err, errors.CodeConflict) {
+			c.log.WithContext(ctx).Warn("payment received for non-pending order, dead-lettering it for refund",
+				zap.Uint("order_id", event.Payload.OrderID),
+				zap.String("payment_id", event.Payload.PaymentID),
+			)
+			return rabbitmq.Permanent(err)
+		}
+
+		return err
+	}
+
+	c.log.WithContext(ctx).Info("order confirmed from payment",
+		zap.Uint("order_id", event.Payload.OrderID),
+		zap.String("payment_id", event.Payload.PaymentID),
+		zap.String("trace_id", event.TraceID),
+	)
+
+	return nil
+}
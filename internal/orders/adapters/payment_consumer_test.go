@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-micro/internal/orders/application"
+	"go-micro/internal/orders/domain"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/rabbitmq"
+)
+
+func TestPaymentSucceededConsumer_HandleMessage(t *testing.T) {
+	payment := func(orderID uint) []byte {
+		body, err := json.Marshal(events.NewPaymentSucceededEvent("pay-1", orderID, 10, time.Now(), ""))
+		if err != nil {
+			t.Fatalf("failed to marshal event: %v", err)
+		}
+		return body
+	}
+
+	tests := []struct {
+		name          string
+		body          []byte
+		wantErr       bool
+		wantPermanent bool
+		wantStatus    domain.OrderStatus
+	}{
+		{"confirms a pending order", payment(1), false, false, domain.OrderStatusConfirmed},
+		{"retries a missing order", payment(99), true, false, domain.OrderStatusPending},
+		{"dead-letters a payment for a cancelled order", payment(2), true, true, domain.OrderStatusPending},
+		{"dead-letters a malformed event", []byte(`{"payload":`), true, true, domain.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := &inMemoryOrderRepository{orders: map[uint]*domain.Order{
+				1: {ID: 1, UserID: 7, Total: 10, Status: domain.OrderStatusPending},
+				2: {ID: 2, UserID: 7, Total: 10, Status: domain.OrderStatusCancelled},
+			}}
+			log := logger.New("test", "debug")
+			consumer := &PaymentSucceededConsumer{useCase: application.NewOrderUseCase(repo, nil, nil, log), log: log}
+
+			// Act
+			err := consumer.handleMessage(context.Background(), tt.body)
+
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if rabbitmq.IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("expected permanent=%v, got %v", tt.wantPermanent, err)
+			}
+			if got := repo.orders[1].Status; got != tt.wantStatus {
+				t.Errorf("expected order 1 to be %s, got %s", tt.wantStatus, got)
+			}
+		})
+	}
+}
@@ -11,16 +11,33 @@ import (
 
 // RabbitMQPublisher implements EventPublisher using RabbitMQ
 type RabbitMQPublisher struct {
-	publisher *rabbitmq.Publisher
-	log       *logger.Logger
+	publisher  *rabbitmq.Publisher
+	routingKey events.RoutingKeyFunc
+	log        *logger.Logger
+}
+
+// PublisherOption configures a RabbitMQPublisher
+type PublisherOption func(*RabbitMQPublisher)
+
+// WithRoutingKeyFunc sets how routing keys are computed from events.
+// The default publishes each event under its base routing key.
+func WithRoutingKeyFunc(fn events.RoutingKeyFunc) PublisherOption {
+	return func(p *RabbitMQPublisher) {
+		p.routingKey = fn
+	}
 }
 
 // NewRabbitMQPublisher creates a new RabbitMQ event publisher
-func NewRabbitMQPublisher(publisher *rabbitmq.Publisher, log *logger.Logger) *RabbitMQPublisher {
-	return &RabbitMQPublisher{
-		publisher: publisher,
-		log:       log,
+func NewRabbitMQPublisher(publisher *rabbitmq.Publisher, log *logger.Logger, opts ...PublisherOption) *RabbitMQPublisher {
+	p := &RabbitMQPublisher{
+		publisher:  publisher,
+		routingKey: events.DefaultRoutingKey,
+		log:        log,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
 // PublishOrderCreated publishes an order created event
@@ -36,5 +53,49 @@ func (p *RabbitMQPublisher) PublishOrderCreated(ctx context.Context, order *doma
 		traceID,
 	)
 
-	return p.publisher.Publish(ctx, events.RoutingKeyOrderCreated, event)
+	return p.publisher.Publish(ctx, p.routingKey(events.RoutingKeyOrderCreated, event), event)
+}
+
+// PublishOrderConfirmed publishes an order confirmed event
+func (p *RabbitMQPublisher) PublishOrderConfirmed(ctx context.Context, order *domain.Order) error {
+	traceID := logger.GetTraceID(ctx)
+
+	event := events.NewOrderConfirmedEvent(
+		order.ID,
+		order.UserID,
+		order.UpdatedAt,
+		traceID,
+	)
+
+	return p.publisher.Publish(ctx, p.routingKey(events.RoutingKeyOrderConfirmed, event), event)
+}
+
+// PublishOrderCancelled publishes an order cancelled event
+func (p *RabbitMQPublisher) PublishOrderCancelled(ctx context.Context, order *domain.Order, reason string) error {
+	traceID := logger.GetTraceID(ctx)
+
+	event := events.NewOrderCancelledEvent(
+		order.ID,
+		order.UserID,
+		reason,
+		order.UpdatedAt,
+		traceID,
+	)
+
+	return p.publisher.Publish(ctx, p.routingKey(events.RoutingKeyOrderCancelled, event), event)
+}
+
+// PublishOrderReopened publishes an order reopened event
+func (p *RabbitMQPublisher) PublishOrderReopened(ctx context.Context, order *domain.Order) error {
+	traceID := logger.GetTraceID(ctx)
+
+	event := events.NewOrderReopenedEvent(
+		order.ID,
+		order.UserID,
+		string(order.Status),
+		order.UpdatedAt,
+		traceID,
+	)
+
+	return p.publisher.Publish(ctx, p.routingKey(events.RoutingKeyOrderReopened, event), event)
 }
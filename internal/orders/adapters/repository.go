@@ -6,19 +6,45 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"go-micro/internal/orders/domain"
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/db"
+	"go-micro/pkg/db/scopes"
 	apperrors "go-micro/pkg/errors"
+	"go-micro/pkg/tenant"
 )
 
 // OrderModel is the GORM model for orders (persistence layer)
 type OrderModel struct {
 	ID        uint               `gorm:"primaryKey"`
-	UserID    uint               `gorm:"index;not null"`
+	UserID    uint               `gorm:"index;not null;uniqueIndex:idx_orders_user_idempotency_key,priority:1"`
 	Total     float64            `gorm:"not null"`
-	Status    domain.OrderStatus `gorm:"size:20;not null;default:'pending'"`
-	CreatedAt time.Time          `gorm:"autoCreateTime"`
+	Status    domain.OrderStatus `gorm:"size:20;not null;default:'pending';index:idx_orders_status_created_at,priority:1"`
+	CreatedAt time.Time          `gorm:"autoCreateTime;index:idx_orders_status_created_at,priority:2;index:idx_orders_created_at"`
 	UpdatedAt time.Time          `gorm:"autoUpdateTime"`
+
+	// Stored inverted so the zero value (and existing rows) mean verified;
+	// GORM skips zero values on columns with a default on insert
+	UserUnverified bool `gorm:"not null;default:false;index"`
+
+	// Anonymized orders belonged to a deleted user and have user_id 0
+	Anonymized bool `gorm:"not null;default:false"`
+
+	// CancelledAt is when the order was last cancelled, NULL otherwise
+	CancelledAt *time.Time
+
+	// TenantID is the tenant the order belongs to; existing rows and requests
+	// without a tenant use the empty one
+	TenantID string `gorm:"size:64;not null;default:'';index"`
+
+	// IdempotencyKey is NULL for orders created without one, so only keyed
+	// orders are held unique per user
+	IdempotencyKey *string `gorm:"size:255;uniqueIndex:idx_orders_user_idempotency_key,priority:2"`
+
+	// Soft delete: GORM excludes rows with a deleted_at from regular queries
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName returns the table name for GORM
@@ -26,6 +52,37 @@ func (OrderModel) TableName() string {
 	return "orders"
 }
 
+// OrderArchiveModel is the GORM model for archived orders: old confirmed
+// orders moved out of the orders table to keep it small
+type OrderArchiveModel struct {
+	ID             uint               `gorm:"primaryKey;autoIncrement:false"`
+	UserID         uint               `gorm:"index;not null"`
+	Total          float64            `gorm:"not null"`
+	Status         domain.OrderStatus `gorm:"size:20;not null"`
+	CreatedAt      time.Time          `gorm:"autoCreateTime:false"`
+	UpdatedAt      time.Time          `gorm:"autoUpdateTime:false"`
+	UserUnverified bool               `gorm:"not null;default:false"`
+	Anonymized     bool               `gorm:"not null;default:false"`
+	TenantID       string             `gorm:"size:64;not null;default:'';index"`
+	IdempotencyKey *string            `gorm:"size:255"`
+	CancelledAt    *time.Time
+	// DeletedAt is kept as is; archived orders are never queried as live ones
+	DeletedAt  *time.Time
+	ArchivedAt time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for GORM
+func (OrderArchiveModel) TableName() string {
+	return "orders_archive"
+}
+
+// orderSortColumns maps allowed sort fields to their columns
+var orderSortColumns = map[string]string{
+	"created_at": "created_at",
+	"total":      "total",
+	"status":     "status",
+}
+
 // PostgresOrderRepository implements OrderRepository using PostgreSQL
 type PostgresOrderRepository struct {
 	db *gorm.DB
@@ -36,18 +93,34 @@ func NewPostgresOrderRepository(db *gorm.DB) *PostgresOrderRepository {
 	return &PostgresOrderRepository{db: db}
 }
 
-// Migrate runs auto-migration for the order model
+// Migrate runs auto-migration for the order and archive models
 func (r *PostgresOrderRepository) Migrate() error {
-	return r.db.AutoMigrate(&OrderModel{})
+	return r.db.AutoMigrate(&OrderModel{}, &OrderArchiveModel{})
 }
 
-// Create creates a new order
+// Create creates a new order. If the user already has an order with its
+// idempotency key, nothing is inserted and ErrIdempotencyKeyReused is returned.
 func (r *PostgresOrderRepository) Create(ctx context.Context, order *domain.Order) error {
 	model := toModel(order)
+	model.TenantID = tenant.FromContext(ctx)
+
+	query := r.db.WithContext(ctx)
+	if model.IdempotencyKey != nil {
+		// Concurrent requests with the same key can all get past the caller's
+		// checks; the unique index lets only one insert through and the others
+		// insert nothing instead of failing
+		query = query.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "idempotency_key"}},
+			DoNothing: true,
+		})
+	}
 
-	result := r.db.WithContext(ctx).Create(model)
+	result := query.Create(model)
 	if result.Error != nil {
-		return result.Error
+		return db.NewError("failed to insert order", result.Error)
+	}
+	if model.IdempotencyKey != nil && result.RowsAffected == 0 {
+		return domain.ErrIdempotencyKeyReused
 	}
 
 	// Update domain entity with generated ID
@@ -58,39 +131,78 @@ func (r *PostgresOrderRepository) Create(ctx context.Context, order *domain.Orde
 	return nil
 }
 
-// GetByID retrieves an order by ID
+// GetByID retrieves an order by ID. Soft-deleted orders are not found.
 func (r *PostgresOrderRepository) GetByID(ctx context.Context, id uint) (*domain.Order, error) {
 	var model OrderModel
 
-	result := r.db.WithContext(ctx).First(&model, id)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).First(&model, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, domain.NewOrderNotFound(id)
 		}
-		return nil, apperrors.NewInternal("failed to get order", result.Error)
+		return nil, db.NewError("failed to get order", result.Error)
 	}
 
 	return toDomain(&model), nil
 }
 
-// Update updates an existing order
-func (r *PostgresOrderRepository) Update(ctx context.Context, order *domain.Order) error {
-	model := toModel(order)
+// GetByIDIncludingDeleted retrieves an order by ID even if it was soft-deleted
+func (r *PostgresOrderRepository) GetByIDIncludingDeleted(ctx context.Context, id uint) (*domain.Order, error) {
+	var model OrderModel
 
-	result := r.db.WithContext(ctx).Save(model)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx), scopes.IncludeDeleted(true)).First(&model, id)
 	if result.Error != nil {
-		return apperrors.NewInternal("failed to update order", result.Error)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, domain.NewOrderNotFound(id)
+		}
+		return nil, db.NewError("failed to get order", result.Error)
 	}
 
-	order.UpdatedAt = model.UpdatedAt
+	return toDomain(&model), nil
+}
+
+// Update updates an existing order, or returns not found if there is none
+// (soft-deleted orders included)
+func (r *PostgresOrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	// Unlike Save, this never inserts: updating a missing order affects no
+	// rows. Select("*") writes zero values too; GORM sets updated_at on target.
+	target := &OrderModel{}
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Model(target).
+		Where("id = ?", order.ID).
+		Select("*").Omit("id", "tenant_id", "created_at", "deleted_at").
+		Updates(toModel(order))
+	if result.Error != nil {
+		return db.NewError("failed to update order", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewOrderNotFound(order.ID)
+	}
+
+	order.UpdatedAt = target.UpdatedAt
 	return nil
 }
 
-// Delete deletes an order by ID
+// UpdateIfPending writes the order's status, cancellation time and user
+// verification if the stored order is still pending
+func (r *PostgresOrderRepository) UpdateIfPending(ctx context.Context, order *domain.Order) (bool, error) {
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Model(&OrderModel{}).
+		Where("id = ? AND status = ?", order.ID, domain.OrderStatusPending).
+		Updates(map[string]interface{}{
+			"status":          order.Status,
+			"user_unverified": !order.UserVerified,
+			"cancelled_at":    order.CancelledAt,
+		})
+	if result.Error != nil {
+		return false, db.NewError("failed to update order", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete soft-deletes an order by ID
 func (r *PostgresOrderRepository) Delete(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&OrderModel{}, id)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Delete(&OrderModel{}, id)
 	if result.Error != nil {
-		return apperrors.NewInternal("failed to delete order", result.Error)
+		return db.NewError("failed to delete order", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return domain.NewOrderNotFound(id)
@@ -98,13 +210,163 @@ func (r *PostgresOrderRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
-// GetByUserID retrieves orders for a user
+// GetByUserID retrieves orders for a user. No orders yields a non-nil empty slice.
 func (r *PostgresOrderRepository) GetByUserID(ctx context.Context, userID uint) ([]*domain.Order, error) {
 	var models []OrderModel
 
-	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&models)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("user_id = ?", userID).
+		Scopes(scopes.OrderBy("created_at", true, orderSortColumns, "created_at")).
+		Find(&models)
+	if result.Error != nil {
+		return nil, db.NewError("failed to get orders by user", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = toDomain(&model)
+	}
+
+	return orders, nil
+}
+
+// GetStalePending retrieves up to limit pending orders created before cutoff, oldest first
+func (r *PostgresOrderRepository) GetStalePending(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Order, error) {
+	var models []OrderModel
+
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("status = ? AND created_at < ?", domain.OrderStatusPending, cutoff).
+		Scopes(
+			scopes.OrderBy("created_at", false, orderSortColumns, "created_at"),
+			scopes.Limit(limit),
+		).
+		Find(&models)
+	if result.Error != nil {
+		return nil, db.NewError("failed to get stale pending orders", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = toDomain(&model)
+	}
+
+	return orders, nil
+}
+
+// ArchiveConfirmed moves up to limit confirmed orders created before cutoff,
+// oldest first, to the archive table in one transaction, and returns how many
+// it moved. Rows locked by a concurrent archiver are skipped.
+func (r *PostgresOrderRepository) ArchiveConfirmed(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	archived := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var models []OrderModel
+		result := tx.Unscoped().
+			Where("status = ? AND created_at < ?", domain.OrderStatusConfirmed, cutoff).
+			Scopes(
+				scopes.OrderBy("created_at", false, orderSortColumns, "created_at"),
+				scopes.Limit(limit),
+			).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Find(&models)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(models) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		ids := make([]uint, len(models))
+		archives := make([]OrderArchiveModel, len(models))
+		for i, model := range models {
+			ids[i] = model.ID
+			archives[i] = toArchiveModel(&model, now)
+		}
+		if err := tx.Create(&archives).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&OrderModel{}, ids).Error; err != nil {
+			return err
+		}
+
+		archived = len(models)
+		return nil
+	})
+	if err != nil {
+		return 0, db.NewError("failed to archive confirmed orders", err)
+	}
+	return archived, nil
+}
+
+// ListArchived retrieves a page of archived orders, newest first, optionally
+// filtered by user. Rows marked deleted are left out.
+func (r *PostgresOrderRepository) ListArchived(ctx context.Context, filter ports.OrderListFilter) ([]*domain.Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&OrderArchiveModel{}).
+		Scopes(scopes.Tenant(ctx)).
+		Where("deleted_at IS NULL")
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, db.NewError("failed to count archived orders", err)
+	}
+
+	var models []OrderArchiveModel
+	result := query.Scopes(
+		scopes.OrderBy("created_at", true, orderSortColumns, "created_at"),
+		scopes.Paginate(filter.Page, filter.PageSize),
+	).Find(&models)
+	if result.Error != nil {
+		return nil, 0, db.NewError("failed to list archived orders", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = archiveToDomain(&model)
+	}
+
+	return orders, total, nil
+}
+
+// AnonymizeArchived anonymizes the user's archived orders the same way
+// domain.Order.Anonymize does live ones, also marking them deleted when
+// softDelete is set, and returns how many rows it changed
+func (r *PostgresOrderRepository) AnonymizeArchived(ctx context.Context, userID uint, now time.Time, softDelete bool) (int, error) {
+	updates := map[string]interface{}{
+		"user_id":         0,
+		"anonymized":      true,
+		"idempotency_key": nil,
+		"updated_at":      now,
+	}
+	if softDelete {
+		updates["deleted_at"] = now
+	}
+
+	result := r.db.WithContext(ctx).Model(&OrderArchiveModel{}).
+		Scopes(scopes.Tenant(ctx)).
+		Where("user_id = ?", userID).
+		Updates(updates)
+	if result.Error != nil {
+		return 0, db.NewError("failed to anonymize archived orders", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// GetUnverified retrieves up to limit pending orders whose user is not verified, oldest first
+func (r *PostgresOrderRepository) GetUnverified(ctx context.Context, limit int) ([]*domain.Order, error) {
+	var models []OrderModel
+
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("status = ? AND user_unverified = ?", domain.OrderStatusPending, true).
+		Scopes(
+			scopes.OrderBy("created_at", false, orderSortColumns, "created_at"),
+			scopes.Limit(limit),
+		).
+		Find(&models)
 	if result.Error != nil {
-		return nil, apperrors.NewInternal("failed to get orders by user", result.Error)
+		return nil, db.NewError("failed to get unverified orders", result.Error)
 	}
 
 	orders := make([]*domain.Order, len(models))
@@ -115,26 +377,234 @@ func (r *PostgresOrderRepository) GetByUserID(ctx context.Context, userID uint)
 	return orders, nil
 }
 
+// GetByStatus retrieves up to limit orders in status, oldest first, skipping
+// the first offset. It is served by the (status, created_at) index.
+func (r *PostgresOrderRepository) GetByStatus(ctx context.Context, status domain.OrderStatus, limit, offset int) ([]*domain.Order, error) {
+	var models []OrderModel
+
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("status = ?", status).
+		Scopes(
+			scopes.OrderBy("created_at", false, orderSortColumns, "created_at"),
+			scopes.Limit(limit),
+		).
+		Offset(offset).
+		Find(&models)
+	if result.Error != nil {
+		return nil, db.NewError("failed to get orders by status", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = toDomain(&model)
+	}
+
+	return orders, nil
+}
+
+// GetByIDs retrieves the orders among ids with a single WHERE id IN query
+func (r *PostgresOrderRepository) GetByIDs(ctx context.Context, ids []uint) ([]*domain.Order, error) {
+	var models []OrderModel
+
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("id IN ?", ids).
+		Find(&models)
+	if result.Error != nil {
+		return nil, db.NewError("failed to get orders by ids", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = toDomain(&model)
+	}
+
+	return orders, nil
+}
+
+// GetByIdempotencyKey retrieves the order a user created with an idempotency key
+func (r *PostgresOrderRepository) GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*domain.Order, error) {
+	var model OrderModel
+
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).
+		Where("user_id = ? AND idempotency_key = ?", userID, key).
+		First(&model)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFound("order", key)
+		}
+		return nil, db.NewError("failed to get order by idempotency key", result.Error)
+	}
+
+	return toDomain(&model), nil
+}
+
+// StatsByUser counts and sums a user's orders per status in one grouped query
+func (r *PostgresOrderRepository) StatsByUser(ctx context.Context, userID uint) ([]ports.OrderStats, error) {
+	var rows []struct {
+		Status domain.OrderStatus
+		Count  int64
+		Total  float64
+	}
+
+	result := r.db.WithContext(ctx).Model(&OrderModel{}).Scopes(scopes.Tenant(ctx)).
+		Select("status, COUNT(*) AS count, COALESCE(SUM(total), 0) AS total").
+		Where("user_id = ?", userID).
+		Group("status").
+		Order("status").
+		Find(&rows)
+	if result.Error != nil {
+		return nil, db.NewError("failed to get order stats", result.Error)
+	}
+
+	stats := make([]ports.OrderStats, len(rows))
+	for i, row := range rows {
+		stats[i] = ports.OrderStats{Status: row.Status, Count: row.Count, Total: row.Total}
+	}
+	return stats, nil
+}
+
+// CountByWindow counts and sums the orders created since a time in one
+// aggregate query, a range scan of idx_orders_created_at
+func (r *PostgresOrderRepository) CountByWindow(ctx context.Context, since time.Time) (int64, float64, error) {
+	var row struct {
+		Count   int64
+		Revenue float64
+	}
+
+	result := r.db.WithContext(ctx).Model(&OrderModel{}).Scopes(scopes.Tenant(ctx)).
+		Select("COUNT(*) AS count, COALESCE(SUM(CASE WHEN status <> ? THEN total END), 0) AS revenue",
+			domain.OrderStatusCancelled).
+		Where("created_at >= ?", since).
+		Find(&row)
+	if result.Error != nil {
+		return 0, 0, db.NewError("failed to count orders in window", result.Error)
+	}
+	return row.Count, row.Revenue, nil
+}
+
+// List retrieves a page of orders, newest first, and the total match count
+func (r *PostgresOrderRepository) List(ctx context.Context, filter ports.OrderListFilter) ([]*domain.Order, int64, error) {
+	query := r.db.WithContext(ctx).Model(&OrderModel{}).Scopes(scopes.Tenant(ctx))
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, db.NewError("failed to count orders", err)
+	}
+
+	var models []OrderModel
+	result := query.Scopes(
+		scopes.OrderBy("created_at", true, orderSortColumns, "created_at"),
+		scopes.Paginate(filter.Page, filter.PageSize),
+	).Find(&models)
+	if result.Error != nil {
+		return nil, 0, db.NewError("failed to list orders", result.Error)
+	}
+
+	orders := make([]*domain.Order, len(models))
+	for i, model := range models {
+		orders[i] = toDomain(&model)
+	}
+
+	return orders, total, nil
+}
+
+// Transaction runs fn with a repository bound to a single database transaction
+func (r *PostgresOrderRepository) Transaction(ctx context.Context, fn func(repo ports.OrderRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&PostgresOrderRepository{db: tx})
+	})
+}
+
 // toModel converts a domain entity to a GORM model
 func toModel(order *domain.Order) *OrderModel {
-	return &OrderModel{
+	model := &OrderModel{
 		ID:        order.ID,
 		UserID:    order.UserID,
 		Total:     order.Total,
 		Status:    order.Status,
 		CreatedAt: order.CreatedAt,
 		UpdatedAt: order.UpdatedAt,
+
+		UserUnverified: !order.UserVerified,
+		Anonymized:     order.Anonymized,
+		CancelledAt:    order.CancelledAt,
+	}
+	if order.IdempotencyKey != "" {
+		model.IdempotencyKey = &order.IdempotencyKey
+	}
+	if order.DeletedAt != nil {
+		model.DeletedAt = gorm.DeletedAt{Time: *order.DeletedAt, Valid: true}
+	}
+	return model
+}
+
+// toArchiveModel copies an order row into its archived form
+func toArchiveModel(model *OrderModel, archivedAt time.Time) OrderArchiveModel {
+	archive := OrderArchiveModel{
+		ID:             model.ID,
+		UserID:         model.UserID,
+		Total:          model.Total,
+		Status:         model.Status,
+		CreatedAt:      model.CreatedAt,
+		UpdatedAt:      model.UpdatedAt,
+		UserUnverified: model.UserUnverified,
+		Anonymized:     model.Anonymized,
+		CancelledAt:    model.CancelledAt,
+		TenantID:       model.TenantID,
+		IdempotencyKey: model.IdempotencyKey,
+		ArchivedAt:     archivedAt,
+	}
+	if model.DeletedAt.Valid {
+		deletedAt := model.DeletedAt.Time
+		archive.DeletedAt = &deletedAt
+	}
+	return archive
+}
+
+// archiveToDomain converts an archived order row to a domain entity
+func archiveToDomain(model *OrderArchiveModel) *domain.Order {
+	order := &domain.Order{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		Total:     model.Total,
+		Status:    model.Status,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+
+		UserVerified: !model.UserUnverified,
+		Anonymized:   model.Anonymized,
+		CancelledAt:  model.CancelledAt,
+		DeletedAt:    model.DeletedAt,
+	}
+	if model.IdempotencyKey != nil {
+		order.IdempotencyKey = *model.IdempotencyKey
 	}
+	return order
 }
 
 // toDomain converts a GORM model to a domain entity
 func toDomain(model *OrderModel) *domain.Order {
-	return &domain.Order{
+	order := &domain.Order{
 		ID:        model.ID,
 		UserID:    model.UserID,
 		Total:     model.Total,
 		Status:    model.Status,
 		CreatedAt: model.CreatedAt,
 		UpdatedAt: model.UpdatedAt,
+
+		UserVerified: !model.UserUnverified,
+		Anonymized:   model.Anonymized,
+		CancelledAt:  model.CancelledAt,
+	}
+	if model.IdempotencyKey != nil {
+		order.IdempotencyKey = *model.IdempotencyKey
+	}
+	if model.DeletedAt.Valid {
+		deletedAt := model.DeletedAt.Time
+		order.DeletedAt = &deletedAt
 	}
+	return order
 }
@@ -0,0 +1,322 @@
+package adapters
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"go-micro/internal/orders/domain"
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/tenant"
+)
+
+// newDryRunDB returns a DB that builds statements without executing them, so
+// every write affects no rows; the SQL of the last insert or update is stored
+// in sql
+func newDryRunDB(t *testing.T, sql *string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+	capture := func(tx *gorm.DB) {
+		*sql = tx.Statement.SQL.String()
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	return db
+}
+
+func TestPostgresOrderRepository_Update_NotFound(t *testing.T) {
+	// Arrange
+	var sql string
+	repo := NewPostgresOrderRepository(newDryRunDB(t, &sql))
+	order := &domain.Order{ID: 42, UserID: 1, Total: 0, Status: domain.OrderStatusPending}
+
+	// Act
+	err := repo.Update(context.Background(), order)
+
+	// Assert: the missing order is reported instead of being inserted
+	if !errors.Is(err, errors.CodeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if !strings.HasPrefix(sql, `UPDATE "orders"`) || !strings.Contains(sql, "WHERE id = $") {
+		t.Errorf("expected an UPDATE by id, got %s", sql)
+	}
+	if !strings.Contains(sql, `"total"=$`) {
+		t.Errorf("expected zero-valued total to be written, got %s", sql)
+	}
+}
+
+func TestPostgresOrderRepository_Update_TenantScoped(t *testing.T) {
+	// Arrange
+	var sql string
+	repo := NewPostgresOrderRepository(newDryRunDB(t, &sql))
+	ctx := tenant.WithContext(context.Background(), "acme")
+
+	// Act
+	_ = repo.Update(ctx, &domain.Order{ID: 42, UserID: 1, Total: 10, Status: domain.OrderStatusPending})
+
+	// Assert: only the tenant's order is matched, and its tenant is kept
+	where := sql[strings.Index(sql, "WHERE"):]
+	if !strings.Contains(where, "tenant_id = $") {
+		t.Errorf("expected the update to filter by tenant, got %s", sql)
+	}
+	if strings.Contains(sql, `"tenant_id"=$`) {
+		t.Errorf("expected tenant_id not to be overwritten, got %s", sql)
+	}
+}
+
+func TestPostgresOrderRepository_Create_IdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		wantConflict bool
+	}{
+		{"without key", "", false},
+		{"with key", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var sql string
+			repo := NewPostgresOrderRepository(newDryRunDB(t, &sql))
+			order := &domain.Order{UserID: 1, Total: 10, Status: domain.OrderStatusPending, IdempotencyKey: tt.key}
+
+			// Act
+			err := repo.Create(context.Background(), order)
+
+			// Assert: a keyed insert that inserts nothing (as in a dry run)
+			// lost the race to an existing order
+			onConflict := strings.Contains(sql, `ON CONFLICT ("user_id","idempotency_key") DO NOTHING`)
+			if onConflict != tt.wantConflict {
+				t.Errorf("expected ON CONFLICT %v, got %s", tt.wantConflict, sql)
+			}
+			if gotConflict := stderrors.Is(err, domain.ErrIdempotencyKeyReused); gotConflict != tt.wantConflict {
+				t.Errorf("expected reused key %v, got %v", tt.wantConflict, err)
+			}
+		})
+	}
+}
+
+func TestOrderModel_IdempotencyKeyIndex(t *testing.T) {
+	// Arrange
+	s, err := schema.Parse(&OrderModel{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("failed to parse model: %v", err)
+	}
+
+	// Act
+	index, ok := s.ParseIndexes()["idx_orders_user_idempotency_key"]
+
+	// Assert: the migration creates a unique index on (user_id, idempotency_key)
+	if !ok {
+		t.Fatal("expected idx_orders_user_idempotency_key")
+	}
+	var columns []string
+	for _, field := range index.Fields {
+		columns = append(columns, field.DBName)
+	}
+	if index.Class != "UNIQUE" || strings.Join(columns, ",") != "user_id,idempotency_key" {
+		t.Errorf("expected unique index on user_id,idempotency_key, got %s on %v", index.Class, columns)
+	}
+}
+
+func TestPostgresOrderRepository_UpdateIfPending(t *testing.T) {
+	// Arrange
+	var sql string
+	repo := NewPostgresOrderRepository(newDryRunDB(t, &sql))
+	order := &domain.Order{ID: 42, UserID: 1, Total: 10, Status: domain.OrderStatusCancelled}
+
+	// Act
+	updated, err := repo.UpdateIfPending(context.Background(), order)
+
+	// Assert: only the reconciled columns are written, and only over a pending order
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated {
+		t.Error("expected a dry run to update nothing")
+	}
+	for _, want := range []string{`"status"=$`, `"user_unverified"=$`, `"cancelled_at"=$`, "id = $", "status = $"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("expected %q in %s", want, sql)
+		}
+	}
+	if strings.Contains(sql, `"total"`) || strings.Contains(sql, `"user_id"`) {
+		t.Errorf("expected the other columns to be left alone, got %s", sql)
+	}
+}
+
+func TestPostgresOrderRepository_AnonymizeArchived(t *testing.T) {
+	tests := []struct {
+		name        string
+		softDelete  bool
+		wantDeleted bool
+	}{
+		{"anonymize", false, false},
+		{"delete", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var sql string
+			repo := NewPostgresOrderRepository(newDryRunDB(t, &sql))
+			ctx := tenant.WithContext(context.Background(), "acme")
+
+			// Act
+			_, err := repo.AnonymizeArchived(ctx, 7, time.Now(), tt.softDelete)
+
+			// Assert: the user's archived orders within the tenant are detached
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			for _, want := range []string{
+				`UPDATE "orders_archive" SET`,
+				`"user_id"=$`, `"anonymized"=$`, `"idempotency_key"=$`,
+				"tenant_id = $", "user_id = $",
+			} {
+				if !strings.Contains(sql, want) {
+					t.Errorf("expected %q in %s", want, sql)
+				}
+			}
+			if got := strings.Contains(sql, `"deleted_at"=$`); got != tt.wantDeleted {
+				t.Errorf("expected deleted_at set=%v, got %s", tt.wantDeleted, sql)
+			}
+		})
+	}
+}
+
+func TestPostgresOrderRepository_ListArchived(t *testing.T) {
+	// Arrange
+	var unused, sql string
+	db := newDryRunDB(t, &unused)
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture_query", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	repo := NewPostgresOrderRepository(db)
+	ctx := tenant.WithContext(context.Background(), "acme")
+
+	// Act
+	_, _, err := repo.ListArchived(ctx, ports.OrderListFilter{UserID: 7, Page: 1, PageSize: 10})
+
+	// Assert: a dry run keeps the first statement's SQL, so only the count's
+	// filters are checked: the archive, without orders deleted with their user
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{
+		`FROM "orders_archive"`, "deleted_at IS NULL", "tenant_id = $", "user_id = $",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("expected %q in %s", want, sql)
+		}
+	}
+}
+
+func TestPostgresOrderRepository_CountByWindow(t *testing.T) {
+	// Arrange
+	var unused, sql string
+	db := newDryRunDB(t, &unused)
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture_query", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	repo := NewPostgresOrderRepository(db)
+	ctx := tenant.WithContext(context.Background(), "acme")
+
+	// Act
+	_, _, err := repo.CountByWindow(ctx, time.Now().Add(-24*time.Hour))
+
+	// Assert: one aggregate over the window, within the tenant
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{
+		"SELECT COUNT(*) AS count, COALESCE(SUM(CASE WHEN status <> $1 THEN total END), 0) AS revenue",
+		"created_at >= $",
+		"tenant_id = $",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("expected %q in %s", want, sql)
+		}
+	}
+	if strings.Contains(sql, "GROUP BY") {
+		t.Errorf("expected a single aggregate row, got %s", sql)
+	}
+}
+
+func TestPostgresOrderRepository_GetByID_DatabaseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{"connection lost", driver.ErrBadConn, errors.CodeUnavailable},
+		{"query rejected", &pgconn.PgError{Code: "42P01", Message: `relation "orders" does not exist`}, errors.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the query fails with the error
+			var unused string
+			db := newDryRunDB(t, &unused)
+			if err := db.Callback().Query().After("gorm:query").Register("test:fail_query", func(tx *gorm.DB) {
+				_ = tx.AddError(tt.err)
+			}); err != nil {
+				t.Fatalf("failed to register callback: %v", err)
+			}
+			repo := NewPostgresOrderRepository(db)
+
+			// Act
+			_, err := repo.GetByID(context.Background(), 42)
+
+			// Assert: only connection failures are worth retrying
+			if !errors.Is(err, tt.wantCode) {
+				t.Errorf("expected %s, got %v", tt.wantCode, err)
+			}
+			if !stderrors.Is(err, tt.err) {
+				t.Errorf("expected the database error as the cause, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOrderModel_CreatedAtIndex(t *testing.T) {
+	// Arrange
+	s, err := schema.Parse(&OrderModel{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatalf("failed to parse model: %v", err)
+	}
+
+	// Act
+	index, ok := s.ParseIndexes()["idx_orders_created_at"]
+
+	// Assert: window counts range-scan created_at on its own index
+	if !ok || len(index.Fields) != 1 || index.Fields[0].DBName != "created_at" {
+		t.Fatalf("expected idx_orders_created_at on created_at, got %+v", index)
+	}
+}
@@ -20,8 +20,9 @@ type GRPCUserClient struct {
 	conn   *grpc.ClientConn
 }
 
-// NewGRPCUserClient creates a new gRPC client for the users service
-func NewGRPCUserClient(cfg *config.Config) (*GRPCUserClient, error) {
+// NewGRPCUserClient creates a new gRPC client for the users service. Extra
+// dial options are applied last, e.g. to dial an in-process server in tests.
+func NewGRPCUserClient(cfg *config.Config, extraOpts ...grpc.DialOption) (*GRPCUserClient, error) {
 	var opts []grpc.DialOption
 
 	// Add client interceptor
@@ -42,6 +43,8 @@ func NewGRPCUserClient(cfg *config.Config) (*GRPCUserClient, error) {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	opts = append(opts, extraOpts...)
+
 	conn, err := grpc.Dial(cfg.UsersGRPCAddr, opts...)
 	if err != nil {
 		return nil, err
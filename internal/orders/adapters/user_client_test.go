@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	userspb "go-micro/api/gen/users/v1"
+	usersapp "go-micro/internal/users/application"
+	usersdomain "go-micro/internal/users/domain"
+	usersinfra "go-micro/internal/users/infrastructure"
+	usersports "go-micro/internal/users/ports"
+	"go-micro/pkg/config"
+	"go-micro/pkg/errors"
+	grpcpkg "go-micro/pkg/grpc"
+	"go-micro/pkg/grpc/grpctest"
+	"go-micro/pkg/logger"
+)
+
+// blockingUserRepository never answers GetByID before the context ends
+type blockingUserRepository struct {
+	inMemoryUserRepository
+}
+
+func (r *blockingUserRepository) GetByID(ctx context.Context, id uint) (*usersdomain.User, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// newTestUserClient serves the users gRPC API in process, backed by repo and
+// behind the same server interceptor as cmd/users, and returns a
+// GRPCUserClient connected to it with the given call timeout
+func newTestUserClient(t *testing.T, repo usersports.UserRepository, timeout time.Duration) *GRPCUserClient {
+	t.Helper()
+
+	log := logger.New("test", "debug")
+	useCase := usersapp.NewUserUseCase(repo, nil, log)
+	server := grpctest.NewServer(t, func(s *grpc.Server) {
+		userspb.RegisterUserServiceServer(s, usersinfra.NewGRPCServer(useCase))
+	}, grpc.ChainUnaryInterceptor(grpcpkg.UnaryServerInterceptor(log, 0)))
+
+	client, err := NewGRPCUserClient(&config.Config{
+		UsersGRPCAddr: grpctest.Target,
+		GRPCTimeout:   timeout,
+	}, server.DialOptions()...)
+	if err != nil {
+		t.Fatalf("failed to create user client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestGRPCUserClient_GetUser(t *testing.T) {
+	// Arrange
+	repo := &inMemoryUserRepository{users: map[string]*usersdomain.User{}}
+	_ = repo.Create(context.Background(), &usersdomain.User{Name: "John Doe", Email: "john@example.com"})
+	client := newTestUserClient(t, repo, time.Second)
+
+	// Act
+	user, err := client.GetUser(context.Background(), 1)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.ID != 1 || user.Name != "John Doe" || user.Email != "john@example.com" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+}
+
+func TestGRPCUserClient_GetUser_NotFound(t *testing.T) {
+	// Arrange
+	repo := &inMemoryUserRepository{users: map[string]*usersdomain.User{}}
+	client := newTestUserClient(t, repo, time.Second)
+
+	// Act
+	_, err := client.GetUser(context.Background(), 42)
+
+	// Assert
+	if !errors.Is(err, errors.CodeNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestGRPCUserClient_GetUser_Timeout(t *testing.T) {
+	// Arrange
+	repo := &blockingUserRepository{inMemoryUserRepository{users: map[string]*usersdomain.User{}}}
+	client := newTestUserClient(t, repo, 50*time.Millisecond)
+
+	// Act
+	start := time.Now()
+	_, err := client.GetUser(context.Background(), 1)
+
+	// Assert
+	if !errors.Is(err, errors.CodeTimeout) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the client timeout to end the call, took %s", elapsed)
+	}
+}
@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+
+	"go.uber.org/zap"
+
+	"go-micro/internal/orders/application"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/rabbitmq"
+)
+
+// UserDeletedConsumer consumes UserDeleted events and erases the deleted
+// user's data from the orders service
+type UserDeletedConsumer struct {
+	consumer *rabbitmq.Consumer
+	useCase  *application.OrderUseCase
+	log      *logger.Logger
+}
+
+// NewUserDeletedConsumer creates a new consumer for UserDeleted events
+func NewUserDeletedConsumer(conn *rabbitmq.Connection, queuePrefix string, useCase *application.OrderUseCase, log *logger.Logger) (*UserDeletedConsumer, error) {
+	consumer, err := rabbitmq.NewConsumer(
+		conn,
+		queuePrefix,
+		"orders.user-deleted", // queue name
+		events.ExchangeUsers,  // exchange
+		[]string{events.BindingKey(events.RoutingKeyUserDeleted)},
+		log,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDeletedConsumer{
+		consumer: consumer,
+		useCase:  useCase,
+		log:      log,
+	}, nil
+}
+
+// SetDeduplicator skips redelivered events; call it before Run
+func (c *UserDeletedConsumer) SetDeduplicator(d *rabbitmq.Deduplicator) {
+	c.consumer.SetDeduplicator(d)
+}
+
+// Run consumes UserDeleted events until ctx is cancelled, returning once the
+// event in flight has been handled
+func (c *UserDeletedConsumer) Run(ctx context.Context) error {
+	return c.consumer.Run(ctx, c.handleMessage)
+}
+
+func (c *UserDeletedConsumer) handleMessage(ctx context.Context, body []byte) error {
+	var event events.UserDeletedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		c.log.WithContext(ctx).Error("failed to unmarshal UserDeletedEvent",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	// A failure requeues the event: the erasure must not be lost
+	output, err := c.useCase.HandleUserDeleted(ctx, application.HandleUserDeletedInput{
+		UserID:    event.Payload.ID,
+		DeletedAt: event.Payload.DeletedAt,
+		Sequence:  event.Sequence,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.WithContext(ctx).Info("user deletion applied",
+		zap.Uint("user_id", event.Payload.ID),
+		zap.Int("orders", output.Orders),
+		zap.String("trace_id", event.TraceID),
+	)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-micro/internal/orders/application"
+	"go-micro/internal/orders/domain"
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/events"
+	"go-micro/pkg/logger"
+)
+
+// inMemoryOrderRepository implements the order repository methods the user
+// deletion path needs; the embedded interface panics on any other call
+type inMemoryOrderRepository struct {
+	ports.OrderRepository
+	orders   map[uint]*domain.Order
+	archived map[uint]*domain.Order
+}
+
+func (r *inMemoryOrderRepository) GetByID(ctx context.Context, id uint) (*domain.Order, error) {
+	order, ok := r.orders[id]
+	if !ok || order.DeletedAt != nil {
+		return nil, errors.NewNotFound("order", id)
+	}
+	return order, nil
+}
+
+func (r *inMemoryOrderRepository) GetByUserID(ctx context.Context, userID uint) ([]*domain.Order, error) {
+	result := []*domain.Order{}
+	for _, order := range r.orders {
+		if order.UserID == userID && order.DeletedAt == nil {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (r *inMemoryOrderRepository) Update(ctx context.Context, order *domain.Order) error {
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *inMemoryOrderRepository) Delete(ctx context.Context, id uint) error {
+	now := time.Now()
+	r.orders[id].DeletedAt = &now
+	return nil
+}
+
+func (r *inMemoryOrderRepository) AnonymizeArchived(ctx context.Context, userID uint, now time.Time, softDelete bool) (int, error) {
+	changed := 0
+	for _, order := range r.archived {
+		if order.UserID != userID {
+			continue
+		}
+		order.Anonymize(now)
+		if softDelete {
+			deletedAt := now
+			order.DeletedAt = &deletedAt
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+func (r *inMemoryOrderRepository) Transaction(ctx context.Context, fn func(repo ports.OrderRepository) error) error {
+	return fn(r)
+}
+
+func TestUserDeletedConsumer_AppliesPolicy(t *testing.T) {
+	tests := []struct {
+		policy      application.UserDeletionPolicy
+		wantDeleted bool
+	}{
+		{application.UserDeletionAnonymize, false},
+		{application.UserDeletionDelete, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			// Arrange
+			repo := &inMemoryOrderRepository{orders: map[uint]*domain.Order{
+				1: {ID: 1, UserID: 7, Total: 10, Status: domain.OrderStatusConfirmed},
+				2: {ID: 2, UserID: 7, Total: 20, Status: domain.OrderStatusPending},
+				3: {ID: 3, UserID: 8, Total: 30, Status: domain.OrderStatusPending},
+			}, archived: map[uint]*domain.Order{
+				4: {ID: 4, UserID: 7, Total: 40, Status: domain.OrderStatusConfirmed, IdempotencyKey: "key-4"},
+				5: {ID: 5, UserID: 8, Total: 50, Status: domain.OrderStatusConfirmed},
+			}}
+			readModel := &inMemoryUserReadModel{users: map[uint]*ports.UserInfo{
+				7: {ID: 7, Name: "John Doe", Email: "john@example.com"},
+			}}
+			log := logger.New("test", "debug")
+			useCase := application.NewOrderUseCase(repo, nil, nil, log)
+			useCase.SetUserReadModel(readModel)
+			useCase.SetUserDeletionPolicy(tt.policy)
+			consumer := &UserDeletedConsumer{useCase: useCase, log: log}
+
+			body, err := json.Marshal(events.NewUserDeletedEvent(7, time.Now(), ""))
+			if err != nil {
+				t.Fatalf("failed to marshal event: %v", err)
+			}
+
+			// Act: the second delivery is a redelivery of the same event
+			for i := 0; i < 2; i++ {
+				if err := consumer.handleMessage(context.Background(), body); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+			}
+
+			// Assert
+			for _, id := range []uint{1, 2} {
+				order := repo.orders[id]
+				if order.UserID != 0 || !order.Anonymized {
+					t.Errorf("expected order %d to be anonymized, got %+v", id, order)
+				}
+				if (order.DeletedAt != nil) != tt.wantDeleted {
+					t.Errorf("expected order %d deleted=%v, got deleted_at %v", id, tt.wantDeleted, order.DeletedAt)
+				}
+			}
+			if other := repo.orders[3]; other.UserID != 8 || other.Anonymized || other.DeletedAt != nil {
+				t.Errorf("expected another user's order to be untouched, got %+v", other)
+			}
+
+			archived := repo.archived[4]
+			if archived.UserID != 0 || !archived.Anonymized || archived.IdempotencyKey != "" {
+				t.Errorf("expected the archived order to be anonymized, got %+v", archived)
+			}
+			if (archived.DeletedAt != nil) != tt.wantDeleted {
+				t.Errorf("expected the archived order deleted=%v, got deleted_at %v", tt.wantDeleted, archived.DeletedAt)
+			}
+			if other := repo.archived[5]; other.UserID != 8 || other.Anonymized {
+				t.Errorf("expected another user's archived order to be untouched, got %+v", other)
+			}
+
+			user := readModel.users[7]
+			if user.DeletedAt == nil || user.Name != "" || user.Email != "" {
+				t.Errorf("expected the read-model to keep only the deletion, got %+v", user)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go-micro/internal/orders/ports"
+	"go-micro/pkg/db"
+)
+
+// UserModel is the GORM model for the local user read-model
+type UserModel struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:100;not null"`
+	Email     string `gorm:"size:255;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// EventTimestamp and EventSequence identify the event the row reflects,
+	// used to ignore out-of-order events. Null for rows written before they
+	// existed, or for events published without a sequence.
+	EventTimestamp *time.Time
+	EventSequence  *int64
+
+	// DeletedAt marks users deleted in the users service. It is a plain
+	// column rather than gorm.DeletedAt so deleted users are still found
+	// and can be reported as inactive.
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+// TableName returns the table name for GORM
+func (UserModel) TableName() string {
+	return "users_read_model"
+}
+
+// ProcessedEventModel is the GORM model for the IDs of events already applied
+// to the read-model. Unlike the consumers' in-memory deduplication it
+// survives restarts.
+type ProcessedEventModel struct {
+	EventID     string `gorm:"primaryKey;size:64"`
+	ProcessedAt time.Time
+}
+
+// TableName returns the table name for GORM
+func (ProcessedEventModel) TableName() string {
+	return "processed_events"
+}
+
+// PostgresUserReadModel implements UserReadModel using PostgreSQL
+type PostgresUserReadModel struct {
+	db *gorm.DB
+}
+
+// NewPostgresUserReadModel creates a new PostgreSQL user read-model
+func NewPostgresUserReadModel(db *gorm.DB) *PostgresUserReadModel {
+	return &PostgresUserReadModel{db: db}
+}
+
+// Migrate runs auto-migration for the user read-model
+func (r *PostgresUserReadModel) Migrate() error {
+	return r.db.AutoMigrate(&UserModel{}, &ProcessedEventModel{})
+}
+
+// Upsert stores or replaces a user unless the stored row reflects a later
+// event. The check is part of the upsert so concurrent writers can't race it.
+func (r *PostgresUserReadModel) Upsert(ctx context.Context, user *ports.UserInfo) (bool, error) {
+	eventTimestamp := user.UpdatedAt
+	model := &UserModel{
+		ID:             user.ID,
+		Name:           user.Name,
+		Email:          user.Email,
+		EventTimestamp: &eventTimestamp,
+		DeletedAt:      user.DeletedAt,
+	}
+	if user.Sequence != 0 {
+		eventSequence := int64(user.Sequence)
+		model.EventSequence = &eventSequence
+	}
+
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "email", "updated_at", "event_timestamp", "event_sequence", "deleted_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			// Same order as events.CompareOrder: sequences when both
+			// events have one, timestamps otherwise
+			clause.Expr{SQL: "users_read_model.event_timestamp IS NULL OR CASE" +
+				" WHEN users_read_model.event_sequence IS NOT NULL AND excluded.event_sequence IS NOT NULL" +
+				" THEN users_read_model.event_sequence <= excluded.event_sequence" +
+				" ELSE users_read_model.event_timestamp <= excluded.event_timestamp END"},
+		}},
+	}).Create(model)
+	if result.Error != nil {
+		return false, db.NewError("failed to upsert user", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Exists reports whether the user is known locally
+func (r *PostgresUserReadModel) Exists(ctx context.Context, id uint) (bool, error) {
+	var found []int
+	result := r.db.WithContext(ctx).Model(&UserModel{}).Select("1").Where("id = ?", id).Limit(1).Find(&found)
+	if result.Error != nil {
+		return false, db.NewError("failed to look up user", result.Error)
+	}
+	return len(found) > 0, nil
+}
+
+// IsDeleted reports whether the user is known locally and marked deleted
+func (r *PostgresUserReadModel) IsDeleted(ctx context.Context, id uint) (bool, error) {
+	var count int64
+	result := r.db.WithContext(ctx).Model(&UserModel{}).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count)
+	if result.Error != nil {
+		return false, db.NewError("failed to look up user", result.Error)
+	}
+	return count > 0, nil
+}
+
+// MarkProcessed records eventID in processed_events and reports false when it
+// was already there
+func (r *PostgresUserReadModel) MarkProcessed(ctx context.Context, eventID string) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ProcessedEventModel{
+		EventID:     eventID,
+		ProcessedAt: time.Now(),
+	})
+	if result.Error != nil {
+		return false, db.NewError("failed to record processed event", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Transaction runs fn with a read-model bound to a single database transaction
+func (r *PostgresUserReadModel) Transaction(ctx context.Context, fn func(readModel ports.UserReadModel) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&PostgresUserReadModel{db: tx})
+	})
+}
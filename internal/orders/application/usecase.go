@@ -2,11 +2,14 @@ package application
 
 import (
 	"context"
+	stderrors "errors"
+	"time"
 
 	"go-micro/internal/orders/domain"
 	"go-micro/internal/orders/ports"
 	"go-micro/pkg/errors"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/pagination"
 
 	"go.uber.org/zap"
 )
@@ -17,6 +20,20 @@ type OrderUseCase struct {
 	publisher  ports.EventPublisher
 	userClient ports.UserClient
 	log        *logger.Logger
+
+	// userReadModel is the local copy of users, when available
+	userReadModel ports.UserReadModel
+	// asyncUserValidation validates users against userReadModel only
+	asyncUserValidation bool
+
+	// reopenWindow is how long after cancellation an order can be reopened
+	reopenWindow time.Duration
+	// userDeletionPolicy is applied to the orders of deleted users
+	userDeletionPolicy UserDeletionPolicy
+	// maxBatchSize is the most orders a batch request may name
+	maxBatchSize int
+	// minorUnits is the number of decimal places order totals may have
+	minorUnits int
 }
 
 // NewOrderUseCase creates a new order use case
@@ -31,13 +48,62 @@ func NewOrderUseCase(
 		publisher:  publisher,
 		userClient: userClient,
 		log:        log,
+
+		reopenWindow:       domain.DefaultReopenWindow,
+		userDeletionPolicy: UserDeletionAnonymize,
+		maxBatchSize:       DefaultMaxBatchSize,
+		minorUnits:         domain.DefaultMinorUnits,
 	}
 }
 
+// SetReopenWindow sets how long after cancellation ReopenOrder accepts an order
+func (uc *OrderUseCase) SetReopenWindow(window time.Duration) {
+	uc.reopenWindow = window
+}
+
+// SetMaxBatchSize sets the most orders UpdateOrderStatuses and BatchGetOrders
+// accept at once
+func (uc *OrderUseCase) SetMaxBatchSize(max int) {
+	uc.maxBatchSize = max
+}
+
+// SetMinorUnits sets the number of decimal places CreateOrder accepts in
+// totals, e.g. 0 for currencies without cents
+func (uc *OrderUseCase) SetMinorUnits(minorUnits int) {
+	uc.minorUnits = minorUnits
+}
+
+// SetUserDeletionPolicy sets what HandleUserDeleted does to a deleted user's orders
+func (uc *OrderUseCase) SetUserDeletionPolicy(policy UserDeletionPolicy) {
+	uc.userDeletionPolicy = policy
+}
+
+// EnableAsyncUserValidation switches CreateOrder from a synchronous users
+// service call to optimistic validation against the local user read-model.
+//
+// Trade-offs: CreateOrder no longer depends on the users service's latency or
+// availability, but an order for a user the read-model doesn't know yet (new
+// user, event not consumed yet, or a user that doesn't exist) is accepted as
+// unverified. ReconcileUnverifiedOrders later verifies it or cancels it, so
+// invalid orders are visible as pending for up to one reconciliation interval.
+func (uc *OrderUseCase) EnableAsyncUserValidation(readModel ports.UserReadModel) {
+	uc.userReadModel = readModel
+	uc.asyncUserValidation = true
+}
+
+// SetUserReadModel gives CreateOrder the local user read-model, which it
+// checks for deleted users whichever way users are validated. The users
+// service may still answer for a user whose deletion is already known locally.
+func (uc *OrderUseCase) SetUserReadModel(readModel ports.UserReadModel) {
+	uc.userReadModel = readModel
+}
+
 // CreateOrderInput represents the input for creating an order
 type CreateOrderInput struct {
 	UserID uint
 	Total  float64
+	// IdempotencyKey optionally identifies the request across retries
+	IdempotencyKey string
 }
 
 // CreateOrderOutput represents the output of creating an order
@@ -45,28 +111,60 @@ type CreateOrderOutput struct {
 	Order *domain.Order
 }
 
-// CreateOrder creates a new order
+// CreateOrder creates a new order.
+// The user is validated before anything is persisted, so a failed or timed
+// out users call never leaves an orphaned order behind. With an idempotency
+// key, a request the user already made returns the order it created, which is
+// enforced by the database so concurrent retries can't both create one.
 func (uc *OrderUseCase) CreateOrder(ctx context.Context, input CreateOrderInput) (*CreateOrderOutput, error) {
-	// Validate user exists via gRPC
-	if uc.userClient != nil {
+	ctx = logger.WithField(ctx, "user_id", input.UserID)
+	userVerified := true
+
+	if len(input.IdempotencyKey) > domain.MaxIdempotencyKeyLength {
+		return nil, domain.ErrIdempotencyKeyTooLong
+	}
+
+	if uc.asyncUserValidation {
+		// Optimistic validation: unknown users are reconciled later
+		exists, err := uc.userReadModel.Exists(ctx, input.UserID)
+		if err != nil {
+			uc.log.WithContext(ctx).Warn("user read-model lookup failed, deferring validation",
+				zap.Error(err),
+			)
+		}
+		userVerified = exists
+	} else if uc.userClient != nil {
+		// Validate user exists via gRPC
 		_, err := uc.userClient.GetUser(ctx, input.UserID)
 		if err != nil {
 			if errors.Is(err, errors.CodeNotFound) {
 				return nil, domain.NewUserNotFoundError(input.UserID)
 			}
+			if errors.Is(err, errors.CodeTimeout) || stderrors.Is(err, context.DeadlineExceeded) {
+				return nil, domain.ErrUserServiceTimeout
+			}
 			return nil, errors.Wrap(err, "failed to validate user")
 		}
 	}
 
+	if err := uc.checkUserActive(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+
 	// Create domain entity with validation
-	order, err := domain.NewOrder(input.UserID, input.Total)
+	order, err := domain.NewOrder(input.UserID, input.Total, uc.minorUnits)
 	if err != nil {
 		return nil, err
 	}
+	order.UserVerified = userVerified
+	order.IdempotencyKey = input.IdempotencyKey
 
 	// Create order in repository
 	if err := uc.repo.Create(ctx, order); err != nil {
-		return nil, errors.NewInternal("failed to create order", err)
+		if stderrors.Is(err, domain.ErrIdempotencyKeyReused) {
+			return uc.existingOrder(ctx, order)
+		}
+		return nil, errors.Wrap(err, "failed to create order")
 	}
 
 	// Publish event (async, don't fail on error)
@@ -81,13 +179,35 @@ func (uc *OrderUseCase) CreateOrder(ctx context.Context, input CreateOrderInput)
 
 	uc.log.WithContext(ctx).Info("order created",
 		zap.Uint("order_id", order.ID),
-		zap.Uint("user_id", order.UserID),
 		zap.Float64("total", order.Total),
+		zap.Bool("user_verified", order.UserVerified),
 	)
 
 	return &CreateOrderOutput{Order: order}, nil
 }
 
+// existingOrder returns the order already created with order's idempotency
+// key, as long as it was created for the same request. It was announced when
+// it was created, so no event is published.
+func (uc *OrderUseCase) existingOrder(ctx context.Context, order *domain.Order) (*CreateOrderOutput, error) {
+	existing, err := uc.repo.GetByIdempotencyKey(ctx, order.UserID, order.IdempotencyKey)
+	if err != nil {
+		if errors.Is(err, errors.CodeNotFound) {
+			// The order has been deleted since
+			return nil, domain.ErrIdempotencyKeyReused
+		}
+		return nil, err
+	}
+	if existing.Total != order.Total {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+
+	uc.log.WithContext(ctx).Info("order already created with idempotency key",
+		zap.Uint("order_id", existing.ID),
+	)
+	return &CreateOrderOutput{Order: existing}, nil
+}
+
 // GetOrderInput represents the input for getting an order
 type GetOrderInput struct {
 	ID uint
@@ -107,3 +227,830 @@ func (uc *OrderUseCase) GetOrder(ctx context.Context, input GetOrderInput) (*Get
 
 	return &GetOrderOutput{Order: order}, nil
 }
+
+// GetOrderIncludingDeleted retrieves an order by ID even if it was
+// soft-deleted. It is meant for admin access only.
+func (uc *OrderUseCase) GetOrderIncludingDeleted(ctx context.Context, input GetOrderInput) (*GetOrderOutput, error) {
+	order, err := uc.repo.GetByIDIncludingDeleted(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetOrderOutput{Order: order}, nil
+}
+
+// BatchGetOrdersInput represents the input for getting several orders at once
+type BatchGetOrdersInput struct {
+	IDs []uint
+}
+
+// BatchGetOrdersOutput represents the output of getting several orders:
+// the orders found and the IDs that weren't, each in request order without
+// duplicates. Neither slice is nil.
+type BatchGetOrdersOutput struct {
+	Orders     []*domain.Order
+	MissingIDs []uint
+}
+
+// BatchGetOrders retrieves up to the batch size limit of orders in one
+// repository call. Missing orders don't fail the request; they are listed in
+// MissingIDs.
+func (uc *OrderUseCase) BatchGetOrders(ctx context.Context, input BatchGetOrdersInput) (*BatchGetOrdersOutput, error) {
+	if len(input.IDs) == 0 {
+		return nil, errors.NewValidation("ids is required", nil)
+	}
+	if len(input.IDs) > uc.maxBatchSize {
+		return nil, errors.NewValidation("too many orders", map[string]interface{}{
+			"max":   uc.maxBatchSize,
+			"count": len(input.IDs),
+		})
+	}
+
+	ids := make([]uint, 0, len(input.IDs))
+	seen := make(map[uint]bool, len(input.IDs))
+	for _, id := range input.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	orders, err := uc.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*domain.Order, len(orders))
+	for _, order := range orders {
+		byID[order.ID] = order
+	}
+
+	output := &BatchGetOrdersOutput{
+		Orders:     make([]*domain.Order, 0, len(orders)),
+		MissingIDs: []uint{},
+	}
+	for _, id := range ids {
+		if order, ok := byID[id]; ok {
+			output.Orders = append(output.Orders, order)
+		} else {
+			output.MissingIDs = append(output.MissingIDs, id)
+		}
+	}
+	return output, nil
+}
+
+// ListOrdersInput represents the input for listing orders
+type ListOrdersInput struct {
+	UserID   uint
+	Page     int
+	PageSize int
+}
+
+// ListOrdersOutput represents the output of listing orders.
+// Orders is never nil.
+type ListOrdersOutput struct {
+	Orders   []*domain.Order
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// ListOrders retrieves a page of orders, optionally filtered by user
+func (uc *OrderUseCase) ListOrders(ctx context.Context, input ListOrdersInput) (*ListOrdersOutput, error) {
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	input.PageSize = pagination.NormalizePageSize(input.PageSize)
+
+	orders, total, err := uc.repo.List(ctx, ports.OrderListFilter{
+		UserID:   input.UserID,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Guarantee an empty page serializes as [] rather than null
+	if orders == nil {
+		orders = []*domain.Order{}
+	}
+
+	return &ListOrdersOutput{
+		Orders:   orders,
+		Total:    total,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+	}, nil
+}
+
+// ListArchivedOrders retrieves a page of archived orders, optionally filtered
+// by user. Archived orders are old confirmed ones moved out of the orders
+// table; they are read-only.
+func (uc *OrderUseCase) ListArchivedOrders(ctx context.Context, input ListOrdersInput) (*ListOrdersOutput, error) {
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	input.PageSize = pagination.NormalizePageSize(input.PageSize)
+
+	orders, total, err := uc.repo.ListArchived(ctx, ports.OrderListFilter{
+		UserID:   input.UserID,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if orders == nil {
+		orders = []*domain.Order{}
+	}
+
+	return &ListOrdersOutput{
+		Orders:   orders,
+		Total:    total,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+	}, nil
+}
+
+// GetOrderStatsInput represents the input for summarizing a user's orders
+type GetOrderStatsInput struct {
+	UserID uint
+}
+
+// GetOrderStatsOutput represents a user's orders summarized by status.
+// Stats is never nil.
+type GetOrderStatsOutput struct {
+	Stats []ports.OrderStats
+}
+
+// GetOrderStats counts and sums a user's orders per status
+func (uc *OrderUseCase) GetOrderStats(ctx context.Context, input GetOrderStatsInput) (*GetOrderStatsOutput, error) {
+	if input.UserID == 0 {
+		return nil, domain.ErrUserIDRequired
+	}
+
+	stats, err := uc.repo.StatsByUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = []ports.OrderStats{}
+	}
+
+	return &GetOrderStatsOutput{Stats: stats}, nil
+}
+
+// StatsWindows are the rolling windows GetOrderWindowStats accepts, by name
+var StatsWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// DefaultStatsWindow is the window GetOrderWindowStats uses when none is given
+const DefaultStatsWindow = "24h"
+
+// GetOrderWindowStatsInput represents the input for summarizing recent orders
+type GetOrderWindowStatsInput struct {
+	// Window names one of StatsWindows; empty means DefaultStatsWindow
+	Window string
+	Now    time.Time
+}
+
+// GetOrderWindowStatsOutput summarizes the orders created since Since
+type GetOrderWindowStatsOutput struct {
+	Window string
+	Since  time.Time
+	Count  int64
+	// Revenue sums the totals of the orders that weren't cancelled
+	Revenue float64
+}
+
+// GetOrderWindowStats counts the orders created within a rolling window
+// ending now and sums their revenue
+func (uc *OrderUseCase) GetOrderWindowStats(ctx context.Context, input GetOrderWindowStatsInput) (*GetOrderWindowStatsOutput, error) {
+	window := input.Window
+	if window == "" {
+		window = DefaultStatsWindow
+	}
+	length, ok := StatsWindows[window]
+	if !ok {
+		return nil, errors.NewValidation("window must be 24h, 7d or 30d", map[string]interface{}{
+			"window": input.Window,
+		})
+	}
+
+	since := input.Now.Add(-length)
+	count, revenue, err := uc.repo.CountByWindow(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetOrderWindowStatsOutput{Window: window, Since: since, Count: count, Revenue: revenue}, nil
+}
+
+// GetOrdersByStatusInput represents the input for paging through orders in a status
+type GetOrdersByStatusInput struct {
+	Status domain.OrderStatus
+	Limit  int
+	Offset int
+}
+
+// GetOrdersByStatusOutput represents the output of paging through orders in
+// a status. Orders is never nil.
+type GetOrdersByStatusOutput struct {
+	Orders []*domain.Order
+}
+
+// GetOrdersByStatus retrieves one page of orders in a status, oldest first,
+// for background jobs that work through them. A limit <= 0 defaults to 100.
+func (uc *OrderUseCase) GetOrdersByStatus(ctx context.Context, input GetOrdersByStatusInput) (*GetOrdersByStatusOutput, error) {
+	if input.Limit <= 0 {
+		input.Limit = 100
+	}
+	if input.Offset < 0 {
+		input.Offset = 0
+	}
+
+	orders, err := uc.repo.GetByStatus(ctx, input.Status, input.Limit, input.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetOrdersByStatusOutput{Orders: orders}, nil
+}
+
+// ConfirmOrderInput represents the input for confirming an order
+type ConfirmOrderInput struct {
+	ID uint
+}
+
+// ConfirmOrderOutput represents the output of confirming an order
+type ConfirmOrderOutput struct {
+	Order *domain.Order
+}
+
+// ConfirmOrder moves a pending order to confirmed.
+// Confirming an already confirmed order is a no-op so redelivered events are safe.
+func (uc *OrderUseCase) ConfirmOrder(ctx context.Context, input ConfirmOrderInput) (*ConfirmOrderOutput, error) {
+	order, err := uc.repo.GetByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == domain.OrderStatusConfirmed {
+		return &ConfirmOrderOutput{Order: order}, nil
+	}
+
+	if err := order.Confirm(); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	uc.log.WithContext(ctx).Info("order confirmed",
+		zap.Uint("order_id", order.ID),
+	)
+
+	return &ConfirmOrderOutput{Order: order}, nil
+}
+
+// ReopenOrderInput represents the input for reopening a cancelled order
+type ReopenOrderInput struct {
+	ID uint
+}
+
+// ReopenOrderOutput represents the output of reopening a cancelled order
+type ReopenOrderOutput struct {
+	Order *domain.Order
+}
+
+// ReopenOrder moves an order cancelled within the reopen window back to
+// pending and publishes an OrderReopened event
+func (uc *OrderUseCase) ReopenOrder(ctx context.Context, input ReopenOrderInput) (*ReopenOrderOutput, error) {
+	order, err := uc.repo.GetByID(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := order.Reopen(time.Now(), uc.reopenWindow); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	if uc.publisher != nil {
+		if err := uc.publisher.PublishOrderReopened(ctx, order); err != nil {
+			uc.log.WithContext(ctx).Error("failed to publish order reopened event",
+				zap.Error(err),
+				zap.Uint("order_id", order.ID),
+			)
+		}
+	}
+
+	uc.log.WithContext(ctx).Info("order reopened",
+		zap.Uint("order_id", order.ID),
+	)
+
+	return &ReopenOrderOutput{Order: order}, nil
+}
+
+// DefaultMaxBatchSize is the most orders UpdateOrderStatuses and
+// BatchGetOrders accept at once unless SetMaxBatchSize says otherwise
+const DefaultMaxBatchSize = 100
+
+// defaultCancelReason is the cancellation reason when an operator gives none
+const defaultCancelReason = "admin"
+
+// UpdateOrderStatusesInput represents the input for changing the status of
+// several orders at once
+type UpdateOrderStatusesInput struct {
+	IDs    []uint
+	Status domain.OrderStatus
+	// Reason is published with cancellations; it defaults to "admin"
+	Reason string
+}
+
+// OrderStatusResult is the outcome of a status change for one order.
+// Err is nil on success.
+type OrderStatusResult struct {
+	ID      uint
+	Order   *domain.Order
+	Changed bool
+	Err     error
+}
+
+// UpdateOrderStatusesOutput represents the output of changing the status of
+// several orders, with one result per distinct ID in request order
+type UpdateOrderStatusesOutput struct {
+	Results   []OrderStatusResult
+	Succeeded int
+	Failed    int
+}
+
+// UpdateOrderStatuses moves each order to input.Status, enforcing the
+// transition rules per order. Orders that are missing or can't make the
+// transition are reported as failed without affecting the others; orders
+// already in the target status succeed unchanged. All changes are written in
+// one transaction, so a storage error fails the whole batch and changes
+// nothing. Events are published only after the transaction commits.
+func (uc *OrderUseCase) UpdateOrderStatuses(ctx context.Context, input UpdateOrderStatusesInput) (*UpdateOrderStatusesOutput, error) {
+	if input.Status != domain.OrderStatusConfirmed && input.Status != domain.OrderStatusCancelled {
+		return nil, errors.NewValidation("status must be confirmed or cancelled", map[string]interface{}{
+			"status": input.Status,
+		})
+	}
+	if len(input.IDs) == 0 {
+		return nil, errors.NewValidation("order_ids is required", nil)
+	}
+	if len(input.IDs) > uc.maxBatchSize {
+		return nil, errors.NewValidation("too many orders", map[string]interface{}{
+			"max":   uc.maxBatchSize,
+			"count": len(input.IDs),
+		})
+	}
+	if input.Reason == "" {
+		input.Reason = defaultCancelReason
+	}
+
+	var results []OrderStatusResult
+	err := uc.repo.Transaction(ctx, func(repo ports.OrderRepository) error {
+		results = make([]OrderStatusResult, 0, len(input.IDs))
+		seen := make(map[uint]bool, len(input.IDs))
+
+		for _, id := range input.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			result := OrderStatusResult{ID: id}
+			order, err := repo.GetByID(ctx, id)
+			if err != nil {
+				if !errors.Is(err, errors.CodeNotFound) {
+					return err
+				}
+				result.Err = err
+				results = append(results, result)
+				continue
+			}
+			result.Order = order
+
+			if order.Status != input.Status {
+				if err := order.TransitionTo(input.Status); err != nil {
+					result.Err = err
+					results = append(results, result)
+					continue
+				}
+				if err := repo.Update(ctx, order); err != nil {
+					return err
+				}
+				result.Changed = true
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update order statuses")
+	}
+
+	output := &UpdateOrderStatusesOutput{Results: results}
+	for _, result := range results {
+		if result.Err != nil {
+			output.Failed++
+			continue
+		}
+		output.Succeeded++
+		if result.Changed {
+			uc.publishStatusChange(ctx, result.Order, input.Reason)
+		}
+	}
+
+	uc.log.WithContext(ctx).Info("updated order statuses",
+		zap.String("status", string(input.Status)),
+		zap.Int("succeeded", output.Succeeded),
+		zap.Int("failed", output.Failed),
+	)
+
+	return output, nil
+}
+
+// publishStatusChange publishes the event for an order's new status
+func (uc *OrderUseCase) publishStatusChange(ctx context.Context, order *domain.Order, reason string) {
+	if uc.publisher == nil {
+		return
+	}
+
+	var err error
+	switch order.Status {
+	case domain.OrderStatusConfirmed:
+		err = uc.publisher.PublishOrderConfirmed(ctx, order)
+	case domain.OrderStatusCancelled:
+		err = uc.publisher.PublishOrderCancelled(ctx, order, reason)
+	}
+	if err != nil {
+		uc.log.WithContext(ctx).Error("failed to publish order status event",
+			zap.Error(err),
+			zap.Uint("order_id", order.ID),
+			zap.String("status", string(order.Status)),
+		)
+	}
+}
+
+// CancelStalePendingOrdersInput represents the input for cancelling stale pending orders
+type CancelStalePendingOrdersInput struct {
+	Now       time.Time
+	Threshold time.Duration
+	BatchSize int
+}
+
+// CancelStalePendingOrdersOutput represents the output of cancelling stale pending orders
+type CancelStalePendingOrdersOutput struct {
+	Cancelled int
+}
+
+// CancelStalePendingOrders cancels orders that have stayed pending longer than the threshold.
+// Orders are processed in batches until no stale orders remain.
+func (uc *OrderUseCase) CancelStalePendingOrders(ctx context.Context, input CancelStalePendingOrdersInput) (*CancelStalePendingOrdersOutput, error) {
+	if input.BatchSize <= 0 {
+		input.BatchSize = 100
+	}
+
+	cutoff := input.Now.Add(-input.Threshold)
+	cancelled := 0
+
+	for {
+		orders, err := uc.repo.GetStalePending(ctx, cutoff, input.BatchSize)
+		if err != nil {
+			return &CancelStalePendingOrdersOutput{Cancelled: cancelled}, err
+		}
+
+		for _, order := range orders {
+			if !order.IsStalePending(input.Now, input.Threshold) {
+				continue
+			}
+
+			order.Cancel()
+			if err := uc.repo.Update(ctx, order); err != nil {
+				return &CancelStalePendingOrdersOutput{Cancelled: cancelled}, err
+			}
+			cancelled++
+
+			if uc.publisher != nil {
+				if err := uc.publisher.PublishOrderCancelled(ctx, order, "stale_pending"); err != nil {
+					uc.log.WithContext(ctx).Error("failed to publish order cancelled event",
+						zap.Error(err),
+						zap.Uint("order_id", order.ID),
+					)
+				}
+			}
+		}
+
+		if len(orders) < input.BatchSize {
+			break
+		}
+	}
+
+	if cancelled > 0 {
+		uc.log.WithContext(ctx).Info("cancelled stale pending orders",
+			zap.Int("count", cancelled),
+			zap.Duration("threshold", input.Threshold),
+		)
+	}
+
+	return &CancelStalePendingOrdersOutput{Cancelled: cancelled}, nil
+}
+
+// ArchiveConfirmedOrdersInput represents the input for archiving old confirmed orders
+type ArchiveConfirmedOrdersInput struct {
+	Now       time.Time
+	Retention time.Duration
+	BatchSize int
+}
+
+// ArchiveConfirmedOrdersOutput represents the output of archiving old confirmed orders
+type ArchiveConfirmedOrdersOutput struct {
+	Archived int
+}
+
+// ArchiveConfirmedOrders moves confirmed orders created more than the
+// retention period ago to the archive, one batch per transaction, until none
+// remain or ctx is cancelled. Batches already moved stay archived when a
+// later one fails.
+func (uc *OrderUseCase) ArchiveConfirmedOrders(ctx context.Context, input ArchiveConfirmedOrdersInput) (*ArchiveConfirmedOrdersOutput, error) {
+	if input.BatchSize <= 0 {
+		input.BatchSize = 100
+	}
+
+	cutoff := input.Now.Add(-input.Retention)
+	archived := 0
+
+	for ctx.Err() == nil {
+		n, err := uc.repo.ArchiveConfirmed(ctx, cutoff, input.BatchSize)
+		archived += n
+		if err != nil {
+			return &ArchiveConfirmedOrdersOutput{Archived: archived}, err
+		}
+		if n < input.BatchSize {
+			break
+		}
+	}
+
+	if archived > 0 {
+		uc.log.WithContext(ctx).Info("archived confirmed orders",
+			zap.Int("count", archived),
+			zap.Duration("retention", input.Retention),
+		)
+	}
+
+	return &ArchiveConfirmedOrdersOutput{Archived: archived}, nil
+}
+
+// ReconcileUnverifiedOrdersInput represents the input for reconciling unverified orders
+type ReconcileUnverifiedOrdersInput struct {
+	BatchSize int
+}
+
+// ReconcileUnverifiedOrdersOutput represents the output of reconciling unverified orders
+type ReconcileUnverifiedOrdersOutput struct {
+	Verified  int
+	Cancelled int
+}
+
+// ReconcileUnverifiedOrders checks one batch of orders created by async user
+// validation. Orders whose user is now in the read-model, or is confirmed by
+// the users service, are verified; orders whose user the users service
+// reports as not found are cancelled. Orders that can't be decided yet (users
+// service unavailable) are left for the next run, which is why only one batch
+// is processed per call.
+func (uc *OrderUseCase) ReconcileUnverifiedOrders(ctx context.Context, input ReconcileUnverifiedOrdersInput) (*ReconcileUnverifiedOrdersOutput, error) {
+	if input.BatchSize <= 0 {
+		input.BatchSize = 100
+	}
+
+	orders, err := uc.repo.GetUnverified(ctx, input.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &ReconcileUnverifiedOrdersOutput{}
+	for _, order := range orders {
+		exists, err := uc.userExists(ctx, order.UserID)
+		if err != nil {
+			uc.log.WithContext(ctx).Warn("could not verify order user, retrying later",
+				zap.Error(err),
+				zap.Uint("order_id", order.ID),
+				zap.Uint("user_id", order.UserID),
+			)
+			continue
+		}
+
+		// The order may have changed since it was read, e.g. confirmed by
+		// its payment: only still pending orders are updated
+		if exists {
+			order.MarkUserVerified()
+			updated, err := uc.repo.UpdateIfPending(ctx, order)
+			if err != nil {
+				return output, err
+			}
+			if !updated {
+				uc.logOrderNoLongerPending(ctx, order)
+				continue
+			}
+			output.Verified++
+			continue
+		}
+
+		if err := order.TransitionTo(domain.OrderStatusCancelled); err != nil {
+			uc.logOrderNoLongerPending(ctx, order)
+			continue
+		}
+		updated, err := uc.repo.UpdateIfPending(ctx, order)
+		if err != nil {
+			return output, err
+		}
+		if !updated {
+			uc.logOrderNoLongerPending(ctx, order)
+			continue
+		}
+		output.Cancelled++
+
+		if uc.publisher != nil {
+			if err := uc.publisher.PublishOrderCancelled(ctx, order, "user_not_found"); err != nil {
+				uc.log.WithContext(ctx).Error("failed to publish order cancelled event",
+					zap.Error(err),
+					zap.Uint("order_id", order.ID),
+				)
+			}
+		}
+	}
+
+	if output.Verified > 0 || output.Cancelled > 0 {
+		uc.log.WithContext(ctx).Info("reconciled unverified orders",
+			zap.Int("verified", output.Verified),
+			zap.Int("cancelled", output.Cancelled),
+		)
+	}
+
+	return output, nil
+}
+
+// logOrderNoLongerPending notes an unverified order left alone by
+// reconciliation because it stopped being pending
+func (uc *OrderUseCase) logOrderNoLongerPending(ctx context.Context, order *domain.Order) {
+	uc.log.WithContext(ctx).Info("order no longer pending, skipping reconciliation",
+		zap.Uint("order_id", order.ID),
+		zap.Uint("user_id", order.UserID),
+	)
+}
+
+// checkUserActive rejects users the read-model knows to be deleted. A failed
+// lookup is logged and does not block the order, like async validation.
+func (uc *OrderUseCase) checkUserActive(ctx context.Context, userID uint) error {
+	if uc.userReadModel == nil {
+		return nil
+	}
+
+	deleted, err := uc.userReadModel.IsDeleted(ctx, userID)
+	if err != nil {
+		uc.log.WithContext(ctx).Warn("user read-model lookup failed, skipping deleted user check",
+			zap.Error(err),
+		)
+		return nil
+	}
+	if deleted {
+		return domain.NewUserInactiveError(userID)
+	}
+	return nil
+}
+
+// userExists checks the read-model first and falls back to the users service,
+// which is authoritative. A missing read-model entry alone is not enough to
+// reject a user because the read-model may lag.
+func (uc *OrderUseCase) userExists(ctx context.Context, userID uint) (bool, error) {
+	if uc.userReadModel != nil {
+		exists, err := uc.userReadModel.Exists(ctx, userID)
+		if err == nil && exists {
+			return true, nil
+		}
+	}
+
+	if uc.userClient == nil {
+		return false, errors.NewUnavailable("users service is not configured")
+	}
+
+	if _, err := uc.userClient.GetUser(ctx, userID); err != nil {
+		if errors.Is(err, errors.CodeNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// UserDeletionPolicy decides what happens to the orders of a deleted user
+type UserDeletionPolicy string
+
+const (
+	// UserDeletionAnonymize keeps the orders, e.g. for accounting, but
+	// detaches them from the user
+	UserDeletionAnonymize UserDeletionPolicy = "anonymize"
+	// UserDeletionDelete anonymizes and then soft-deletes the orders
+	UserDeletionDelete UserDeletionPolicy = "delete"
+)
+
+// ParseUserDeletionPolicy validates a policy name from configuration
+func ParseUserDeletionPolicy(name string) (UserDeletionPolicy, error) {
+	switch policy := UserDeletionPolicy(name); policy {
+	case UserDeletionAnonymize, UserDeletionDelete:
+		return policy, nil
+	default:
+		return "", errors.NewValidation("user deletion policy must be anonymize or delete", map[string]interface{}{
+			"policy": name,
+		})
+	}
+}
+
+// HandleUserDeletedInput represents a user deleted in the users service
+type HandleUserDeletedInput struct {
+	UserID    uint
+	DeletedAt time.Time
+	// Sequence orders the deletion against other events for the user
+	Sequence uint64
+}
+
+// HandleUserDeletedOutput represents the output of handling a user deletion
+type HandleUserDeletedOutput struct {
+	// Orders is how many orders the deletion policy was applied to
+	Orders int
+	// ArchivedOrders is how many archived orders it was applied to
+	ArchivedOrders int
+}
+
+// HandleUserDeleted erases a deleted user from the orders service: the user's
+// name and email are dropped from the read-model, which keeps only the
+// deletion, and the user deletion policy is applied to all their orders,
+// archived ones included, in one transaction. Handling the same deletion again finds no orders left, so
+// redeliveries are harmless. Orders soft-deleted before the user was are not
+// visible to the repository and keep their user ID.
+func (uc *OrderUseCase) HandleUserDeleted(ctx context.Context, input HandleUserDeletedInput) (*HandleUserDeletedOutput, error) {
+	ctx = logger.WithField(ctx, "user_id", input.UserID)
+
+	if uc.userReadModel != nil {
+		deletedAt := input.DeletedAt
+		if _, err := uc.userReadModel.Upsert(ctx, &ports.UserInfo{
+			ID:        input.UserID,
+			UpdatedAt: input.DeletedAt,
+			Sequence:  input.Sequence,
+			DeletedAt: &deletedAt,
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed to mark user deleted")
+		}
+	}
+
+	now := time.Now()
+	output := &HandleUserDeletedOutput{}
+	err := uc.repo.Transaction(ctx, func(repo ports.OrderRepository) error {
+		orders, err := repo.GetByUserID(ctx, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			order.Anonymize(now)
+			if err := repo.Update(ctx, order); err != nil {
+				return err
+			}
+			if uc.userDeletionPolicy == UserDeletionDelete {
+				if err := repo.Delete(ctx, order.ID); err != nil {
+					return err
+				}
+			}
+		}
+		output.Orders = len(orders)
+
+		archived, err := repo.AnonymizeArchived(ctx, input.UserID, now, uc.userDeletionPolicy == UserDeletionDelete)
+		if err != nil {
+			return err
+		}
+		output.ArchivedOrders = archived
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to apply user deletion to orders")
+	}
+
+	uc.log.WithContext(ctx).Info("applied user deletion to orders",
+		zap.String("policy", string(uc.userDeletionPolicy)),
+		zap.Int("orders", output.Orders),
+		zap.Int("archived_orders", output.ArchivedOrders),
+	)
+
+	return output, nil
+}
@@ -2,7 +2,15 @@ package application
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"go-micro/internal/orders/domain"
 	"go-micro/internal/orders/ports"
@@ -12,8 +20,15 @@ import (
 
 // MockOrderRepository is a mock implementation of OrderRepository
 type MockOrderRepository struct {
+	// mu guards creation, which may be concurrent
+	mu     sync.Mutex
 	orders map[uint]*domain.Order
 	nextID uint
+
+	getByIDsCalls int
+
+	archived     map[uint]*domain.Order
+	archiveCalls int
 }
 
 func NewMockOrderRepository() *MockOrderRepository {
@@ -24,6 +39,14 @@ func NewMockOrderRepository() *MockOrderRepository {
 }
 
 func (m *MockOrderRepository) Create(ctx context.Context, order *domain.Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Like the unique index on (user_id, idempotency_key)
+	for _, existing := range m.orders {
+		if order.IdempotencyKey != "" && existing.UserID == order.UserID && existing.IdempotencyKey == order.IdempotencyKey {
+			return domain.ErrIdempotencyKeyReused
+		}
+	}
 	order.ID = m.nextID
 	m.nextID++
 	m.orders[order.ID] = order
@@ -31,6 +54,14 @@ func (m *MockOrderRepository) Create(ctx context.Context, order *domain.Order) e
 }
 
 func (m *MockOrderRepository) GetByID(ctx context.Context, id uint) (*domain.Order, error) {
+	order, ok := m.orders[id]
+	if !ok || order.DeletedAt != nil {
+		return nil, domain.NewOrderNotFound(id)
+	}
+	return order, nil
+}
+
+func (m *MockOrderRepository) GetByIDIncludingDeleted(ctx context.Context, id uint) (*domain.Order, error) {
 	order, ok := m.orders[id]
 	if !ok {
 		return nil, domain.NewOrderNotFound(id)
@@ -43,13 +74,28 @@ func (m *MockOrderRepository) Update(ctx context.Context, order *domain.Order) e
 	return nil
 }
 
+func (m *MockOrderRepository) UpdateIfPending(ctx context.Context, order *domain.Order) (bool, error) {
+	stored, ok := m.orders[order.ID]
+	if !ok || stored.Status != domain.OrderStatusPending {
+		return false, nil
+	}
+	stored.Status = order.Status
+	stored.UserVerified = order.UserVerified
+	return true, nil
+}
+
 func (m *MockOrderRepository) Delete(ctx context.Context, id uint) error {
-	delete(m.orders, id)
+	order, ok := m.orders[id]
+	if !ok || order.DeletedAt != nil {
+		return domain.NewOrderNotFound(id)
+	}
+	now := time.Now()
+	order.DeletedAt = &now
 	return nil
 }
 
 func (m *MockOrderRepository) GetByUserID(ctx context.Context, userID uint) ([]*domain.Order, error) {
-	var result []*domain.Order
+	result := []*domain.Order{}
 	for _, order := range m.orders {
 		if order.UserID == userID {
 			result = append(result, order)
@@ -58,9 +104,162 @@ func (m *MockOrderRepository) GetByUserID(ctx context.Context, userID uint) ([]*
 	return result, nil
 }
 
+func (m *MockOrderRepository) GetStalePending(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Order, error) {
+	var result []*domain.Order
+	for _, order := range m.orders {
+		if order.Status == domain.OrderStatusPending && order.CreatedAt.Before(cutoff) && len(result) < limit {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) ArchiveConfirmed(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	m.archiveCalls++
+	if m.archived == nil {
+		m.archived = make(map[uint]*domain.Order)
+	}
+	moved := 0
+	for id, order := range m.orders {
+		if order.Status == domain.OrderStatusConfirmed && order.CreatedAt.Before(cutoff) && moved < limit {
+			m.archived[id] = order
+			delete(m.orders, id)
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// GetUnverified returns copies, like rows read from the database
+func (m *MockOrderRepository) GetUnverified(ctx context.Context, limit int) ([]*domain.Order, error) {
+	var result []*domain.Order
+	for _, order := range m.orders {
+		if order.Status == domain.OrderStatusPending && !order.UserVerified && len(result) < limit {
+			read := *order
+			result = append(result, &read)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) GetByStatus(ctx context.Context, status domain.OrderStatus, limit, offset int) ([]*domain.Order, error) {
+	result := []*domain.Order{}
+	for id := uint(1); id < m.nextID; id++ {
+		order, ok := m.orders[id]
+		if !ok || order.DeletedAt != nil || order.Status != status {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(result) < limit {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) GetByIDs(ctx context.Context, ids []uint) ([]*domain.Order, error) {
+	m.getByIDsCalls++
+	result := []*domain.Order{}
+	for _, id := range ids {
+		if order, ok := m.orders[id]; ok && order.DeletedAt == nil {
+			result = append(result, order)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*domain.Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, order := range m.orders {
+		if order.UserID == userID && order.IdempotencyKey == key && order.DeletedAt == nil {
+			return order, nil
+		}
+	}
+	return nil, errors.NewNotFound("order", key)
+}
+
+func (m *MockOrderRepository) StatsByUser(ctx context.Context, userID uint) ([]ports.OrderStats, error) {
+	var result []ports.OrderStats
+	for _, status := range []domain.OrderStatus{domain.OrderStatusCancelled, domain.OrderStatusConfirmed, domain.OrderStatusPending} {
+		stats := ports.OrderStats{Status: status}
+		for _, order := range m.orders {
+			if order.UserID == userID && order.DeletedAt == nil && order.Status == status {
+				stats.Count++
+				stats.Total += order.Total
+			}
+		}
+		if stats.Count > 0 {
+			result = append(result, stats)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockOrderRepository) CountByWindow(ctx context.Context, since time.Time) (int64, float64, error) {
+	var count int64
+	var revenue float64
+	for _, order := range m.orders {
+		if order.DeletedAt != nil || order.CreatedAt.Before(since) {
+			continue
+		}
+		count++
+		if order.Status != domain.OrderStatusCancelled {
+			revenue += order.Total
+		}
+	}
+	return count, revenue, nil
+}
+
+func (m *MockOrderRepository) List(ctx context.Context, filter ports.OrderListFilter) ([]*domain.Order, int64, error) {
+	var result []*domain.Order
+	for _, order := range m.orders {
+		if filter.UserID == 0 || order.UserID == filter.UserID {
+			result = append(result, order)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockOrderRepository) ListArchived(ctx context.Context, filter ports.OrderListFilter) ([]*domain.Order, int64, error) {
+	var result []*domain.Order
+	for _, order := range m.archived {
+		if order.DeletedAt == nil && (filter.UserID == 0 || order.UserID == filter.UserID) {
+			result = append(result, order)
+		}
+	}
+	return result, int64(len(result)), nil
+}
+
+func (m *MockOrderRepository) AnonymizeArchived(ctx context.Context, userID uint, now time.Time, softDelete bool) (int, error) {
+	changed := 0
+	for _, order := range m.archived {
+		if order.UserID != userID {
+			continue
+		}
+		order.Anonymize(now)
+		if softDelete {
+			deletedAt := now
+			order.DeletedAt = &deletedAt
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+func (m *MockOrderRepository) Transaction(ctx context.Context, fn func(repo ports.OrderRepository) error) error {
+	return fn(m)
+}
+
 // MockEventPublisher is a mock implementation of EventPublisher
 type MockEventPublisher struct {
-	events []interface{}
+	events    []interface{}
+	confirmed []uint
+	cancelled []string
+	reopened  []uint
 }
 
 func (m *MockEventPublisher) PublishOrderCreated(ctx context.Context, order *domain.Order) error {
@@ -68,9 +267,56 @@ func (m *MockEventPublisher) PublishOrderCreated(ctx context.Context, order *dom
 	return nil
 }
 
+func (m *MockEventPublisher) PublishOrderConfirmed(ctx context.Context, order *domain.Order) error {
+	m.events = append(m.events, order)
+	m.confirmed = append(m.confirmed, order.ID)
+	return nil
+}
+
+func (m *MockEventPublisher) PublishOrderCancelled(ctx context.Context, order *domain.Order, reason string) error {
+	m.events = append(m.events, order)
+	m.cancelled = append(m.cancelled, reason)
+	return nil
+}
+
+func (m *MockEventPublisher) PublishOrderReopened(ctx context.Context, order *domain.Order) error {
+	m.events = append(m.events, order)
+	m.reopened = append(m.reopened, order.ID)
+	return nil
+}
+
+// MockUserReadModel is a mock implementation of UserReadModel
+type MockUserReadModel struct {
+	users map[uint]*ports.UserInfo
+}
+
+func (m *MockUserReadModel) Upsert(ctx context.Context, user *ports.UserInfo) (bool, error) {
+	m.users[user.ID] = user
+	return true, nil
+}
+
+func (m *MockUserReadModel) Exists(ctx context.Context, id uint) (bool, error) {
+	_, ok := m.users[id]
+	return ok, nil
+}
+
+func (m *MockUserReadModel) IsDeleted(ctx context.Context, id uint) (bool, error) {
+	user, ok := m.users[id]
+	return ok && user.DeletedAt != nil, nil
+}
+
+func (m *MockUserReadModel) MarkProcessed(ctx context.Context, eventID string) (bool, error) {
+	return true, nil
+}
+
+func (m *MockUserReadModel) Transaction(ctx context.Context, fn func(readModel ports.UserReadModel) error) error {
+	return fn(m)
+}
+
 // MockUserClient is a mock implementation of UserClient
 type MockUserClient struct {
 	users map[uint]*ports.UserInfo
+	err   error
 }
 
 func NewMockUserClient() *MockUserClient {
@@ -82,6 +328,9 @@ func NewMockUserClient() *MockUserClient {
 }
 
 func (m *MockUserClient) GetUser(ctx context.Context, userID uint) (*ports.UserInfo, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
 	user, ok := m.users[userID]
 	if !ok {
 		return nil, errors.NewNotFound("user", userID)
@@ -131,6 +380,109 @@ func TestCreateOrder_Success(t *testing.T) {
 	}
 }
 
+// syncPublisher counts created events from concurrent requests
+type syncPublisher struct {
+	MockEventPublisher
+	mu      sync.Mutex
+	created int
+}
+
+func (p *syncPublisher) PublishOrderCreated(ctx context.Context, order *domain.Order) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.created++
+	return nil
+}
+
+func TestCreateOrder_IdempotencyKey(t *testing.T) {
+	// Arrange: the user already created an order with the key
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	useCase := NewOrderUseCase(repo, publisher, NewMockUserClient(), logger.New("test", "error"))
+	first, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10, IdempotencyKey: "abc"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("same request returns the existing order", func(t *testing.T) {
+		// Act
+		output, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10, IdempotencyKey: "abc"})
+
+		// Assert
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if output.Order.ID != first.Order.ID {
+			t.Errorf("expected order %d, got %d", first.Order.ID, output.Order.ID)
+		}
+		if len(repo.orders) != 1 || len(publisher.events) != 1 {
+			t.Errorf("expected 1 order and 1 event, got %d and %d", len(repo.orders), len(publisher.events))
+		}
+	})
+
+	t.Run("different request conflicts", func(t *testing.T) {
+		// Act
+		_, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 20, IdempotencyKey: "abc"})
+
+		// Assert
+		if !errors.Is(err, errors.CodeConflict) {
+			t.Errorf("expected conflict, got %v", err)
+		}
+	})
+
+	t.Run("key too long", func(t *testing.T) {
+		// Act
+		_, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10, IdempotencyKey: string(make([]byte, domain.MaxIdempotencyKeyLength+1))})
+
+		// Assert
+		if !errors.Is(err, errors.CodeValidation) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+}
+
+func TestCreateOrder_IdempotencyKey_Concurrent(t *testing.T) {
+	// Arrange
+	const requests = 20
+	repo := NewMockOrderRepository()
+	publisher := &syncPublisher{}
+	useCase := NewOrderUseCase(repo, publisher, NewMockUserClient(), logger.New("test", "error"))
+
+	// Act: identical requests race to create the order
+	ids := make([]uint, requests)
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			output, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10, IdempotencyKey: "abc"})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i] = output.Order.ID
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert: one order was created and every request got it
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("request %d: expected no error, got %v", i, err)
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("request %d: expected order %d, got %d", i, ids[0], ids[i])
+		}
+	}
+	if len(repo.orders) != 1 {
+		t.Errorf("expected 1 order, got %d", len(repo.orders))
+	}
+	if publisher.created != 1 {
+		t.Errorf("expected 1 created event, got %d", publisher.created)
+	}
+}
+
 func TestCreateOrder_InvalidTotal(t *testing.T) {
 	// Arrange
 	repo := NewMockOrderRepository()
@@ -157,6 +509,24 @@ func TestCreateOrder_InvalidTotal(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_MinorUnits(t *testing.T) {
+	// Arrange: a currency without cents
+	useCase := NewOrderUseCase(NewMockOrderRepository(), &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+	useCase.SetMinorUnits(0)
+
+	// Act
+	_, fractionalErr := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 99.99})
+	_, wholeErr := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 100})
+
+	// Assert
+	if !errors.Is(fractionalErr, errors.CodeValidation) {
+		t.Errorf("expected validation error for a fractional total, got %v", fractionalErr)
+	}
+	if wholeErr != nil {
+		t.Errorf("expected a whole total to be accepted, got %v", wholeErr)
+	}
+}
+
 func TestCreateOrder_UserNotFound(t *testing.T) {
 	// Arrange
 	repo := NewMockOrderRepository()
@@ -183,6 +553,41 @@ func TestCreateOrder_UserNotFound(t *testing.T) {
 	}
 }
 
+func TestCreateOrder_UserServiceTimeout(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	userClient.err = errors.FromGRPCStatus(status.Error(codes.DeadlineExceeded, "context deadline exceeded"))
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	input := CreateOrderInput{
+		UserID: 1,
+		Total:  99.99,
+	}
+
+	// Act
+	output, err := useCase.CreateOrder(context.Background(), input)
+
+	// Assert
+	if output != nil {
+		t.Error("expected nil output")
+	}
+
+	if !errors.Is(err, errors.CodeUnavailable) {
+		t.Errorf("expected unavailable error, got %v", err)
+	}
+
+	// User validation runs before persistence, so nothing was created
+	if len(repo.orders) != 0 {
+		t.Errorf("expected no orders to be created, got %d", len(repo.orders))
+	}
+	if len(publisher.events) != 0 {
+		t.Errorf("expected no events to be published, got %d", len(publisher.events))
+	}
+}
+
 func TestGetOrder_Success(t *testing.T) {
 	// Arrange
 	repo := NewMockOrderRepository()
@@ -233,3 +638,839 @@ func TestGetOrder_NotFound(t *testing.T) {
 		t.Errorf("expected not found error, got %v", err)
 	}
 }
+
+func TestGetOrder_SoftDeleted(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	createOutput, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 99.99})
+	if err := repo.Delete(context.Background(), createOutput.Order.ID); err != nil {
+		t.Fatalf("failed to delete order: %v", err)
+	}
+
+	// Act
+	_, err := useCase.GetOrder(context.Background(), GetOrderInput{ID: createOutput.Order.ID})
+
+	// Assert
+	if !errors.Is(err, errors.CodeNotFound) {
+		t.Errorf("expected not found error for deleted order, got %v", err)
+	}
+}
+
+func TestGetOrderIncludingDeleted(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	active, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	deleted, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 20})
+	if err := repo.Delete(context.Background(), deleted.Order.ID); err != nil {
+		t.Fatalf("failed to delete order: %v", err)
+	}
+
+	// Act
+	activeOutput, activeErr := useCase.GetOrderIncludingDeleted(context.Background(), GetOrderInput{ID: active.Order.ID})
+	deletedOutput, deletedErr := useCase.GetOrderIncludingDeleted(context.Background(), GetOrderInput{ID: deleted.Order.ID})
+	_, missingErr := useCase.GetOrderIncludingDeleted(context.Background(), GetOrderInput{ID: 999})
+
+	// Assert
+	if activeErr != nil {
+		t.Fatalf("expected no error for active order, got %v", activeErr)
+	}
+	if activeOutput.Order.DeletedAt != nil {
+		t.Error("expected active order to have no deletion time")
+	}
+	if deletedErr != nil {
+		t.Fatalf("expected no error for deleted order, got %v", deletedErr)
+	}
+	if deletedOutput.Order.DeletedAt == nil {
+		t.Error("expected deleted order to have a deletion time")
+	}
+	if !errors.Is(missingErr, errors.CodeNotFound) {
+		t.Errorf("expected not found error for missing order, got %v", missingErr)
+	}
+}
+
+func TestConfirmOrder_Success(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	createOutput, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{
+		UserID: 1,
+		Total:  99.99,
+	})
+
+	// Act
+	output, err := useCase.ConfirmOrder(context.Background(), ConfirmOrderInput{ID: createOutput.Order.ID})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if output.Order.Status != domain.OrderStatusConfirmed {
+		t.Errorf("expected status confirmed, got %s", output.Order.Status)
+	}
+
+	// Confirming again is a no-op
+	if _, err := useCase.ConfirmOrder(context.Background(), ConfirmOrderInput{ID: createOutput.Order.ID}); err != nil {
+		t.Errorf("expected repeated confirm to succeed, got %v", err)
+	}
+}
+
+func TestConfirmOrder_NotPending(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	createOutput, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{
+		UserID: 1,
+		Total:  99.99,
+	})
+	createOutput.Order.Cancel()
+
+	// Act
+	_, err := useCase.ConfirmOrder(context.Background(), ConfirmOrderInput{ID: createOutput.Order.ID})
+
+	// Assert
+	if !errors.Is(err, errors.CodeConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestConfirmOrder_NotFound(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	// Act
+	_, err := useCase.ConfirmOrder(context.Background(), ConfirmOrderInput{ID: 999})
+
+	// Assert
+	if !errors.Is(err, errors.CodeNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestReopenOrder(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	cancelled, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	cancelled.Order.Cancel()
+	expired, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 20})
+	expired.Order.Cancel()
+	longAgo := time.Now().Add(-domain.DefaultReopenWindow - time.Minute)
+	expired.Order.CancelledAt = &longAgo
+	confirmed, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 30})
+	confirmed.Order.Confirm()
+	anonymized, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 40})
+	anonymized.Order.Cancel()
+	anonymized.Order.Anonymize(time.Now())
+
+	// Act
+	output, err := useCase.ReopenOrder(context.Background(), ReopenOrderInput{ID: cancelled.Order.ID})
+	_, expiredErr := useCase.ReopenOrder(context.Background(), ReopenOrderInput{ID: expired.Order.ID})
+	_, confirmedErr := useCase.ReopenOrder(context.Background(), ReopenOrderInput{ID: confirmed.Order.ID})
+	_, anonymizedErr := useCase.ReopenOrder(context.Background(), ReopenOrderInput{ID: anonymized.Order.ID})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.Order.Status != domain.OrderStatusPending {
+		t.Errorf("expected status pending, got %s", output.Order.Status)
+	}
+	if len(publisher.reopened) != 1 || publisher.reopened[0] != cancelled.Order.ID {
+		t.Errorf("expected one reopened event for order %d, got %v", cancelled.Order.ID, publisher.reopened)
+	}
+	if !errors.Is(expiredErr, errors.CodeConflict) {
+		t.Errorf("expected conflict error past the window, got %v", expiredErr)
+	}
+	if !errors.Is(confirmedErr, errors.CodeConflict) {
+		t.Errorf("expected conflict error for a confirmed order, got %v", confirmedErr)
+	}
+	if anonymizedErr != domain.ErrOrderAnonymized {
+		t.Errorf("expected an anonymized order not to be reopened, got %v", anonymizedErr)
+	}
+}
+
+func TestArchiveConfirmedOrders(t *testing.T) {
+	// Arrange: 5 old confirmed orders, plus a recent confirmed one and an
+	// old pending one that must stay
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	repo := NewMockOrderRepository()
+	for _, order := range []*domain.Order{
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: old},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: old},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: old},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: old},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: old},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed, CreatedAt: now},
+		{UserID: 1, Total: 10, Status: domain.OrderStatusPending, CreatedAt: old},
+	} {
+		_ = repo.Create(context.Background(), order)
+	}
+	useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "error"))
+
+	// Act
+	output, err := useCase.ArchiveConfirmedOrders(context.Background(), ArchiveConfirmedOrdersInput{
+		Now:       now,
+		Retention: 24 * time.Hour,
+		BatchSize: 2,
+	})
+
+	// Assert: batches run until one comes back short
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.Archived != 5 || len(repo.archived) != 5 {
+		t.Errorf("expected 5 archived orders, got %d (%d in the archive)", output.Archived, len(repo.archived))
+	}
+	if repo.archiveCalls != 3 {
+		t.Errorf("expected 3 batches, got %d", repo.archiveCalls)
+	}
+	if len(repo.orders) != 2 {
+		t.Errorf("expected the recent and pending orders to stay, got %d orders", len(repo.orders))
+	}
+}
+
+func TestArchiveConfirmedOrders_StopsOnShutdown(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	_ = repo.Create(context.Background(), &domain.Order{UserID: 1, Total: 10, Status: domain.OrderStatusConfirmed})
+	useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "error"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	output, err := useCase.ArchiveConfirmedOrders(ctx, ArchiveConfirmedOrdersInput{Now: time.Now().Add(time.Hour)})
+
+	// Assert
+	if err != nil || output.Archived != 0 || repo.archiveCalls != 0 {
+		t.Errorf("expected no batch after cancellation, got %d archived in %d calls (err %v)", output.Archived, repo.archiveCalls, err)
+	}
+}
+
+func TestCancelStalePendingOrders(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		output, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+		output.Order.CreatedAt = now.Add(-48 * time.Hour)
+	}
+	fresh, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	publisher.events = nil
+
+	// Act
+	output, err := useCase.CancelStalePendingOrders(context.Background(), CancelStalePendingOrdersInput{
+		Now:       now,
+		Threshold: 24 * time.Hour,
+		BatchSize: 2,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if output.Cancelled != 3 {
+		t.Errorf("expected 3 cancelled orders, got %d", output.Cancelled)
+	}
+
+	if fresh.Order.Status != domain.OrderStatusPending {
+		t.Errorf("expected fresh order to stay pending, got %s", fresh.Order.Status)
+	}
+
+	if len(publisher.events) != 3 {
+		t.Errorf("expected 3 events published, got %d", len(publisher.events))
+	}
+}
+
+func TestListOrders_NoOrders(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+
+	// Act
+	output, err := useCase.ListOrders(context.Background(), ListOrdersInput{UserID: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if output.Orders == nil {
+		t.Fatal("expected non-nil empty slice, got nil")
+	}
+
+	data, _ := json.Marshal(output.Orders)
+	if string(data) != "[]" {
+		t.Errorf("expected [] in JSON, got %s", data)
+	}
+}
+
+func TestGetOrderStats(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+	for _, order := range []*domain.Order{
+		{UserID: 1, Total: 10, Status: domain.OrderStatusPending},
+		{UserID: 1, Total: 15.5, Status: domain.OrderStatusPending},
+		{UserID: 1, Total: 20, Status: domain.OrderStatusCancelled},
+		{UserID: 2, Total: 99, Status: domain.OrderStatusPending},
+	} {
+		_ = repo.Create(context.Background(), order)
+	}
+
+	// Act
+	output, err := useCase.GetOrderStats(context.Background(), GetOrderStatsInput{UserID: 1})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := []ports.OrderStats{
+		{Status: domain.OrderStatusCancelled, Count: 1, Total: 20},
+		{Status: domain.OrderStatusPending, Count: 2, Total: 25.5},
+	}
+	if !reflect.DeepEqual(output.Stats, want) {
+		t.Errorf("expected %+v, got %+v", want, output.Stats)
+	}
+
+	// A user without orders gets an empty list, and user 0 is rejected
+	output, err = useCase.GetOrderStats(context.Background(), GetOrderStatsInput{UserID: 3})
+	if err != nil || output.Stats == nil || len(output.Stats) != 0 {
+		t.Errorf("expected empty stats, got %+v, %v", output, err)
+	}
+	if _, err := useCase.GetOrderStats(context.Background(), GetOrderStatsInput{}); !errors.Is(err, errors.CodeValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestGetOrderWindowStats(t *testing.T) {
+	// Arrange: orders from 1 hour, 2 days and 10 days ago, and one from 40
+	// days ago outside every window
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	repo := NewMockOrderRepository()
+	useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+	for _, order := range []*domain.Order{
+		{UserID: 1, Total: 10, Status: domain.OrderStatusPending, CreatedAt: now.Add(-time.Hour)},
+		{UserID: 2, Total: 20, Status: domain.OrderStatusCancelled, CreatedAt: now.Add(-time.Hour)},
+		{UserID: 1, Total: 30, Status: domain.OrderStatusConfirmed, CreatedAt: now.Add(-48 * time.Hour)},
+		{UserID: 3, Total: 40, Status: domain.OrderStatusConfirmed, CreatedAt: now.Add(-10 * 24 * time.Hour)},
+		{UserID: 3, Total: 50, Status: domain.OrderStatusConfirmed, CreatedAt: now.Add(-40 * 24 * time.Hour)},
+	} {
+		_ = repo.Create(context.Background(), order)
+	}
+
+	tests := []struct {
+		window      string
+		wantWindow  string
+		wantCount   int64
+		wantRevenue float64
+	}{
+		{"", "24h", 2, 10},
+		{"24h", "24h", 2, 10},
+		{"7d", "7d", 3, 40},
+		{"30d", "30d", 4, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run("window "+tt.window, func(t *testing.T) {
+			// Act
+			output, err := useCase.GetOrderWindowStats(context.Background(), GetOrderWindowStatsInput{Window: tt.window, Now: now})
+
+			// Assert
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if output.Window != tt.wantWindow || output.Since != now.Add(-StatsWindows[tt.wantWindow]) {
+				t.Errorf("expected window %s, got %s since %v", tt.wantWindow, output.Window, output.Since)
+			}
+			if output.Count != tt.wantCount || output.Revenue != tt.wantRevenue {
+				t.Errorf("expected %d orders and %v revenue, got %d and %v", tt.wantCount, tt.wantRevenue, output.Count, output.Revenue)
+			}
+		})
+	}
+}
+
+func TestGetOrderWindowStats_RejectsUnknownWindow(t *testing.T) {
+	useCase := NewOrderUseCase(NewMockOrderRepository(), &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+
+	for _, window := range []string{"1h", "24H", "7 days", "-1d"} {
+		if _, err := useCase.GetOrderWindowStats(context.Background(), GetOrderWindowStatsInput{Window: window, Now: time.Now()}); !errors.Is(err, errors.CodeValidation) {
+			t.Errorf("window %q: expected validation error, got %v", window, err)
+		}
+	}
+}
+
+func TestBatchGetOrders(t *testing.T) {
+	// Orders 1 and 2 exist, 3 was deleted
+	newUseCase := func() (*OrderUseCase, *MockOrderRepository) {
+		repo := NewMockOrderRepository()
+		for _, order := range []*domain.Order{
+			{UserID: 1, Total: 10, Status: domain.OrderStatusPending},
+			{UserID: 1, Total: 20, Status: domain.OrderStatusConfirmed},
+			{UserID: 2, Total: 30, Status: domain.OrderStatusPending},
+		} {
+			_ = repo.Create(context.Background(), order)
+		}
+		_ = repo.Delete(context.Background(), 3)
+		return NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "error")), repo
+	}
+
+	tests := []struct {
+		name        string
+		ids         []uint
+		wantIDs     []uint
+		wantMissing []uint
+	}{
+		{"all found", []uint{2, 1}, []uint{2, 1}, []uint{}},
+		{"partial", []uint{1, 3, 9, 1}, []uint{1}, []uint{3, 9}},
+		{"none found", []uint{7, 8}, []uint{}, []uint{7, 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			useCase, repo := newUseCase()
+
+			// Act
+			output, err := useCase.BatchGetOrders(context.Background(), BatchGetOrdersInput{IDs: tt.ids})
+
+			// Assert: one repository call, results in request order without duplicates
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if repo.getByIDsCalls != 1 {
+				t.Errorf("expected a single repository call, got %d", repo.getByIDsCalls)
+			}
+			gotIDs := []uint{}
+			for _, order := range output.Orders {
+				gotIDs = append(gotIDs, order.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("expected orders %v, got %v", tt.wantIDs, gotIDs)
+			}
+			if !reflect.DeepEqual(output.MissingIDs, tt.wantMissing) {
+				t.Errorf("expected missing %v, got %v", tt.wantMissing, output.MissingIDs)
+			}
+		})
+	}
+}
+
+func TestBatchGetOrders_Validation(t *testing.T) {
+	useCase := NewOrderUseCase(NewMockOrderRepository(), &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "error"))
+	useCase.SetMaxBatchSize(2)
+
+	for _, ids := range [][]uint{nil, {1, 2, 3}} {
+		if _, err := useCase.BatchGetOrders(context.Background(), BatchGetOrdersInput{IDs: ids}); !errors.Is(err, errors.CodeValidation) {
+			t.Errorf("ids %v: expected validation error, got %v", ids, err)
+		}
+	}
+}
+
+func TestCreateOrder_AsyncUserValidation(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	// The users service is down; async validation must not call it
+	userClient.err = errors.NewUnavailable("users service is unavailable")
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+	useCase.EnableAsyncUserValidation(&MockUserReadModel{users: map[uint]*ports.UserInfo{
+		1: {ID: 1, Name: "John Doe", Email: "john@example.com"},
+	}})
+
+	// Act
+	known, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	unknown, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 2, Total: 10})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Assert
+	if !known.Order.UserVerified {
+		t.Error("expected order for a known user to be verified")
+	}
+	if unknown.Order.UserVerified {
+		t.Error("expected order for an unknown user to be unverified")
+	}
+}
+
+func TestCreateOrder_DeletedUser(t *testing.T) {
+	deletedAt := time.Now()
+
+	tests := []struct {
+		name  string
+		async bool
+	}{
+		{"sync validation", false},
+		{"async validation", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the users service still answers for the user, but the
+			// read-model has already seen the deletion
+			repo := NewMockOrderRepository()
+			log := logger.New("test", "debug")
+			useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), log)
+			readModel := &MockUserReadModel{users: map[uint]*ports.UserInfo{
+				1: {ID: 1, Name: "John Doe", Email: "john@example.com", DeletedAt: &deletedAt},
+			}}
+			if tt.async {
+				useCase.EnableAsyncUserValidation(readModel)
+			} else {
+				useCase.SetUserReadModel(readModel)
+			}
+
+			// Act
+			_, err := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+
+			// Assert
+			if !errors.Is(err, errors.CodeValidation) {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if appErr, ok := err.(*errors.AppError); !ok || appErr.Message != "user is inactive" {
+				t.Errorf("expected \"user is inactive\", got %v", err)
+			}
+			if len(repo.orders) != 0 {
+				t.Errorf("expected no order to be created, got %d", len(repo.orders))
+			}
+		})
+	}
+}
+
+func TestReconcileUnverifiedOrders(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	userClient := NewMockUserClient()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, userClient, log)
+	useCase.EnableAsyncUserValidation(&MockUserReadModel{users: map[uint]*ports.UserInfo{}})
+
+	// User 1 exists in the users service but not yet in the read-model; user 2 doesn't exist
+	valid, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	invalid, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 2, Total: 10})
+
+	// Act
+	output, err := useCase.ReconcileUnverifiedOrders(context.Background(), ReconcileUnverifiedOrdersInput{BatchSize: 10})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.Verified != 1 || output.Cancelled != 1 {
+		t.Errorf("expected 1 verified and 1 cancelled, got %+v", output)
+	}
+	if !valid.Order.UserVerified || valid.Order.Status != domain.OrderStatusPending {
+		t.Errorf("expected valid order to be verified and pending, got %+v", valid.Order)
+	}
+	if invalid.Order.Status != domain.OrderStatusCancelled {
+		t.Errorf("expected invalid order to be cancelled, got %s", invalid.Order.Status)
+	}
+}
+
+// confirmingOrderRepository confirms the unverified orders right after
+// reading them, like payments landing mid-reconciliation
+type confirmingOrderRepository struct {
+	*MockOrderRepository
+}
+
+func (r confirmingOrderRepository) GetUnverified(ctx context.Context, limit int) ([]*domain.Order, error) {
+	orders, err := r.MockOrderRepository.GetUnverified(ctx, limit)
+	for _, order := range orders {
+		r.orders[order.ID].Status = domain.OrderStatusConfirmed
+	}
+	return orders, err
+}
+
+func TestReconcileUnverifiedOrders_SkipsOrdersNoLongerPending(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	useCase := NewOrderUseCase(confirmingOrderRepository{repo}, publisher, NewMockUserClient(), logger.New("test", "debug"))
+	useCase.EnableAsyncUserValidation(&MockUserReadModel{users: map[uint]*ports.UserInfo{}})
+
+	valid, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	invalid, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 2, Total: 10})
+	publisher.events = nil
+
+	// Act
+	output, err := useCase.ReconcileUnverifiedOrders(context.Background(), ReconcileUnverifiedOrdersInput{BatchSize: 10})
+
+	// Assert: the confirmations stand, even for the order of an unknown user
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.Verified != 0 || output.Cancelled != 0 {
+		t.Errorf("expected nothing reconciled, got %+v", output)
+	}
+	for _, order := range []*domain.Order{valid.Order, invalid.Order} {
+		if order.Status != domain.OrderStatusConfirmed {
+			t.Errorf("expected order %d to stay confirmed, got %s", order.ID, order.Status)
+		}
+	}
+	if len(publisher.events) != 0 {
+		t.Errorf("expected no cancellation event, got %v", publisher.events)
+	}
+}
+
+func TestUpdateOrderStatuses(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, NewMockUserClient(), log)
+
+	pending, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+	confirmed, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 20})
+	confirmed.Order.Status = domain.OrderStatusConfirmed
+	cancelled, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 30})
+	cancelled.Order.Cancel()
+	publisher.events = nil
+
+	ids := []uint{pending.Order.ID, confirmed.Order.ID, cancelled.Order.ID, 999, pending.Order.ID}
+
+	// Act
+	output, err := useCase.UpdateOrderStatuses(context.Background(), UpdateOrderStatusesInput{
+		IDs:    ids,
+		Status: domain.OrderStatusConfirmed,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(output.Results) != 4 {
+		t.Fatalf("expected one result per distinct order, got %d", len(output.Results))
+	}
+	if output.Succeeded != 2 || output.Failed != 2 {
+		t.Errorf("expected 2 succeeded and 2 failed, got %d and %d", output.Succeeded, output.Failed)
+	}
+
+	byID := map[uint]OrderStatusResult{}
+	for _, result := range output.Results {
+		byID[result.ID] = result
+	}
+	if r := byID[pending.Order.ID]; r.Err != nil || !r.Changed {
+		t.Errorf("expected pending order to be confirmed, got %+v", r)
+	}
+	if r := byID[confirmed.Order.ID]; r.Err != nil || r.Changed {
+		t.Errorf("expected confirmed order to succeed unchanged, got %+v", r)
+	}
+	if r := byID[cancelled.Order.ID]; !errors.Is(r.Err, errors.CodeConflict) {
+		t.Errorf("expected conflict for cancelled order, got %v", r.Err)
+	}
+	if r := byID[999]; !errors.Is(r.Err, errors.CodeNotFound) {
+		t.Errorf("expected not found for missing order, got %v", r.Err)
+	}
+
+	// Only the order that changed is published
+	if len(publisher.confirmed) != 1 || publisher.confirmed[0] != pending.Order.ID {
+		t.Errorf("expected one confirmed event for order %d, got %v", pending.Order.ID, publisher.confirmed)
+	}
+}
+
+func TestUpdateOrderStatuses_CancelPublishesReason(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	publisher := &MockEventPublisher{}
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, publisher, NewMockUserClient(), log)
+
+	created, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+
+	// Act
+	output, err := useCase.UpdateOrderStatuses(context.Background(), UpdateOrderStatusesInput{
+		IDs:    []uint{created.Order.ID},
+		Status: domain.OrderStatusCancelled,
+		Reason: "fraud_sweep",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.Succeeded != 1 || repo.orders[created.Order.ID].Status != domain.OrderStatusCancelled {
+		t.Errorf("expected order to be cancelled, got %+v", output.Results)
+	}
+	if len(publisher.cancelled) != 1 || publisher.cancelled[0] != "fraud_sweep" {
+		t.Errorf("expected cancellation published with reason, got %v", publisher.cancelled)
+	}
+}
+
+func TestUpdateOrderStatuses_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		input UpdateOrderStatusesInput
+	}{
+		{"no orders", UpdateOrderStatusesInput{Status: domain.OrderStatusCancelled}},
+		{"pending target", UpdateOrderStatusesInput{IDs: []uint{1}, Status: domain.OrderStatusPending}},
+		{"too many orders", UpdateOrderStatusesInput{IDs: make([]uint, DefaultMaxBatchSize+1), Status: domain.OrderStatusCancelled}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := NewOrderUseCase(NewMockOrderRepository(), &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+
+			_, err := useCase.UpdateOrderStatuses(context.Background(), tt.input)
+
+			if !errors.Is(err, errors.CodeValidation) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateOrderStatuses_MaxBatchSize(t *testing.T) {
+	const max = 3
+
+	tests := []struct {
+		name    string
+		count   int
+		wantErr bool
+	}{
+		{"exactly max", max, false},
+		{"max plus one", max + 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := NewMockOrderRepository()
+			useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), logger.New("test", "debug"))
+			useCase.SetMaxBatchSize(max)
+
+			ids := make([]uint, 0, tt.count)
+			for i := 0; i < tt.count; i++ {
+				created, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+				ids = append(ids, created.Order.ID)
+			}
+
+			// Act
+			output, err := useCase.UpdateOrderStatuses(context.Background(), UpdateOrderStatusesInput{
+				IDs:    ids,
+				Status: domain.OrderStatusConfirmed,
+			})
+
+			// Assert
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if output.Succeeded != max {
+					t.Errorf("expected %d orders confirmed, got %d", max, output.Succeeded)
+				}
+				return
+			}
+
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) || appErr.Code != errors.CodeValidation {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			details, _ := appErr.Details.(map[string]interface{})
+			if details["max"] != max {
+				t.Errorf("expected the limit in the error details, got %v", appErr.Details)
+			}
+			for _, id := range ids {
+				if repo.orders[id].Status != domain.OrderStatusPending {
+					t.Errorf("expected order %d untouched, got %s", id, repo.orders[id].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestGetOrdersByStatus(t *testing.T) {
+	// Arrange
+	repo := NewMockOrderRepository()
+	log := logger.New("test", "debug")
+	useCase := NewOrderUseCase(repo, &MockEventPublisher{}, NewMockUserClient(), log)
+
+	var pendingIDs []uint
+	for i := 0; i < 5; i++ {
+		created, _ := useCase.CreateOrder(context.Background(), CreateOrderInput{UserID: 1, Total: 10})
+		if i%2 == 0 {
+			created.Order.Cancel()
+			continue
+		}
+		pendingIDs = append(pendingIDs, created.Order.ID)
+	}
+
+	// Act
+	firstPage, err := useCase.GetOrdersByStatus(context.Background(), GetOrdersByStatusInput{
+		Status: domain.OrderStatusPending,
+		Limit:  1,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	secondPage, err := useCase.GetOrdersByStatus(context.Background(), GetOrdersByStatusInput{
+		Status: domain.OrderStatusPending,
+		Limit:  1,
+		Offset: 1,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	confirmed, err := useCase.GetOrdersByStatus(context.Background(), GetOrdersByStatusInput{
+		Status: domain.OrderStatusConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Assert
+	var got []uint
+	for _, order := range append(firstPage.Orders, secondPage.Orders...) {
+		if order.Status != domain.OrderStatusPending {
+			t.Errorf("expected only pending orders, got order %d in %s", order.ID, order.Status)
+		}
+		got = append(got, order.ID)
+	}
+	if len(got) != len(pendingIDs) || got[0] != pendingIDs[0] || got[1] != pendingIDs[1] {
+		t.Errorf("expected pending orders %v across both pages, got %v", pendingIDs, got)
+	}
+	if confirmed.Orders == nil || len(confirmed.Orders) != 0 {
+		t.Errorf("expected an empty non-nil slice for confirmed orders, got %v", confirmed.Orders)
+	}
+}
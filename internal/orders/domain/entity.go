@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"math"
 	"time"
 )
 
@@ -13,6 +14,16 @@ const (
 	OrderStatusCancelled OrderStatus = "cancelled"
 )
 
+// DefaultMinorUnits is the number of decimal places allowed in totals (e.g.
+// cents) unless the service is configured for another currency
+const DefaultMinorUnits = 2
+
+// MaxTotal is the largest total an order may have
+const MaxTotal = 1000000
+
+// MaxIdempotencyKeyLength is the longest idempotency key an order may have
+const MaxIdempotencyKeyLength = 255
+
 // Order represents the order domain entity
 type Order struct {
 	ID        uint
@@ -21,47 +32,184 @@ type Order struct {
 	Status    OrderStatus
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// UserVerified is false for orders created optimistically before their
+	// user was known; they are reconciled asynchronously
+	UserVerified bool
+
+	// DeletedAt is set once the order has been soft-deleted
+	DeletedAt *time.Time
+
+	// CancelledAt is when the order was last cancelled; nil for orders that
+	// are not cancelled, and for ones cancelled before it was recorded
+	CancelledAt *time.Time
+
+	// Anonymized is set once the order has been detached from its deleted
+	// user; UserID is then 0
+	Anonymized bool
+
+	// IdempotencyKey is the client-chosen key the order was created with, if
+	// any. A user's orders never share a key, so retries of a create request
+	// return the order it created instead of adding another.
+	IdempotencyKey string
 }
 
-// Validate validates the order entity
-func (o *Order) Validate() error {
-	if o.UserID == 0 {
+// Validate validates the order entity. minorUnits is the number of decimal
+// places the total may have.
+func (o *Order) Validate(minorUnits int) error {
+	if o.UserID == 0 && !o.Anonymized {
 		return ErrUserIDRequired
 	}
 	if o.Total <= 0 {
 		return ErrInvalidTotal
 	}
-	if o.Total > 1000000 {
-		return ErrTotalTooHigh
+	if o.Total > MaxTotal {
+		return NewTotalTooHigh(MaxTotal)
+	}
+	if !HasValidPrecision(o.Total, minorUnits) {
+		return NewInvalidTotalPrecision(minorUnits)
 	}
 	return nil
 }
 
-// NewOrder creates a new order with validation
-func NewOrder(userID uint, total float64) (*Order, error) {
+// HasValidPrecision reports whether amount has at most minorUnits decimal places.
+// The amount is scaled and compared to its rounded value with a small tolerance
+// so binary float representation (e.g. 99.99) is not mistaken for extra precision.
+func HasValidPrecision(amount float64, minorUnits int) bool {
+	scaled := amount * math.Pow10(minorUnits)
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
+
+// NewOrder creates a new order with validation; its total may have at most
+// minorUnits decimal places
+func NewOrder(userID uint, total float64, minorUnits int) (*Order, error) {
 	order := &Order{
 		UserID:    userID,
 		Total:     total,
 		Status:    OrderStatusPending,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		UserVerified: true,
 	}
 
-	if err := order.Validate(); err != nil {
+	if err := order.Validate(minorUnits); err != nil {
 		return nil, err
 	}
 
 	return order, nil
 }
 
-// Confirm confirms the order
-func (o *Order) Confirm() {
+// Confirm confirms the order. Only pending orders can be confirmed.
+func (o *Order) Confirm() error {
+	if o.Status != OrderStatusPending {
+		return ErrOrderNotPending
+	}
 	o.Status = OrderStatusConfirmed
 	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// ChangeTotal sets a new total with at most minorUnits decimal places. Only
+// pending orders can change their total.
+func (o *Order) ChangeTotal(total float64, minorUnits int) error {
+	if o.Status != OrderStatusPending {
+		return ErrOrderNotPending
+	}
+
+	previous := o.Total
+	o.Total = total
+	if err := o.Validate(minorUnits); err != nil {
+		o.Total = previous
+		return err
+	}
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// MarkUserVerified records that the order's user has been validated
+func (o *Order) MarkUserVerified() {
+	o.UserVerified = true
+	o.UpdatedAt = time.Now()
+}
+
+// DefaultReopenWindow is how long after cancellation an order can be reopened
+const DefaultReopenWindow = 24 * time.Hour
+
+// statusTransitions lists the statuses each status may move to. Cancelled is
+// final here; Reopen is the only way back, and only for a limited time.
+var statusTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusCancelled},
+}
+
+// CanTransitionTo reports whether the order may move to status
+func (o *Order) CanTransitionTo(status OrderStatus) bool {
+	for _, allowed := range statusTransitions[o.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo moves the order to status, enforcing the allowed transitions
+func (o *Order) TransitionTo(status OrderStatus) error {
+	if !o.CanTransitionTo(status) {
+		return NewInvalidStatusTransitionError(o.Status, status)
+	}
+	o.Status = status
+	o.UpdatedAt = time.Now()
+	if status == OrderStatusCancelled {
+		cancelledAt := o.UpdatedAt
+		o.CancelledAt = &cancelledAt
+	}
+	return nil
+}
+
+// Reopen moves a cancelled order back to pending, if it was cancelled no more
+// than window before now. Orders without a recorded cancellation time can't be
+// shown to be within the window and are refused, and so are anonymized ones,
+// which no longer have a user to reopen them for.
+func (o *Order) Reopen(now time.Time, window time.Duration) error {
+	if o.Status != OrderStatusCancelled {
+		return ErrOrderNotCancelled
+	}
+	if o.Anonymized {
+		return ErrOrderAnonymized
+	}
+	if o.CancelledAt == nil || now.Sub(*o.CancelledAt) > window {
+		return ErrReopenWindowExpired
+	}
+	o.Status = OrderStatusPending
+	o.UpdatedAt = now
+	o.CancelledAt = nil
+	return nil
+}
+
+// Anonymize detaches the order from its user, whose data is being erased.
+// The idempotency key is dropped too: keys are only unique per user.
+func (o *Order) Anonymize(now time.Time) {
+	o.UserID = 0
+	o.Anonymized = true
+	o.IdempotencyKey = ""
+	o.UpdatedAt = now
 }
 
 // Cancel cancels the order
 func (o *Order) Cancel() {
 	o.Status = OrderStatusCancelled
 	o.UpdatedAt = time.Now()
+	cancelledAt := o.UpdatedAt
+	o.CancelledAt = &cancelledAt
+}
+
+// Age returns how long ago the order was created relative to now
+func (o *Order) Age(now time.Time) time.Duration {
+	return now.Sub(o.CreatedAt)
+}
+
+// IsStalePending reports whether the order is still pending after threshold
+func (o *Order) IsStalePending(now time.Time, threshold time.Duration) bool {
+	return o.Status == OrderStatusPending && o.Age(now) > threshold
 }
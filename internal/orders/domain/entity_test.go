@@ -0,0 +1,200 @@
+package domain
+
+import (
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go-micro/pkg/errors"
+)
+
+func TestNewOrder_TotalPrecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   float64
+		wantErr bool
+	}{
+		{"two decimals", 99.99, false},
+		{"one decimal", 0.1, false},
+		{"whole number", 100, false},
+		{"float artifact", 0.1 + 0.2, false},
+		{"three decimals", 99.995, true},
+		{"three decimals small", 0.001, true},
+		{"many decimals", 10.123456, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOrder(1, tt.total, DefaultMinorUnits)
+			if tt.wantErr {
+				if !errors.Is(err, errors.CodeValidation) {
+					t.Errorf("expected validation error for %v, got %v", tt.total, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error for %v, got %v", tt.total, err)
+			}
+		})
+	}
+}
+
+func TestNewOrder_TotalTooHigh(t *testing.T) {
+	// Act
+	_, err := NewOrder(1, MaxTotal+0.01, DefaultMinorUnits)
+
+	// Assert: the limit is in both the message and the details
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) || appErr.Code != errors.CodeValidation {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if appErr.Message != "total cannot exceed 1000000" {
+		t.Errorf("unexpected message %q", appErr.Message)
+	}
+	if details, _ := appErr.Details.(map[string]interface{}); details["max"] != float64(MaxTotal) {
+		t.Errorf("expected max %v in details, got %v", MaxTotal, appErr.Details)
+	}
+
+	if _, err := NewOrder(1, MaxTotal, DefaultMinorUnits); err != nil {
+		t.Errorf("expected the maximum itself to be allowed, got %v", err)
+	}
+}
+
+func TestHasValidPrecision_MinorUnits(t *testing.T) {
+	if !HasValidPrecision(100, 0) {
+		t.Error("expected 100 to be valid with 0 minor units")
+	}
+	if HasValidPrecision(100.5, 0) {
+		t.Error("expected 100.5 to be invalid with 0 minor units")
+	}
+	if !HasValidPrecision(1.234, 3) {
+		t.Error("expected 1.234 to be valid with 3 minor units")
+	}
+}
+
+func TestOrder_TransitionTo(t *testing.T) {
+	tests := []struct {
+		from    OrderStatus
+		to      OrderStatus
+		wantErr bool
+	}{
+		{OrderStatusPending, OrderStatusConfirmed, false},
+		{OrderStatusPending, OrderStatusCancelled, false},
+		{OrderStatusConfirmed, OrderStatusCancelled, false},
+		{OrderStatusConfirmed, OrderStatusPending, true},
+		{OrderStatusCancelled, OrderStatusConfirmed, true},
+		{OrderStatusCancelled, OrderStatusPending, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			order := &Order{Status: tt.from}
+			err := order.TransitionTo(tt.to)
+			if tt.wantErr {
+				if !errors.Is(err, errors.CodeConflict) {
+					t.Errorf("expected conflict error, got %v", err)
+				}
+				if order.Status != tt.from {
+					t.Errorf("expected status to stay %s, got %s", tt.from, order.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if order.Status != tt.to {
+				t.Errorf("expected status %s, got %s", tt.to, order.Status)
+			}
+		})
+	}
+}
+
+func TestOrder_Reopen(t *testing.T) {
+	now := time.Now()
+	at := func(t time.Time) *time.Time { return &t }
+	tests := []struct {
+		name        string
+		status      OrderStatus
+		cancelledAt *time.Time
+		anonymized  bool
+		wantErr     error
+	}{
+		{"recently cancelled", OrderStatusCancelled, at(now.Add(-time.Hour)), false, nil},
+		{"cancelled at the window edge", OrderStatusCancelled, at(now.Add(-DefaultReopenWindow)), false, nil},
+		{"window expired", OrderStatusCancelled, at(now.Add(-DefaultReopenWindow - time.Second)), false, ErrReopenWindowExpired},
+		{"cancellation time unknown", OrderStatusCancelled, nil, false, ErrReopenWindowExpired},
+		{"anonymized", OrderStatusCancelled, at(now.Add(-time.Hour)), true, ErrOrderAnonymized},
+		{"pending", OrderStatusPending, nil, false, ErrOrderNotCancelled},
+		{"confirmed", OrderStatusConfirmed, nil, false, ErrOrderNotCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Updated since the cancellation, e.g. anonymized, which must not
+			// extend the window
+			order := &Order{Status: tt.status, UpdatedAt: now, CancelledAt: tt.cancelledAt, Anonymized: tt.anonymized}
+			err := order.Reopen(now, DefaultReopenWindow)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Errorf("expected %v, got %v", tt.wantErr, err)
+				}
+				if order.Status != tt.status {
+					t.Errorf("expected status to stay %s, got %s", tt.status, order.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if order.Status != OrderStatusPending || !order.UpdatedAt.Equal(now) {
+				t.Errorf("expected pending updated at %v, got %s at %v", now, order.Status, order.UpdatedAt)
+			}
+			if order.CancelledAt != nil {
+				t.Errorf("expected the cancellation time to be cleared, got %v", order.CancelledAt)
+			}
+		})
+	}
+}
+
+func TestOrder_CancelRecordsCancellationTime(t *testing.T) {
+	// Arrange
+	cancelled := &Order{Status: OrderStatusPending}
+	transitioned := &Order{Status: OrderStatusConfirmed}
+
+	// Act
+	cancelled.Cancel()
+	err := transitioned.TransitionTo(OrderStatusCancelled)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, order := range []*Order{cancelled, transitioned} {
+		if order.CancelledAt == nil || !order.CancelledAt.Equal(order.UpdatedAt) {
+			t.Errorf("expected cancelled_at %v, got %v", order.UpdatedAt, order.CancelledAt)
+		}
+	}
+}
+
+func TestOrder_Anonymize(t *testing.T) {
+	order, err := NewOrder(7, 10, DefaultMinorUnits)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	order.IdempotencyKey = "abc"
+
+	order.Anonymize(time.Now())
+
+	if order.UserID != 0 || !order.Anonymized {
+		t.Fatalf("expected the order to be detached from its user, got %+v", order)
+	}
+	// Keys are unique per user, so they can't be kept under user 0
+	if order.IdempotencyKey != "" {
+		t.Errorf("expected the idempotency key to be dropped, got %q", order.IdempotencyKey)
+	}
+	// An anonymized order without a user is still valid
+	if err := order.ChangeTotal(15, DefaultMinorUnits); err != nil {
+		t.Errorf("expected anonymized order to accept a new total, got %v", err)
+	}
+}
@@ -1,14 +1,28 @@
 package domain
 
-import "go-micro/pkg/errors"
+import (
+	"fmt"
+	"strconv"
+
+	"go-micro/pkg/errors"
+)
 
 // Domain-specific errors
 var (
-	ErrUserIDRequired = errors.NewValidation("user_id is required", nil)
-	ErrInvalidTotal   = errors.NewValidation("total must be greater than 0", nil)
-	ErrTotalTooHigh   = errors.NewValidation("total cannot exceed 1,000,000", nil)
-	ErrOrderNotFound  = errors.NewNotFound("order", "unknown")
-	ErrUserNotFound   = errors.NewNotFound("user", "unknown")
+	ErrUserIDRequired        = errors.NewValidation("user_id is required", nil)
+	ErrInvalidTotal          = errors.NewValidation("total must be greater than 0", nil)
+	ErrOrderNotPending       = errors.NewConflict("order is not pending")
+	ErrOrderNotCancelled     = errors.NewConflict("only cancelled orders can be reopened")
+	ErrReopenWindowExpired   = errors.NewConflict("order was cancelled too long ago to be reopened")
+	ErrOrderAnonymized       = errors.NewConflict("order belonged to a deleted user and cannot be reopened")
+	ErrOrderNotFound         = errors.NewNotFound("order", "unknown")
+	ErrUserNotFound          = errors.NewNotFound("user", "unknown")
+	ErrUserServiceTimeout    = errors.NewUnavailable("timed out validating user with the users service")
+	ErrIdempotencyKeyTooLong = errors.NewValidation("idempotency key cannot exceed 255 characters", nil)
+	// ErrIdempotencyKeyReused is returned by repositories when the user
+	// already has an order with the key, and by CreateOrder when that order
+	// doesn't match the request
+	ErrIdempotencyKeyReused = errors.NewConflict("idempotency key was already used for another order")
 )
 
 // NewOrderNotFound creates a not found error with the order ID
@@ -22,3 +36,32 @@ func NewUserNotFoundError(userID uint) error {
 		"user_id": userID,
 	})
 }
+
+// NewUserInactiveError creates a validation error for a user that has been deleted
+func NewUserInactiveError(userID uint) error {
+	return errors.NewValidation("user is inactive", map[string]interface{}{
+		"user_id": userID,
+	})
+}
+
+// NewTotalTooHigh creates a validation error for a total above max, which is
+// reported in the details so clients don't have to know the limit up front
+func NewTotalTooHigh(max float64) error {
+	return errors.NewValidation(fmt.Sprintf("total cannot exceed %s", strconv.FormatFloat(max, 'f', -1, 64)), map[string]interface{}{
+		"max": max,
+	})
+}
+
+// NewInvalidTotalPrecision creates a validation error for a total with more
+// than minorUnits decimal places
+func NewInvalidTotalPrecision(minorUnits int) error {
+	return errors.NewValidation(fmt.Sprintf("total cannot have more than %d decimal places", minorUnits), map[string]interface{}{
+		"minor_units": minorUnits,
+	})
+}
+
+// NewInvalidStatusTransitionError creates a conflict error for a status
+// change the order's current status does not allow
+func NewInvalidStatusTransitionError(from, to OrderStatus) error {
+	return errors.NewConflict(fmt.Sprintf("cannot change order status from %s to %s", from, to))
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// orderActions are the operations that may change an order's status
+var orderActions = map[string]func(o *Order, now time.Time) error{
+	"confirm":                 func(o *Order, now time.Time) error { return o.Confirm() },
+	"cancel":                  func(o *Order, now time.Time) error { o.Cancel(); return nil },
+	"reopen":                  func(o *Order, now time.Time) error { return o.Reopen(now, DefaultReopenWindow) },
+	"change total":            func(o *Order, now time.Time) error { return o.ChangeTotal(20, DefaultMinorUnits) },
+	"transition to pending":   func(o *Order, now time.Time) error { return o.TransitionTo(OrderStatusPending) },
+	"transition to confirmed": func(o *Order, now time.Time) error { return o.TransitionTo(OrderStatusConfirmed) },
+	"transition to cancelled": func(o *Order, now time.Time) error { return o.TransitionTo(OrderStatusCancelled) },
+}
+
+// TestOrder_StateMachine enumerates every (status, action) pair. Adding a
+// status or an action without extending the table fails the test.
+func TestOrder_StateMachine(t *testing.T) {
+	type outcome struct {
+		allowed bool
+		status  OrderStatus
+	}
+	allowed := func(status OrderStatus) outcome { return outcome{true, status} }
+	forbidden := outcome{allowed: false}
+
+	tests := map[OrderStatus]map[string]outcome{
+		OrderStatusPending: {
+			"confirm":                 allowed(OrderStatusConfirmed),
+			"cancel":                  allowed(OrderStatusCancelled),
+			"reopen":                  forbidden,
+			"change total":            allowed(OrderStatusPending),
+			"transition to pending":   forbidden,
+			"transition to confirmed": allowed(OrderStatusConfirmed),
+			"transition to cancelled": allowed(OrderStatusCancelled),
+		},
+		OrderStatusConfirmed: {
+			"confirm":                 forbidden,
+			"cancel":                  allowed(OrderStatusCancelled),
+			"reopen":                  forbidden,
+			"change total":            forbidden,
+			"transition to pending":   forbidden,
+			"transition to confirmed": forbidden,
+			"transition to cancelled": allowed(OrderStatusCancelled),
+		},
+		OrderStatusCancelled: {
+			"confirm": forbidden,
+			// Cancel is unconditional; cancelling again changes nothing
+			"cancel":                  allowed(OrderStatusCancelled),
+			"reopen":                  allowed(OrderStatusPending),
+			"change total":            forbidden,
+			"transition to pending":   forbidden,
+			"transition to confirmed": forbidden,
+			"transition to cancelled": forbidden,
+		},
+	}
+
+	statuses := []OrderStatus{OrderStatusPending, OrderStatusConfirmed, OrderStatusCancelled}
+	if len(tests) != len(statuses) {
+		t.Fatalf("expected outcomes for %d statuses, got %d", len(statuses), len(tests))
+	}
+
+	now := time.Now()
+	for _, status := range statuses {
+		if len(tests[status]) != len(orderActions) {
+			t.Fatalf("expected outcomes for all %d actions from %s, got %d", len(orderActions), status, len(tests[status]))
+		}
+
+		for name, act := range orderActions {
+			want := tests[status][name]
+			t.Run(string(status)+"/"+name, func(t *testing.T) {
+				// Arrange: recently updated and cancelled, so the reopen window is open
+				order := &Order{ID: 1, UserID: 1, Total: 10, Status: status, UpdatedAt: now.Add(-time.Minute)}
+				if status == OrderStatusCancelled {
+					order.CancelledAt = &order.UpdatedAt
+				}
+
+				// Act
+				err := act(order, now)
+
+				// Assert
+				if !want.allowed {
+					if err == nil {
+						t.Fatalf("expected %s to be forbidden from %s, ended %s", name, status, order.Status)
+					}
+					if order.Status != status {
+						t.Errorf("expected a forbidden action to keep status %s, got %s", status, order.Status)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("expected %s to be allowed from %s, got %v", name, status, err)
+				}
+				if order.Status != want.status {
+					t.Errorf("expected status %s, got %s", want.status, order.Status)
+				}
+			})
+		}
+	}
+}
@@ -2,9 +2,12 @@ package infrastructure
 
 import (
 	"context"
+	"time"
 
 	orderspb "go-micro/api/gen/orders/v1"
 	"go-micro/internal/orders/application"
+	"go-micro/internal/orders/domain"
+	"go-micro/pkg/pagination"
 )
 
 // GRPCServer implements the gRPC OrderServiceServer
@@ -32,15 +35,17 @@ func (s *GRPCServer) GetOrder(ctx context.Context, req *orderspb.GetOrderRequest
 		UserId:    uint64(output.Order.UserID),
 		Total:     output.Order.Total,
 		Status:    string(output.Order.Status),
-		CreatedAt: output.Order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
 	}, nil
 }
 
 // CreateOrder implements OrderServiceServer.CreateOrder
 func (s *GRPCServer) CreateOrder(ctx context.Context, req *orderspb.CreateOrderRequest) (*orderspb.OrderResponse, error) {
 	output, err := s.useCase.CreateOrder(ctx, application.CreateOrderInput{
-		UserID: uint(req.GetUserId()),
-		Total:  req.GetTotal(),
+		UserID:         uint(req.GetUserId()),
+		Total:          req.GetTotal(),
+		IdempotencyKey: req.GetIdempotencyKey(),
 	})
 	if err != nil {
 		return nil, err
@@ -51,6 +56,154 @@ func (s *GRPCServer) CreateOrder(ctx context.Context, req *orderspb.CreateOrderR
 		UserId:    uint64(output.Order.UserID),
 		Total:     output.Order.Total,
 		Status:    string(output.Order.Status),
-		CreatedAt: output.Order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
+	}, nil
+}
+
+// ListOrders implements OrderServiceServer.ListOrders
+func (s *GRPCServer) ListOrders(ctx context.Context, req *orderspb.ListOrdersRequest) (*orderspb.ListOrdersResponse, error) {
+	page, err := pagination.DecodeToken(req.GetPage().GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.useCase.ListOrders(ctx, application.ListOrdersInput{
+		UserID:   uint(req.GetUserId()),
+		Page:     page,
+		PageSize: int(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*orderspb.OrderResponse, len(output.Orders))
+	for i, order := range output.Orders {
+		orders[i] = toOrderResponse(order)
+	}
+
+	return &orderspb.ListOrdersResponse{
+		Orders: orders,
+		Page: &orderspb.PageInfo{
+			Total:         output.Total,
+			NextPageToken: pagination.NextToken(output.Page, output.PageSize, output.Total),
+		},
+	}, nil
+}
+
+// ListArchivedOrders implements OrderServiceServer.ListArchivedOrders
+func (s *GRPCServer) ListArchivedOrders(ctx context.Context, req *orderspb.ListOrdersRequest) (*orderspb.ListOrdersResponse, error) {
+	page, err := pagination.DecodeToken(req.GetPage().GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.useCase.ListArchivedOrders(ctx, application.ListOrdersInput{
+		UserID:   uint(req.GetUserId()),
+		Page:     page,
+		PageSize: int(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*orderspb.OrderResponse, len(output.Orders))
+	for i, order := range output.Orders {
+		orders[i] = toOrderResponse(order)
+	}
+
+	return &orderspb.ListOrdersResponse{
+		Orders: orders,
+		Page: &orderspb.PageInfo{
+			Total:         output.Total,
+			NextPageToken: pagination.NextToken(output.Page, output.PageSize, output.Total),
+		},
+	}, nil
+}
+
+// ReopenOrder implements OrderServiceServer.ReopenOrder
+func (s *GRPCServer) ReopenOrder(ctx context.Context, req *orderspb.ReopenOrderRequest) (*orderspb.OrderResponse, error) {
+	output, err := s.useCase.ReopenOrder(ctx, application.ReopenOrderInput{
+		ID: uint(req.GetId()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrderResponse(output.Order), nil
+}
+
+// GetOrderStats implements OrderServiceServer.GetOrderStats
+func (s *GRPCServer) GetOrderStats(ctx context.Context, req *orderspb.GetOrderStatsRequest) (*orderspb.OrderStatsResponse, error) {
+	output, err := s.useCase.GetOrderStats(ctx, application.GetOrderStatsInput{
+		UserID: uint(req.GetUserId()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*orderspb.OrderStatusStats, len(output.Stats))
+	for i, stats := range output.Stats {
+		statuses[i] = &orderspb.OrderStatusStats{
+			Status: string(stats.Status),
+			Count:  stats.Count,
+			Total:  stats.Total,
+		}
+	}
+
+	return &orderspb.OrderStatsResponse{Statuses: statuses}, nil
+}
+
+// GetOrderWindowStats implements OrderServiceServer.GetOrderWindowStats
+func (s *GRPCServer) GetOrderWindowStats(ctx context.Context, req *orderspb.GetOrderWindowStatsRequest) (*orderspb.OrderWindowStatsResponse, error) {
+	output, err := s.useCase.GetOrderWindowStats(ctx, application.GetOrderWindowStatsInput{
+		Window: req.GetWindow(),
+		Now:    time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &orderspb.OrderWindowStatsResponse{
+		Window:  output.Window,
+		Since:   orderspb.FormatTime(output.Since),
+		Count:   output.Count,
+		Revenue: output.Revenue,
 	}, nil
 }
+
+// BatchGetOrders implements OrderServiceServer.BatchGetOrders
+func (s *GRPCServer) BatchGetOrders(ctx context.Context, req *orderspb.BatchGetOrdersRequest) (*orderspb.BatchGetOrdersResponse, error) {
+	ids := make([]uint, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		ids[i] = uint(id)
+	}
+
+	output, err := s.useCase.BatchGetOrders(ctx, application.BatchGetOrdersInput{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]*orderspb.OrderResponse, len(output.Orders))
+	for i, order := range output.Orders {
+		orders[i] = toOrderResponse(order)
+	}
+	missing := make([]uint64, len(output.MissingIDs))
+	for i, id := range output.MissingIDs {
+		missing[i] = uint64(id)
+	}
+
+	return &orderspb.BatchGetOrdersResponse{Orders: orders, MissingIds: missing}, nil
+}
+
+// toOrderResponse converts a domain order to its gRPC representation
+func toOrderResponse(order *domain.Order) *orderspb.OrderResponse {
+	return &orderspb.OrderResponse{
+		Id:        uint64(order.ID),
+		UserId:    uint64(order.UserID),
+		Total:     order.Total,
+		Status:    string(order.Status),
+		CreatedAt: orderspb.FormatTime(order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(order.UpdatedAt),
+	}
+}
@@ -0,0 +1,54 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	orderspb "go-micro/api/gen/orders/v1"
+	"go-micro/internal/orders/application"
+	grpcpkg "go-micro/pkg/grpc"
+	"go-micro/pkg/grpc/grpctest"
+	"go-micro/pkg/logger"
+)
+
+// CreateOrder over gRPC skips the HTTP request binding, so the monetary rules
+// must come from the domain and reach the client as InvalidArgument
+func TestGRPCCreateOrder_RejectsTotalPrecision(t *testing.T) {
+	// Arrange: validation fails before the repository or users service is used
+	log := logger.New("test", "debug")
+	useCase := application.NewOrderUseCase(nil, nil, nil, log)
+
+	server := grpctest.NewServer(t, func(s *grpc.Server) {
+		orderspb.RegisterOrderServiceServer(s, NewGRPCServer(useCase))
+	}, grpc.UnaryInterceptor(grpcpkg.UnaryServerInterceptor(log, 0)))
+	conn, err := grpc.Dial(grpctest.Target, append(server.DialOptions(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	client := orderspb.NewOrderServiceClient(conn)
+
+	for _, total := range []float64{10.005, 0.001, 99.999} {
+		// Act
+		_, err := client.CreateOrder(context.Background(), &orderspb.CreateOrderRequest{
+			UserId: 1,
+			Total:  total,
+		})
+
+		// Assert
+		st, _ := status.FromError(err)
+		if st.Code() != codes.InvalidArgument {
+			t.Errorf("total %v: expected InvalidArgument, got %v", total, err)
+			continue
+		}
+		if st.Message() != "total cannot have more than 2 decimal places" {
+			t.Errorf("total %v: expected precision error, got %q", total, st.Message())
+		}
+	}
+}
@@ -6,28 +6,41 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	orderspb "go-micro/api/gen/orders/v1"
 	"go-micro/internal/orders/application"
+	"go-micro/internal/orders/domain"
 	"go-micro/pkg/errors"
 	"go-micro/pkg/middleware"
 )
 
 // HTTPHandler handles HTTP requests for orders
 type HTTPHandler struct {
-	useCase *application.OrderUseCase
+	useCase     *application.OrderUseCase
+	adminAPIKey string
 }
 
 // NewHTTPHandler creates a new HTTP handler
-func NewHTTPHandler(useCase *application.OrderUseCase) *HTTPHandler {
-	return &HTTPHandler{useCase: useCase}
+func NewHTTPHandler(useCase *application.OrderUseCase, adminAPIKey string) *HTTPHandler {
+	return &HTTPHandler{useCase: useCase, adminAPIKey: adminAPIKey}
 }
 
 // RegisterRoutes registers the order routes
 func (h *HTTPHandler) RegisterRoutes(r *gin.RouterGroup) {
 	orders := r.Group("/orders")
+	middleware.CORSGroup(orders, http.MethodGet, http.MethodPost)
 	{
 		orders.POST("", h.CreateOrder)
 		orders.GET("/:id", h.GetOrder)
 	}
+
+	admin := r.Group("/admin/orders")
+	middleware.CORSGroup(admin, http.MethodGet, http.MethodPost)
+	admin.Use(middleware.AdminAuth(h.adminAPIKey))
+	{
+		admin.GET("/:id", h.GetOrderIncludingDeleted)
+		admin.POST("/status", h.UpdateOrderStatuses)
+		admin.POST("/:id/reopen", h.ReopenOrder)
+	}
 }
 
 // CreateOrderRequest is the request body for creating an order
@@ -42,14 +55,16 @@ type OrderResponse struct {
 	UserID    uint    `json:"user_id"`
 	Total     float64 `json:"total"`
 	Status    string  `json:"status"`
-	CreatedAt string  `json:"created_at"`
+	CreatedAt string  `json:"created_at,omitempty"`
+	UpdatedAt string  `json:"updated_at,omitempty"`
+	DeletedAt string  `json:"deleted_at,omitempty"`
 }
 
 // CreateOrder handles POST /orders
 func (h *HTTPHandler) CreateOrder(c *gin.Context) {
 	var req CreateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(errors.NewValidation("invalid request body", err.Error()))
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -58,19 +73,17 @@ func (h *HTTPHandler) CreateOrder(c *gin.Context) {
 		Total:  req.Total,
 	})
 	if err != nil {
-		c.Error(err)
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": OrderResponse{
-			ID:        output.Order.ID,
-			UserID:    output.Order.UserID,
-			Total:     output.Order.Total,
-			Status:    string(output.Order.Status),
-			CreatedAt: output.Order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
-		"trace_id": c.GetString(middleware.TraceIDKey),
+	middleware.RespondCreated(c, uint64(output.Order.ID), OrderResponse{
+		ID:        output.Order.ID,
+		UserID:    output.Order.UserID,
+		Total:     output.Order.Total,
+		Status:    string(output.Order.Status),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
 	})
 }
 
@@ -79,7 +92,7 @@ func (h *HTTPHandler) GetOrder(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.Error(errors.NewValidation("invalid order id", nil))
+		middleware.RespondError(c, errors.NewValidation("invalid order id", nil))
 		return
 	}
 
@@ -87,18 +100,145 @@ func (h *HTTPHandler) GetOrder(c *gin.Context) {
 		ID: uint(id),
 	})
 	if err != nil {
-		c.Error(err)
+		middleware.RespondError(c, err)
+		return
+	}
+
+	order := OrderResponse{
+		ID:        output.Order.ID,
+		UserID:    output.Order.UserID,
+		Total:     output.Order.Total,
+		Status:    string(output.Order.Status),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
+	}
+	if middleware.NotModified(c, order) {
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, order)
+}
+
+// GetOrderIncludingDeleted handles GET /admin/orders/:id. Unlike GetOrder it
+// also returns soft-deleted orders, with their deletion time.
+func (h *HTTPHandler) GetOrderIncludingDeleted(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		middleware.RespondError(c, errors.NewValidation("invalid order id", nil))
+		return
+	}
+
+	output, err := h.useCase.GetOrderIncludingDeleted(c.Request.Context(), application.GetOrderInput{
+		ID: uint(id),
+	})
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	order := OrderResponse{
+		ID:        output.Order.ID,
+		UserID:    output.Order.UserID,
+		Total:     output.Order.Total,
+		Status:    string(output.Order.Status),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
+	}
+	if output.Order.DeletedAt != nil {
+		order.DeletedAt = orderspb.FormatTime(*output.Order.DeletedAt)
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, order)
+}
+
+// UpdateOrderStatusesRequest is the request body for a batch status change
+type UpdateOrderStatusesRequest struct {
+	OrderIDs []uint `json:"order_ids" binding:"required,min=1"`
+	Status   string `json:"status" binding:"required,oneof=confirmed cancelled"`
+	Reason   string `json:"reason"`
+}
+
+// OrderStatusResultResponse is the outcome of a batch status change for one order
+type OrderStatusResultResponse struct {
+	ID      uint             `json:"id"`
+	Success bool             `json:"success"`
+	Status  string           `json:"status,omitempty"`
+	Changed bool             `json:"changed"`
+	Error   *errors.AppError `json:"error,omitempty"`
+}
+
+// UpdateOrderStatusesResponse is the response body for a batch status change
+type UpdateOrderStatusesResponse struct {
+	Results   []OrderStatusResultResponse `json:"results"`
+	Succeeded int                         `json:"succeeded"`
+	Failed    int                         `json:"failed"`
+}
+
+// UpdateOrderStatuses handles POST /admin/orders/status. Each order succeeds
+// or fails on its own; the response lists which and why.
+func (h *HTTPHandler) UpdateOrderStatuses(c *gin.Context) {
+	var req UpdateOrderStatusesRequest
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	output, err := h.useCase.UpdateOrderStatuses(c.Request.Context(), application.UpdateOrderStatusesInput{
+		IDs:    req.OrderIDs,
+		Status: domain.OrderStatus(req.Status),
+		Reason: req.Reason,
+	})
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	resp := UpdateOrderStatusesResponse{
+		Results:   make([]OrderStatusResultResponse, len(output.Results)),
+		Succeeded: output.Succeeded,
+		Failed:    output.Failed,
+	}
+	for i, result := range output.Results {
+		item := OrderStatusResultResponse{ID: result.ID, Success: result.Err == nil, Changed: result.Changed}
+		if result.Err != nil {
+			appErr, ok := result.Err.(*errors.AppError)
+			if !ok {
+				appErr = errors.NewInternal("failed to update order", result.Err)
+			}
+			item.Error = appErr
+		} else {
+			item.Status = string(result.Order.Status)
+		}
+		resp.Results[i] = item
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, resp)
+}
+
+// ReopenOrder handles POST /admin/orders/:id/reopen
+func (h *HTTPHandler) ReopenOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		middleware.RespondError(c, errors.NewValidation("invalid order id", nil))
+		return
+	}
+
+	output, err := h.useCase.ReopenOrder(c.Request.Context(), application.ReopenOrderInput{
+		ID: uint(id),
+	})
+	if err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": OrderResponse{
-			ID:        output.Order.ID,
-			UserID:    output.Order.UserID,
-			Total:     output.Order.Total,
-			Status:    string(output.Order.Status),
-			CreatedAt: output.Order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
-		"trace_id": c.GetString(middleware.TraceIDKey),
+	middleware.RespondSuccess(c, http.StatusOK, OrderResponse{
+		ID:        output.Order.ID,
+		UserID:    output.Order.UserID,
+		Total:     output.Order.Total,
+		Status:    string(output.Order.Status),
+		CreatedAt: orderspb.FormatTime(output.Order.CreatedAt),
+		UpdatedAt: orderspb.FormatTime(output.Order.UpdatedAt),
 	})
 }
@@ -0,0 +1,169 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-micro/internal/orders/application"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/tenant"
+)
+
+// StaleOrderJob periodically cancels orders that stayed pending too long
+type StaleOrderJob struct {
+	useCase   *application.OrderUseCase
+	log       *logger.Logger
+	interval  time.Duration
+	threshold time.Duration
+	batchSize int
+}
+
+// NewStaleOrderJob creates a new stale order cleanup job
+func NewStaleOrderJob(useCase *application.OrderUseCase, log *logger.Logger, interval, threshold time.Duration, batchSize int) *StaleOrderJob {
+	return &StaleOrderJob{
+		useCase:   useCase,
+		log:       log,
+		interval:  interval,
+		threshold: threshold,
+		batchSize: batchSize,
+	}
+}
+
+// Run runs the job on a ticker until ctx is cancelled, returning once the
+// run in progress has finished. An interval <= 0 disables the job.
+func (j *StaleOrderJob) Run(ctx context.Context) error {
+	if j.interval <= 0 {
+		j.log.Info("stale order job disabled")
+		return nil
+	}
+
+	j.log.Info("stale order job started",
+		zap.Duration("interval", j.interval),
+		zap.Duration("threshold", j.threshold),
+	)
+
+	runEvery(ctx, j.interval, j.run)
+	return nil
+}
+
+func (j *StaleOrderJob) run(ctx context.Context, now time.Time) {
+	_, err := j.useCase.CancelStalePendingOrders(ctx, application.CancelStalePendingOrdersInput{
+		Now:       now,
+		Threshold: j.threshold,
+		BatchSize: j.batchSize,
+	})
+	if err != nil {
+		j.log.Error("stale order job failed", zap.Error(err))
+	}
+}
+
+// UserReconciliationJob periodically verifies or cancels orders created with
+// async user validation
+type UserReconciliationJob struct {
+	useCase   *application.OrderUseCase
+	log       *logger.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+// NewUserReconciliationJob creates a new user reconciliation job
+func NewUserReconciliationJob(useCase *application.OrderUseCase, log *logger.Logger, interval time.Duration, batchSize int) *UserReconciliationJob {
+	return &UserReconciliationJob{
+		useCase:   useCase,
+		log:       log,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run runs the job on a ticker until ctx is cancelled, returning once the
+// run in progress has finished. An interval <= 0 disables the job.
+func (j *UserReconciliationJob) Run(ctx context.Context) error {
+	if j.interval <= 0 {
+		j.log.Info("user reconciliation job disabled")
+		return nil
+	}
+
+	j.log.Info("user reconciliation job started",
+		zap.Duration("interval", j.interval),
+	)
+
+	runEvery(ctx, j.interval, j.run)
+	return nil
+}
+
+func (j *UserReconciliationJob) run(ctx context.Context, _ time.Time) {
+	_, err := j.useCase.ReconcileUnverifiedOrders(ctx, application.ReconcileUnverifiedOrdersInput{
+		BatchSize: j.batchSize,
+	})
+	if err != nil {
+		j.log.Error("user reconciliation job failed", zap.Error(err))
+	}
+}
+
+// OrderArchiveJob periodically moves old confirmed orders to the archive
+type OrderArchiveJob struct {
+	useCase   *application.OrderUseCase
+	log       *logger.Logger
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+}
+
+// NewOrderArchiveJob creates a new order archive job
+func NewOrderArchiveJob(useCase *application.OrderUseCase, log *logger.Logger, interval, retention time.Duration, batchSize int) *OrderArchiveJob {
+	return &OrderArchiveJob{
+		useCase:   useCase,
+		log:       log,
+		interval:  interval,
+		retention: retention,
+		batchSize: batchSize,
+	}
+}
+
+// Run runs the job on a ticker until ctx is cancelled, returning once the
+// batch in progress has finished. An interval <= 0 disables the job.
+func (j *OrderArchiveJob) Run(ctx context.Context) error {
+	if j.interval <= 0 {
+		j.log.Info("order archive job disabled")
+		return nil
+	}
+
+	j.log.Info("order archive job started",
+		zap.Duration("interval", j.interval),
+		zap.Duration("retention", j.retention),
+	)
+
+	runEvery(ctx, j.interval, j.run)
+	return nil
+}
+
+func (j *OrderArchiveJob) run(ctx context.Context, now time.Time) {
+	_, err := j.useCase.ArchiveConfirmedOrders(ctx, application.ArchiveConfirmedOrdersInput{
+		Now:       now,
+		Retention: j.retention,
+		BatchSize: j.batchSize,
+	})
+	if err != nil && ctx.Err() == nil {
+		j.log.Error("order archive job failed", zap.Error(err))
+	}
+}
+
+// runEvery calls fn on every tick of interval until ctx is cancelled. Jobs
+// sweep the orders of every tenant.
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context, now time.Time)) {
+	ctx = tenant.AllTenants(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			fn(ctx, now)
+		}
+	}
+}
@@ -2,32 +2,123 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"go-micro/internal/orders/domain"
 )
 
 // OrderRepository defines the interface for order persistence
 type OrderRepository interface {
-	// Create creates a new order
+	// Create creates a new order. If the user already has an order with the
+	// same idempotency key, nothing is created and it returns
+	// domain.ErrIdempotencyKeyReused.
 	Create(ctx context.Context, order *domain.Order) error
 
-	// GetByID retrieves an order by ID
+	// GetByID retrieves an order by ID. Soft-deleted orders are not found.
 	GetByID(ctx context.Context, id uint) (*domain.Order, error)
 
+	// GetByIDIncludingDeleted retrieves an order by ID, including soft-deleted ones
+	GetByIDIncludingDeleted(ctx context.Context, id uint) (*domain.Order, error)
+
 	// Update updates an existing order
 	Update(ctx context.Context, order *domain.Order) error
 
-	// Delete deletes an order by ID
+	// UpdateIfPending writes the order's status, cancellation time and user
+	// verification, only if the stored order is still pending, and reports
+	// whether it did. Unlike Update it leaves the other columns alone, so a
+	// change made since the order was read, such as a payment confirming it,
+	// is not overwritten.
+	UpdateIfPending(ctx context.Context, order *domain.Order) (bool, error)
+
+	// Delete soft-deletes an order by ID
 	Delete(ctx context.Context, id uint) error
 
-	// GetByUserID retrieves orders for a user
+	// GetByUserID retrieves orders for a user.
+	// It returns a non-nil empty slice when the user has no orders and a nil
+	// slice only together with a non-nil error.
 	GetByUserID(ctx context.Context, userID uint) ([]*domain.Order, error)
+
+	// GetStalePending retrieves up to limit pending orders created before cutoff, oldest first
+	GetStalePending(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Order, error)
+
+	// ArchiveConfirmed moves up to limit confirmed orders created before
+	// cutoff, oldest first, out of the orders table into the archive, and
+	// returns how many it moved. Each call is one transaction.
+	ArchiveConfirmed(ctx context.Context, cutoff time.Time, limit int) (int, error)
+
+	// ListArchived retrieves a page of archived orders, newest first,
+	// optionally filtered by user. Archived orders deleted with their user are
+	// left out.
+	ListArchived(ctx context.Context, filter OrderListFilter) ([]*domain.Order, int64, error)
+
+	// AnonymizeArchived applies a user deletion to the user's archived
+	// orders: they are anonymized and, when softDelete is set, also marked
+	// deleted. It returns how many archived orders it changed.
+	AnonymizeArchived(ctx context.Context, userID uint, now time.Time, softDelete bool) (int, error)
+
+	// GetUnverified retrieves up to limit pending orders whose user has not
+	// been verified yet, oldest first
+	GetUnverified(ctx context.Context, limit int) ([]*domain.Order, error)
+
+	// GetByStatus retrieves up to limit orders in status, oldest first,
+	// skipping the first offset. Like GetByUserID, no orders is a non-nil
+	// empty slice.
+	GetByStatus(ctx context.Context, status domain.OrderStatus, limit, offset int) ([]*domain.Order, error)
+
+	// GetByIDs retrieves the orders among ids in one query, in no particular
+	// order. Missing and soft-deleted orders are left out.
+	GetByIDs(ctx context.Context, ids []uint) ([]*domain.Order, error)
+
+	// GetByIdempotencyKey retrieves the order a user created with an
+	// idempotency key. Soft-deleted orders are not found.
+	GetByIdempotencyKey(ctx context.Context, userID uint, key string) (*domain.Order, error)
+
+	// StatsByUser counts and sums a user's orders per status. Statuses the
+	// user has no orders in are left out.
+	StatsByUser(ctx context.Context, userID uint) ([]OrderStats, error)
+
+	// CountByWindow counts the orders created at or after since, in any
+	// status, and sums the totals of those that weren't cancelled
+	CountByWindow(ctx context.Context, since time.Time) (count int64, revenue float64, err error)
+
+	// List retrieves a page of orders, newest first, and the total match count.
+	// Like GetByUserID, an empty page is a non-nil empty slice.
+	List(ctx context.Context, filter OrderListFilter) ([]*domain.Order, int64, error)
+
+	// Transaction runs fn with a repository bound to a single transaction,
+	// committed if fn returns nil and rolled back otherwise
+	Transaction(ctx context.Context, fn func(repo OrderRepository) error) error
+}
+
+// OrderStats summarizes the orders in one status
+type OrderStats struct {
+	Status domain.OrderStatus
+	Count  int64
+	Total  float64
+}
+
+// OrderListFilter describes filtering and pagination for order listings
+type OrderListFilter struct {
+	UserID   uint
+	Page     int
+	PageSize int
 }
 
 // EventPublisher defines the interface for publishing domain events
 type EventPublisher interface {
 	// PublishOrderCreated publishes an order created event
 	PublishOrderCreated(ctx context.Context, order *domain.Order) error
+
+	// PublishOrderConfirmed publishes an order confirmed event. Orders
+	// confirmed by a payment are not published again: the payment event
+	// already announces them.
+	PublishOrderConfirmed(ctx context.Context, order *domain.Order) error
+
+	// PublishOrderCancelled publishes an order cancelled event
+	PublishOrderCancelled(ctx context.Context, order *domain.Order, reason string) error
+
+	// PublishOrderReopened publishes an order reopened event
+	PublishOrderReopened(ctx context.Context, order *domain.Order) error
 }
 
 // UserClient defines the interface for user service communication
@@ -36,9 +127,52 @@ type UserClient interface {
 	GetUser(ctx context.Context, userID uint) (*UserInfo, error)
 }
 
+// UserReadModel is the local copy of users, built from UserCreated events.
+// It lags behind the users service, so a missing user is not proof that the
+// user doesn't exist.
+//
+// Events may be redelivered out of order, so writes are last-writer-wins on
+// UserInfo.Sequence, falling back to UserInfo.UpdatedAt for events without a
+// sequence (see events.CompareOrder). Sequences never go backwards within a
+// producer, so clock skew can only misorder changes published by different
+// users service instances less than the skew apart.
+type UserReadModel interface {
+	// Upsert stores or replaces a user unless the stored copy reflects a
+	// later event, and reports whether it was stored
+	Upsert(ctx context.Context, user *UserInfo) (bool, error)
+
+	// Exists reports whether the user is known locally
+	Exists(ctx context.Context, id uint) (bool, error)
+
+	// IsDeleted reports whether the user is known locally and marked deleted
+	IsDeleted(ctx context.Context, id uint) (bool, error)
+
+	// MarkProcessed records that the event with eventID has been applied and
+	// reports false when it already was. Call it within Transaction so the
+	// record commits or rolls back together with the event's changes.
+	MarkProcessed(ctx context.Context, eventID string) (bool, error)
+
+	// Transaction runs fn with a read-model bound to a single transaction,
+	// committing only if fn returns nil
+	Transaction(ctx context.Context, fn func(readModel UserReadModel) error) error
+}
+
 // UserInfo represents user information from the users service
 type UserInfo struct {
 	ID    uint
 	Name  string
 	Email string
+
+	// UpdatedAt is when the change this copy reflects happened (the event
+	// timestamp for the read-model); zero for users fetched from the service
+	UpdatedAt time.Time
+
+	// Sequence is the sequence of the event this copy reflects (see
+	// pkg/events); zero when unknown
+	Sequence uint64
+
+	// DeletedAt is set once the user has been deleted in the users service.
+	// The users service does not publish deletions yet, so only the
+	// read-model can carry it.
+	DeletedAt *time.Time
 }
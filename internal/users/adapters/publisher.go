@@ -11,16 +11,33 @@ import (
 
 // RabbitMQPublisher implements EventPublisher using RabbitMQ
 type RabbitMQPublisher struct {
-	publisher *rabbitmq.Publisher
-	log       *logger.Logger
+	publisher  *rabbitmq.Publisher
+	routingKey events.RoutingKeyFunc
+	log        *logger.Logger
+}
+
+// PublisherOption configures a RabbitMQPublisher
+type PublisherOption func(*RabbitMQPublisher)
+
+// WithRoutingKeyFunc sets how routing keys are computed from events.
+// The default publishes each event under its base routing key.
+func WithRoutingKeyFunc(fn events.RoutingKeyFunc) PublisherOption {
+	return func(p *RabbitMQPublisher) {
+		p.routingKey = fn
+	}
 }
 
 // NewRabbitMQPublisher creates a new RabbitMQ event publisher
-func NewRabbitMQPublisher(publisher *rabbitmq.Publisher, log *logger.Logger) *RabbitMQPublisher {
-	return &RabbitMQPublisher{
-		publisher: publisher,
-		log:       log,
+func NewRabbitMQPublisher(publisher *rabbitmq.Publisher, log *logger.Logger, opts ...PublisherOption) *RabbitMQPublisher {
+	p := &RabbitMQPublisher{
+		publisher:  publisher,
+		routingKey: events.DefaultRoutingKey,
+		log:        log,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	return p
 }
 
 // PublishUserCreated publishes a user created event
@@ -35,5 +52,5 @@ func (p *RabbitMQPublisher) PublishUserCreated(ctx context.Context, user *domain
 		traceID,
 	)
 
-	return p.publisher.Publish(ctx, events.RoutingKeyUserCreated, event)
+	return p.publisher.Publish(ctx, p.routingKey(events.RoutingKeyUserCreated, event), event)
 }
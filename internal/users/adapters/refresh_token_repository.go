@@ -0,0 +1,110 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go-micro/internal/users/domain"
+	"go-micro/pkg/db"
+	apperrors "go-micro/pkg/errors"
+)
+
+// RefreshTokenModel is the GORM model for refresh tokens
+type RefreshTokenModel struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"size:36;not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for GORM
+func (RefreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+// PostgresRefreshTokenRepository implements RefreshTokenRepository using PostgreSQL
+type PostgresRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresRefreshTokenRepository creates a new PostgreSQL refresh token repository
+func NewPostgresRefreshTokenRepository(db *gorm.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+// Migrate runs auto-migration for the refresh token model
+func (r *PostgresRefreshTokenRepository) Migrate() error {
+	return r.db.AutoMigrate(&RefreshTokenModel{})
+}
+
+// Create stores a new refresh token
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	model := &RefreshTokenModel{
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+	}
+
+	if result := r.db.WithContext(ctx).Create(model); result.Error != nil {
+		return db.NewError("failed to create refresh token", result.Error)
+	}
+
+	token.ID = model.ID
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var model RefreshTokenModel
+
+	result := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&model)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NewNotFound("refresh token", hash)
+		}
+		return nil, db.NewError("failed to get refresh token", result.Error)
+	}
+
+	return &domain.RefreshToken{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		FamilyID:  model.FamilyID,
+		TokenHash: model.TokenHash,
+		ExpiresAt: model.ExpiresAt,
+		UsedAt:    model.UsedAt,
+		RevokedAt: model.RevokedAt,
+		CreatedAt: model.CreatedAt,
+	}, nil
+}
+
+// MarkUsed sets used_at unless the token is already used or revoked; the
+// condition is part of the update so concurrent rotations can't both win
+func (r *PostgresRefreshTokenRepository) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, db.NewError("failed to mark refresh token used", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeFamily revokes every token of a family that isn't revoked yet
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&RefreshTokenModel{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return db.NewError("failed to revoke refresh tokens", result.Error)
+	}
+	return nil
+}
@@ -3,12 +3,17 @@ package adapters
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
 	"go-micro/internal/users/domain"
+	"go-micro/internal/users/ports"
+	"go-micro/pkg/db"
+	"go-micro/pkg/db/scopes"
 	apperrors "go-micro/pkg/errors"
+	"go-micro/pkg/tenant"
 )
 
 // UserModel is the GORM model for users (persistence layer)
@@ -18,6 +23,15 @@ type UserModel struct {
 	Email     string    `gorm:"size:255;uniqueIndex;not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	// PasswordHash is the bcrypt hash of the password; empty when unset
+	PasswordHash string `gorm:"size:60;not null;default:''"`
+	// Role defaults to "user" for existing rows as well as new ones
+	Role string `gorm:"size:20;not null;default:'user'"`
+
+	// TenantID is the tenant the user belongs to; existing rows and requests
+	// without a tenant use the empty one
+	TenantID string `gorm:"size:64;not null;default:'';index"`
 }
 
 // TableName returns the table name for GORM
@@ -43,14 +57,16 @@ func (r *PostgresUserRepository) Migrate() error {
 // Create creates a new user
 func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) error {
 	model := toModel(user)
+	model.TenantID = tenant.FromContext(ctx)
 
 	result := r.db.WithContext(ctx).Create(model)
 	if result.Error != nil {
-		return result.Error
+		return db.NewError("failed to insert user", result.Error)
 	}
 
 	// Update domain entity with generated ID
 	user.ID = model.ID
+	user.TenantID = model.TenantID
 	user.CreatedAt = model.CreatedAt
 	user.UpdatedAt = model.UpdatedAt
 
@@ -61,12 +77,12 @@ func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User)
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
 	var model UserModel
 
-	result := r.db.WithContext(ctx).First(&model, id)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).First(&model, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, domain.NewUserNotFound(id)
 		}
-		return nil, apperrors.NewInternal("failed to get user", result.Error)
+		return nil, db.NewError("failed to get user", result.Error)
 	}
 
 	return toDomain(&model), nil
@@ -76,35 +92,66 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id uint) (*domain.
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var model UserModel
 
-	result := r.db.WithContext(ctx).Where("email = ?", email).First(&model)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Where("email = ?", email).First(&model)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, apperrors.NewNotFound("user", email)
 		}
-		return nil, apperrors.NewInternal("failed to get user by email", result.Error)
+		return nil, db.NewError("failed to get user by email", result.Error)
 	}
 
 	return toDomain(&model), nil
 }
 
-// Update updates an existing user
-func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
-	model := toModel(user)
+// ExistsByID reports whether a user with the given ID exists
+func (r *PostgresUserRepository) ExistsByID(ctx context.Context, id uint) (bool, error) {
+	return exists(r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)), "id = ?", id)
+}
 
-	result := r.db.WithContext(ctx).Save(model)
+// ExistsByEmail reports whether a user with the given email exists in any
+// tenant: emails are unique across tenants, so one taken elsewhere is taken
+func (r *PostgresUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	return exists(r.db.WithContext(ctx), "email = ?", email)
+}
+
+// exists runs SELECT 1 ... LIMIT 1 for the condition, which spares loading
+// the row when only its existence matters
+func exists(tx *gorm.DB, query string, args ...interface{}) (bool, error) {
+	var found []int
+	result := tx.Model(&UserModel{}).
+		Select("1").Where(query, args...).Limit(1).
+		Find(&found)
 	if result.Error != nil {
-		return apperrors.NewInternal("failed to update user", result.Error)
+		return false, db.NewError("failed to check user existence", result.Error)
 	}
+	return len(found) > 0, nil
+}
 
-	user.UpdatedAt = model.UpdatedAt
+// Update updates an existing user, or returns not found if there is none
+func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
+	// Unlike Save, this never inserts: updating a missing user affects no
+	// rows. Select("*") writes zero values too; GORM sets updated_at on target.
+	target := &UserModel{}
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Model(target).
+		Where("id = ?", user.ID).
+		Select("*").Omit("id", "tenant_id", "created_at").
+		Updates(toModel(user))
+	if result.Error != nil {
+		return db.NewError("failed to update user", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewUserNotFound(user.ID)
+	}
+
+	user.UpdatedAt = target.UpdatedAt
 	return nil
 }
 
 // Delete deletes a user by ID
 func (r *PostgresUserRepository) Delete(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&UserModel{}, id)
+	result := r.db.WithContext(ctx).Scopes(scopes.Tenant(ctx)).Delete(&UserModel{}, id)
 	if result.Error != nil {
-		return apperrors.NewInternal("failed to delete user", result.Error)
+		return db.NewError("failed to delete user", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return domain.NewUserNotFound(id)
@@ -112,6 +159,66 @@ func (r *PostgresUserRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// userSortColumns maps allowed sort fields to their columns
+var userSortColumns = map[string]string{
+	"created_at": "created_at",
+	"name":       "name",
+}
+
+// List retrieves a filtered, sorted page of users and the total match count
+func (r *PostgresUserRepository) List(ctx context.Context, filter ports.UserListFilter) ([]*domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&UserModel{}).Scopes(
+		scopes.Tenant(ctx),
+		nameContains(filter.NameContains),
+		createdBetween(filter.CreatedFrom, filter.CreatedTo),
+	)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, db.NewError("failed to count users", err)
+	}
+
+	var models []UserModel
+	result := query.Scopes(
+		scopes.OrderBy(filter.SortBy, filter.SortDesc, userSortColumns, "created_at"),
+		scopes.Paginate(filter.Page, filter.PageSize),
+	).Find(&models)
+	if result.Error != nil {
+		return nil, 0, db.NewError("failed to list users", result.Error)
+	}
+
+	users := make([]*domain.User, len(models))
+	for i, model := range models {
+		users[i] = toDomain(&model)
+	}
+
+	return users, total, nil
+}
+
+// nameContains filters users whose name contains the given substring (case-insensitive)
+func nameContains(name string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if name == "" {
+			return db
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(name)
+		return db.Where("name ILIKE ?", "%"+escaped+"%")
+	}
+}
+
+// createdBetween filters users created within the optional range
+func createdBetween(from, to *time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if from != nil {
+			db = db.Where("created_at >= ?", *from)
+		}
+		if to != nil {
+			db = db.Where("created_at <= ?", *to)
+		}
+		return db
+	}
+}
+
 // toModel converts a domain entity to a GORM model
 func toModel(user *domain.User) *UserModel {
 	return &UserModel{
@@ -120,6 +227,9 @@ func toModel(user *domain.User) *UserModel {
 		Email:     user.Email,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+
+		PasswordHash: user.PasswordHash,
+		Role:         string(user.Role),
 	}
 }
 
@@ -131,5 +241,9 @@ func toDomain(model *UserModel) *domain.User {
 		Email:     model.Email,
 		CreatedAt: model.CreatedAt,
 		UpdatedAt: model.UpdatedAt,
+
+		PasswordHash: model.PasswordHash,
+		Role:         domain.Role(model.Role),
+		TenantID:     model.TenantID,
 	}
 }
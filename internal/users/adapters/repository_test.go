@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"go-micro/internal/users/domain"
+	"go-micro/pkg/errors"
+)
+
+// newDryRunDB returns a DB that builds statements without executing them, so
+// every write affects no rows and every query finds nothing; the SQL of the
+// last update or query is stored in sql
+func newDryRunDB(t *testing.T, sql *string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+	capture := func(tx *gorm.DB) { *sql = tx.Statement.SQL.String() }
+	if err := db.Callback().Update().After("gorm:update").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture_sql", capture); err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	return db
+}
+
+func TestPostgresUserRepository_Update_NotFound(t *testing.T) {
+	// Arrange
+	var sql string
+	repo := NewPostgresUserRepository(newDryRunDB(t, &sql))
+	user := &domain.User{ID: 42, Name: "Ada", Email: "ada@example.com", Role: domain.RoleUser}
+
+	// Act
+	err := repo.Update(context.Background(), user)
+
+	// Assert: the missing user is reported instead of being inserted
+	if !errors.Is(err, errors.CodeNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if !strings.HasPrefix(sql, `UPDATE "users"`) || !strings.Contains(sql, "WHERE id = $") {
+		t.Errorf("expected an UPDATE by id, got %s", sql)
+	}
+}
+
+func TestPostgresUserRepository_Exists(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  func(repo *PostgresUserRepository) (bool, error)
+		wantSQL string
+	}{
+		{
+			name: "by id",
+			exists: func(repo *PostgresUserRepository) (bool, error) {
+				return repo.ExistsByID(context.Background(), 42)
+			},
+			wantSQL: `SELECT 1 FROM "users" WHERE id = $1 AND tenant_id = $2 LIMIT 1`,
+		},
+		{
+			name: "by email",
+			exists: func(repo *PostgresUserRepository) (bool, error) {
+				return repo.ExistsByEmail(context.Background(), "ada@example.com")
+			},
+			wantSQL: `SELECT 1 FROM "users" WHERE email = $1 LIMIT 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var sql string
+			repo := NewPostgresUserRepository(newDryRunDB(t, &sql))
+
+			// Act
+			exists, err := tt.exists(repo)
+
+			// Assert: no row is loaded, and nothing found means false
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if exists {
+				t.Error("expected no user to exist")
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("expected %s, got %s", tt.wantSQL, sql)
+			}
+		})
+	}
+}
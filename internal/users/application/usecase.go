@@ -2,12 +2,15 @@ package application
 
 import (
 	"context"
+	"time"
 
 	"go-micro/internal/users/domain"
 	"go-micro/internal/users/ports"
 	"go-micro/pkg/errors"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/pagination"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -16,6 +19,13 @@ type UserUseCase struct {
 	repo      ports.UserRepository
 	publisher ports.EventPublisher
 	log       *logger.Logger
+
+	// emailPolicy restricts the email domains CreateUser accepts
+	emailPolicy domain.EmailDomainPolicy
+
+	// refreshTokens stores refresh tokens, valid for refreshTokenTTL
+	refreshTokens   ports.RefreshTokenRepository
+	refreshTokenTTL time.Duration
 }
 
 // NewUserUseCase creates a new user use case
@@ -27,10 +37,25 @@ func NewUserUseCase(repo ports.UserRepository, publisher ports.EventPublisher, l
 	}
 }
 
+// SetEmailDomainPolicy restricts the email domains new users may register
+// with. Existing users are not affected.
+func (uc *UserUseCase) SetEmailDomainPolicy(policy domain.EmailDomainPolicy) {
+	uc.emailPolicy = policy
+}
+
+// SetRefreshTokens enables the refresh token operations, storing tokens in
+// repo and issuing them valid for ttl
+func (uc *UserUseCase) SetRefreshTokens(repo ports.RefreshTokenRepository, ttl time.Duration) {
+	uc.refreshTokens = repo
+	uc.refreshTokenTTL = ttl
+}
+
 // CreateUserInput represents the input for creating a user
 type CreateUserInput struct {
 	Name  string
 	Email string
+	// Password is optional; users created without one can't log in
+	Password string
 }
 
 // CreateUserOutput represents the output of creating a user
@@ -46,18 +71,28 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, input CreateUserInput) (*
 		return nil, err
 	}
 
+	if input.Password != "" {
+		if err := user.SetPassword(input.Password); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.emailPolicy.Check(user.Email); err != nil {
+		return nil, err
+	}
+
 	// Check if email already exists
-	existing, err := uc.repo.GetByEmail(ctx, user.Email)
-	if err != nil && !errors.Is(err, errors.CodeNotFound) {
-		return nil, errors.NewInternal("failed to check email existence", err)
+	exists, err := uc.repo.ExistsByEmail(ctx, user.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check email existence")
 	}
-	if existing != nil {
+	if exists {
 		return nil, domain.ErrEmailExists
 	}
 
 	// Create user in repository
 	if err := uc.repo.Create(ctx, user); err != nil {
-		return nil, errors.NewInternal("failed to create user", err)
+		return nil, errors.Wrap(err, "failed to create user")
 	}
 
 	// Publish event (async, don't fail on error)
@@ -78,6 +113,163 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, input CreateUserInput) (*
 	return &CreateUserOutput{User: user}, nil
 }
 
+// AuthenticateInput represents the input for checking a user's credentials
+type AuthenticateInput struct {
+	Email    string
+	Password string
+}
+
+// AuthenticateOutput represents the output of checking a user's credentials
+type AuthenticateOutput struct {
+	User *domain.User
+}
+
+// Authenticate returns the user with input.Email when input.Password matches
+// their password. An unknown email, a user without a password and a wrong
+// password all fail with the same ErrInvalidCredentials.
+func (uc *UserUseCase) Authenticate(ctx context.Context, input AuthenticateInput) (*AuthenticateOutput, error) {
+	user, err := uc.repo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if errors.Is(err, errors.CodeNotFound) {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !user.CheckPassword(input.Password) {
+		uc.log.WithContext(ctx).Info("failed login attempt",
+			zap.Uint("user_id", user.ID),
+		)
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return &AuthenticateOutput{User: user}, nil
+}
+
+// IssueRefreshTokenInput represents the input for issuing a refresh token
+type IssueRefreshTokenInput struct {
+	UserID uint
+}
+
+// RotateRefreshTokenInput represents the input for rotating a refresh token
+type RotateRefreshTokenInput struct {
+	Token string
+}
+
+// RevokeRefreshTokenInput represents the input for revoking a refresh token
+type RevokeRefreshTokenInput struct {
+	Token string
+}
+
+// RefreshTokenOutput represents a newly issued refresh token and its user
+type RefreshTokenOutput struct {
+	User      *domain.User
+	Token     string
+	ExpiresAt time.Time
+}
+
+// IssueRefreshToken starts a new token family for a user, typically on login
+func (uc *UserUseCase) IssueRefreshToken(ctx context.Context, input IssueRefreshTokenInput) (*RefreshTokenOutput, error) {
+	user, err := uc.repo.GetByID(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.issueRefreshToken(ctx, user, uuid.NewString())
+}
+
+// RotateRefreshToken uses up a refresh token and issues its successor in the
+// same family. Presenting a token that was already used means it was copied:
+// the whole family is revoked, so neither the client nor whoever replayed it
+// can continue without logging in again.
+func (uc *UserUseCase) RotateRefreshToken(ctx context.Context, input RotateRefreshTokenInput) (*RefreshTokenOutput, error) {
+	stored, err := uc.findRefreshToken(ctx, input.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return nil, uc.revokeReusedFamily(ctx, stored)
+	}
+
+	// A concurrent rotation may have used the token since it was read
+	marked, err := uc.refreshTokens.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, uc.revokeReusedFamily(ctx, stored)
+	}
+
+	user, err := uc.repo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, errors.CodeNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	return uc.issueRefreshToken(ctx, user, stored.FamilyID)
+}
+
+// RevokeRefreshToken revokes the family of a refresh token, typically on
+// logout. Unknown tokens are ignored so logging out twice succeeds.
+func (uc *UserUseCase) RevokeRefreshToken(ctx context.Context, input RevokeRefreshTokenInput) error {
+	stored, err := uc.findRefreshToken(ctx, input.Token)
+	if err != nil {
+		if err == domain.ErrInvalidRefreshToken {
+			return nil
+		}
+		return err
+	}
+
+	return uc.refreshTokens.RevokeFamily(ctx, stored.FamilyID, time.Now())
+}
+
+// issueRefreshToken stores a new token for user in familyID
+func (uc *UserUseCase) issueRefreshToken(ctx context.Context, user *domain.User, familyID string) (*RefreshTokenOutput, error) {
+	token, secret, err := domain.NewRefreshToken(user.ID, familyID, uc.refreshTokenTTL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.refreshTokens.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &RefreshTokenOutput{User: user, Token: secret, ExpiresAt: token.ExpiresAt}, nil
+}
+
+// findRefreshToken looks up the stored record of a token value
+func (uc *UserUseCase) findRefreshToken(ctx context.Context, secret string) (*domain.RefreshToken, error) {
+	if secret == "" {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	stored, err := uc.refreshTokens.GetByHash(ctx, domain.HashRefreshToken(secret))
+	if err != nil {
+		if errors.Is(err, errors.CodeNotFound) {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	return stored, nil
+}
+
+// revokeReusedFamily revokes the family of a token presented after it was
+// used, and returns the error to report to the caller
+func (uc *UserUseCase) revokeReusedFamily(ctx context.Context, stored *domain.RefreshToken) error {
+	uc.log.WithContext(ctx).Warn("refresh token reuse detected, revoking its family",
+		zap.Uint("user_id", stored.UserID),
+		zap.String("family_id", stored.FamilyID),
+	)
+	if err := uc.refreshTokens.RevokeFamily(ctx, stored.FamilyID, time.Now()); err != nil {
+		return err
+	}
+	return domain.ErrInvalidRefreshToken
+}
+
 // GetUserInput represents the input for getting a user
 type GetUserInput struct {
 	ID uint
@@ -97,3 +289,71 @@ func (uc *UserUseCase) GetUser(ctx context.Context, input GetUserInput) (*GetUse
 
 	return &GetUserOutput{User: user}, nil
 }
+
+// Pagination limits for user listings
+const (
+	DefaultPageSize = pagination.DefaultPageSize
+	MaxPageSize     = pagination.MaxPageSize
+)
+
+// allowedUserSortFields lists the fields users can be sorted by
+var allowedUserSortFields = map[string]bool{
+	"created_at": true,
+	"name":       true,
+}
+
+// ListUsersInput represents the input for listing users
+type ListUsersInput struct {
+	NameContains string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	SortBy       string
+	SortDesc     bool
+	Page         int
+	PageSize     int
+}
+
+// ListUsersOutput represents the output of listing users
+type ListUsersOutput struct {
+	Users    []*domain.User
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// ListUsers retrieves a filtered, sorted page of users
+func (uc *UserUseCase) ListUsers(ctx context.Context, input ListUsersInput) (*ListUsersOutput, error) {
+	if input.SortBy == "" {
+		input.SortBy = "created_at"
+	}
+	if !allowedUserSortFields[input.SortBy] {
+		return nil, domain.ErrInvalidSort
+	}
+	if input.CreatedFrom != nil && input.CreatedTo != nil && input.CreatedFrom.After(*input.CreatedTo) {
+		return nil, domain.ErrInvalidRange
+	}
+	if input.Page < 1 {
+		input.Page = 1
+	}
+	input.PageSize = pagination.NormalizePageSize(input.PageSize)
+
+	users, total, err := uc.repo.List(ctx, ports.UserListFilter{
+		NameContains: input.NameContains,
+		CreatedFrom:  input.CreatedFrom,
+		CreatedTo:    input.CreatedTo,
+		SortBy:       input.SortBy,
+		SortDesc:     input.SortDesc,
+		Page:         input.Page,
+		PageSize:     input.PageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListUsersOutput{
+		Users:    users,
+		Total:    total,
+		Page:     input.Page,
+		PageSize: input.PageSize,
+	}, nil
+}
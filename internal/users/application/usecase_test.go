@@ -3,8 +3,10 @@ package application
 import (
 	"context"
 	"testing"
+	"time"
 
 	"go-micro/internal/users/domain"
+	"go-micro/internal/users/ports"
 	"go-micro/pkg/errors"
 	"go-micro/pkg/logger"
 )
@@ -14,6 +16,7 @@ type MockUserRepository struct {
 	users     map[uint]*domain.User
 	byEmail   map[string]*domain.User
 	nextID    uint
+	lastList  ports.UserListFilter
 	createFn  func(ctx context.Context, user *domain.User) error
 	getByIDFn func(ctx context.Context, id uint) (*domain.User, error)
 }
@@ -56,6 +59,16 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*dom
 	return user, nil
 }
 
+func (m *MockUserRepository) ExistsByID(ctx context.Context, id uint) (bool, error) {
+	_, ok := m.users[id]
+	return ok, nil
+}
+
+func (m *MockUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, ok := m.byEmail[email]
+	return ok, nil
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
 	m.users[user.ID] = user
 	return nil
@@ -66,6 +79,54 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+func (m *MockUserRepository) List(ctx context.Context, filter ports.UserListFilter) ([]*domain.User, int64, error) {
+	m.lastList = filter
+	var result []*domain.User
+	for _, user := range m.users {
+		result = append(result, user)
+	}
+	return result, int64(len(result)), nil
+}
+
+// MockRefreshTokenRepository is a mock implementation of RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	tokens []*domain.RefreshToken
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	token.ID = uint(len(m.tokens) + 1)
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == hash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, errors.NewNotFound("refresh token", hash)
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	token := m.tokens[id-1]
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &at
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
 // MockEventPublisher is a mock implementation of EventPublisher
 type MockEventPublisher struct {
 	events []interface{}
@@ -171,6 +232,93 @@ func TestCreateUser_DuplicateEmail(t *testing.T) {
 	}
 }
 
+func TestCreateUser_WithPassword(t *testing.T) {
+	// Arrange
+	repo := NewMockUserRepository()
+	useCase := NewUserUseCase(repo, &MockEventPublisher{}, logger.New("test", "debug"))
+
+	// Act
+	output, err := useCase.CreateUser(context.Background(), CreateUserInput{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Password: "correct horse",
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	stored := repo.users[output.User.ID]
+	if stored.PasswordHash == "" || stored.PasswordHash == "correct horse" {
+		t.Errorf("expected the password to be stored hashed, got %q", stored.PasswordHash)
+	}
+}
+
+func TestCreateUser_PasswordTooShort(t *testing.T) {
+	// Arrange
+	repo := NewMockUserRepository()
+	useCase := NewUserUseCase(repo, &MockEventPublisher{}, logger.New("test", "debug"))
+
+	// Act
+	_, err := useCase.CreateUser(context.Background(), CreateUserInput{
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Password: "short",
+	})
+
+	// Assert
+	if !errors.Is(err, errors.CodeValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	if len(repo.users) != 0 {
+		t.Errorf("expected no user to be created, got %d", len(repo.users))
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	useCase := NewUserUseCase(NewMockUserRepository(), &MockEventPublisher{}, logger.New("test", "debug"))
+	created, _ := useCase.CreateUser(context.Background(), CreateUserInput{
+		Name: "John Doe", Email: "john@example.com", Password: "correct horse",
+	})
+	_, _ = useCase.CreateUser(context.Background(), CreateUserInput{
+		Name: "Jane Doe", Email: "jane@example.com",
+	})
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  bool
+	}{
+		{"valid credentials", "john@example.com", "correct horse", false},
+		{"wrong password", "john@example.com", "wrong horse", true},
+		{"unknown email", "nobody@example.com", "correct horse", true},
+		{"user without password", "jane@example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := useCase.Authenticate(context.Background(), AuthenticateInput{
+				Email:    tt.email,
+				Password: tt.password,
+			})
+
+			if tt.wantErr {
+				if err != domain.ErrInvalidCredentials {
+					t.Errorf("expected ErrInvalidCredentials, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if output.User.ID != created.User.ID {
+				t.Errorf("expected user %d, got %d", created.User.ID, output.User.ID)
+			}
+		})
+	}
+}
+
 func TestGetUser_Success(t *testing.T) {
 	// Arrange
 	repo := NewMockUserRepository()
@@ -219,3 +367,199 @@ func TestGetUser_NotFound(t *testing.T) {
 		t.Errorf("expected not found error, got %v", err)
 	}
 }
+
+func TestListUsers_CapsPageSize(t *testing.T) {
+	// Arrange
+	repo := NewMockUserRepository()
+	publisher := &MockEventPublisher{}
+	log := logger.New("test", "debug")
+	useCase := NewUserUseCase(repo, publisher, log)
+
+	// Act
+	output, err := useCase.ListUsers(context.Background(), ListUsersInput{PageSize: 1000})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if output.PageSize != MaxPageSize || repo.lastList.PageSize != MaxPageSize {
+		t.Errorf("expected page size capped to %d, got %d", MaxPageSize, repo.lastList.PageSize)
+	}
+
+	if repo.lastList.SortBy != "created_at" {
+		t.Errorf("expected default sort created_at, got %s", repo.lastList.SortBy)
+	}
+}
+
+func TestListUsers_InvalidSort(t *testing.T) {
+	// Arrange
+	repo := NewMockUserRepository()
+	publisher := &MockEventPublisher{}
+	log := logger.New("test", "debug")
+	useCase := NewUserUseCase(repo, publisher, log)
+
+	// Act
+	_, err := useCase.ListUsers(context.Background(), ListUsersInput{SortBy: "email; DROP TABLE users"})
+
+	// Assert
+	if !errors.Is(err, errors.CodeValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestCreateUser_EmailDomainPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{"allowed domain", "john@example.com", false},
+		{"blocked domain", "john@blocked.example.com", true},
+		{"domain outside allowlist", "john@gmail.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			repo := NewMockUserRepository()
+			publisher := &MockEventPublisher{}
+			log := logger.New("test", "debug")
+			useCase := NewUserUseCase(repo, publisher, log)
+			useCase.SetEmailDomainPolicy(domain.EmailDomainPolicy{
+				Allow: []string{"example.com"},
+				Block: []string{"blocked.example.com"},
+			})
+
+			// Act
+			_, err := useCase.CreateUser(context.Background(), CreateUserInput{Name: "John Doe", Email: tt.email})
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, errors.CodeValidation) {
+					t.Errorf("expected validation error, got %v", err)
+				}
+				if len(repo.users) != 0 {
+					t.Errorf("expected no users to be created, got %d", len(repo.users))
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// newRefreshTokenUseCase returns a use case with one user, ID 1, and
+// refresh tokens valid for ttl
+func newRefreshTokenUseCase(t *testing.T, ttl time.Duration) (*UserUseCase, *MockRefreshTokenRepository) {
+	t.Helper()
+
+	tokens := &MockRefreshTokenRepository{}
+	useCase := NewUserUseCase(NewMockUserRepository(), &MockEventPublisher{}, logger.New("test", "debug"))
+	useCase.SetRefreshTokens(tokens, ttl)
+	if _, err := useCase.CreateUser(context.Background(), CreateUserInput{Name: "John Doe", Email: "john@example.com"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return useCase, tokens
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	// Arrange
+	useCase, tokens := newRefreshTokenUseCase(t, time.Hour)
+	issued, err := useCase.IssueRefreshToken(context.Background(), IssueRefreshTokenInput{UserID: 1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Act
+	rotated, err := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: issued.Token})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rotated.Token == issued.Token || rotated.User.ID != 1 {
+		t.Errorf("expected a new token for user 1, got %+v", rotated)
+	}
+	if len(tokens.tokens) != 2 || tokens.tokens[0].FamilyID != tokens.tokens[1].FamilyID {
+		t.Fatalf("expected the new token in the same family, got %+v", tokens.tokens)
+	}
+	if tokens.tokens[0].UsedAt == nil {
+		t.Error("expected the presented token to be used up")
+	}
+	if tokens.tokens[0].TokenHash == issued.Token {
+		t.Error("expected only the hash of the token to be stored")
+	}
+}
+
+func TestRotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	// Arrange
+	useCase, tokens := newRefreshTokenUseCase(t, time.Hour)
+	issued, _ := useCase.IssueRefreshToken(context.Background(), IssueRefreshTokenInput{UserID: 1})
+	rotated, _ := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: issued.Token})
+	other, _ := useCase.IssueRefreshToken(context.Background(), IssueRefreshTokenInput{UserID: 1})
+
+	// Act: the used token is replayed
+	_, reuseErr := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: issued.Token})
+	_, rotatedErr := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: rotated.Token})
+	_, otherErr := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: other.Token})
+
+	// Assert
+	if reuseErr != domain.ErrInvalidRefreshToken {
+		t.Errorf("expected ErrInvalidRefreshToken for the replayed token, got %v", reuseErr)
+	}
+	if rotatedErr != domain.ErrInvalidRefreshToken {
+		t.Errorf("expected the rest of the family to be revoked, got %v", rotatedErr)
+	}
+	if otherErr != nil {
+		t.Errorf("expected other families to be unaffected, got %v", otherErr)
+	}
+	if tokens.tokens[1].RevokedAt == nil {
+		t.Error("expected the rotated token to be revoked")
+	}
+}
+
+func TestRotateRefreshToken_Invalid(t *testing.T) {
+	useCase, _ := newRefreshTokenUseCase(t, -time.Minute)
+	expired, _ := useCase.IssueRefreshToken(context.Background(), IssueRefreshTokenInput{UserID: 1})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"unknown", "not-a-token"},
+		{"expired", expired.Token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: tt.token})
+
+			if err != domain.ErrInvalidRefreshToken {
+				t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	// Arrange
+	useCase, _ := newRefreshTokenUseCase(t, time.Hour)
+	issued, _ := useCase.IssueRefreshToken(context.Background(), IssueRefreshTokenInput{UserID: 1})
+
+	// Act
+	err := useCase.RevokeRefreshToken(context.Background(), RevokeRefreshTokenInput{Token: issued.Token})
+	againErr := useCase.RevokeRefreshToken(context.Background(), RevokeRefreshTokenInput{Token: "not-a-token"})
+	_, rotateErr := useCase.RotateRefreshToken(context.Background(), RotateRefreshTokenInput{Token: issued.Token})
+
+	// Assert
+	if err != nil || againErr != nil {
+		t.Fatalf("expected logout to succeed, got %v and %v", err, againErr)
+	}
+	if rotateErr != domain.ErrInvalidRefreshToken {
+		t.Errorf("expected the revoked token to be rejected, got %v", rotateErr)
+	}
+}
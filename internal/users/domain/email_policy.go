@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"strings"
+
+	"go-micro/pkg/errors"
+)
+
+// EmailDomainPolicy restricts which email domains may register. A domain
+// matches an entry when it equals it or is a subdomain of it. The zero value
+// allows every domain.
+type EmailDomainPolicy struct {
+	// Allow, when non-empty, is the only set of domains accepted
+	Allow []string
+	// Block lists domains that are always rejected, even if allowed
+	Block []string
+}
+
+// Check returns a validation error when email's domain is not permitted
+func (p EmailDomainPolicy) Check(email string) error {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ErrEmailInvalid
+	}
+	domain := strings.ToLower(email[at+1:])
+
+	if matchesDomain(domain, p.Block) {
+		return errors.NewValidation("email domain is not allowed", map[string]string{"domain": domain})
+	}
+	if len(p.Allow) > 0 && !matchesDomain(domain, p.Allow) {
+		return errors.NewValidation("email domain is not in the list of accepted domains", map[string]string{"domain": domain})
+	}
+	return nil
+}
+
+// matchesDomain reports whether domain equals, or is a subdomain of, any entry
+func matchesDomain(domain string, entries []string) bool {
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if domain == entry || strings.HasSuffix(domain, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
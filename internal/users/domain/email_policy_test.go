@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+
+	"go-micro/pkg/errors"
+)
+
+func TestEmailDomainPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EmailDomainPolicy
+		email   string
+		wantErr bool
+	}{
+		{"disabled", EmailDomainPolicy{}, "john@gmail.com", false},
+		{"blocked", EmailDomainPolicy{Block: []string{"gmail.com"}}, "john@gmail.com", true},
+		{"blocked case-insensitive", EmailDomainPolicy{Block: []string{"Gmail.com"}}, "john@GMAIL.COM", true},
+		{"blocked subdomain", EmailDomainPolicy{Block: []string{"example.com"}}, "john@mail.example.com", true},
+		{"neutral with blocklist", EmailDomainPolicy{Block: []string{"gmail.com"}}, "john@example.com", false},
+		{"suffix is not a subdomain", EmailDomainPolicy{Block: []string{"mail.com"}}, "john@gmail.com", false},
+		{"allowed", EmailDomainPolicy{Allow: []string{"example.com"}}, "john@example.com", false},
+		{"not in allowlist", EmailDomainPolicy{Allow: []string{"example.com"}}, "john@gmail.com", true},
+		{"blocklist wins over allowlist", EmailDomainPolicy{Allow: []string{"example.com"}, Block: []string{"spam.example.com"}}, "john@spam.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.email)
+			if tt.wantErr {
+				if !errors.Is(err, errors.CodeValidation) {
+					t.Errorf("expected validation error for %s, got %v", tt.email, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected no error for %s, got %v", tt.email, err)
+			}
+		})
+	}
+}
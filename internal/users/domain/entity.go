@@ -2,27 +2,49 @@ package domain
 
 import (
 	"regexp"
+	"strings"
 	"time"
 )
 
 // User represents the user domain entity
 type User struct {
-	ID        uint
-	Name      string
-	Email     string
+	ID    uint
+	Name  string
+	Email string
+	// PasswordHash is the bcrypt hash of the user's password; empty for
+	// users without one, who can't log in. Never expose it.
+	PasswordHash string
+	// Role decides what the user is authorized to do
+	Role Role
+	// TenantID is the tenant the user belongs to, empty for the default one
+	TenantID  string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+// Role is a user's authorization level
+type Role string
+
+// User roles
+const (
+	// RoleUser is the role of every new user
+	RoleUser Role = "user"
+	// RoleAdmin may use the admin endpoints
+	RoleAdmin Role = "admin"
+)
+
 // EmailRegex is the pattern for validating emails
 var EmailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 
-// Validate validates the user entity
+// Validate validates the user entity. The name is checked without
+// surrounding whitespace, so a blank name is reported as missing rather
+// than too short.
 func (u *User) Validate() error {
-	if u.Name == "" {
+	name := strings.TrimSpace(u.Name)
+	if name == "" {
 		return ErrNameRequired
 	}
-	if len(u.Name) < 2 || len(u.Name) > 100 {
+	if len(name) < 2 || len(name) > 100 {
 		return ErrNameLength
 	}
 	if u.Email == "" {
@@ -39,6 +61,7 @@ func NewUser(name, email string) (*User, error) {
 	user := &User{
 		Name:      name,
 		Email:     email,
+		Role:      RoleUser,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -0,0 +1,46 @@
+package domain
+
+import "testing"
+
+func TestUser_Validate_Name(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"valid", "John Doe", nil},
+		{"empty", "", ErrNameRequired},
+		{"whitespace only", "   \t ", ErrNameRequired},
+		{"too short", "J", ErrNameLength},
+		{"too short after trimming", "  J  ", ErrNameLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &User{Name: tt.input, Email: "john@example.com"}
+
+			err := user.Validate()
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err != tt.wantErr {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewUser_DefaultRole(t *testing.T) {
+	user, err := NewUser("John Doe", "john@example.com")
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.Role != RoleUser {
+		t.Errorf("expected role %q, got %q", RoleUser, user.Role)
+	}
+}
@@ -10,6 +10,12 @@ var (
 	ErrEmailInvalid  = errors.NewValidation("email format is invalid", nil)
 	ErrEmailExists   = errors.NewConflict("email already exists")
 	ErrUserNotFound  = errors.NewNotFound("user", "unknown")
+	ErrInvalidSort   = errors.NewValidation("sort must be one of: created_at, name", nil)
+	ErrInvalidRange  = errors.NewValidation("created_from must be before created_to", nil)
+
+	ErrPasswordLength      = errors.NewValidation("password must be between 8 and 72 characters", nil)
+	ErrInvalidCredentials  = errors.NewUnauthorized("invalid email or password")
+	ErrInvalidRefreshToken = errors.NewUnauthorized("invalid refresh token")
 )
 
 // NewUserNotFound creates a not found error with the user ID
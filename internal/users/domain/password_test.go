@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUser_SetPassword(t *testing.T) {
+	// Arrange
+	user := &User{Name: "John Doe", Email: "john@example.com"}
+
+	// Act
+	err := user.SetPassword("correct horse")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.PasswordHash == "" || strings.Contains(user.PasswordHash, "correct horse") {
+		t.Fatalf("expected a hash of the password, got %q", user.PasswordHash)
+	}
+	if !user.CheckPassword("correct horse") {
+		t.Error("expected the password to match")
+	}
+	if user.CheckPassword("wrong horse") {
+		t.Error("expected a different password not to match")
+	}
+}
+
+func TestUser_SetPassword_Length(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"too short", strings.Repeat("x", MinPasswordLength-1)},
+		{"too long", strings.Repeat("x", MaxPasswordLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &User{}
+
+			err := user.SetPassword(tt.password)
+
+			if err != ErrPasswordLength {
+				t.Errorf("expected ErrPasswordLength, got %v", err)
+			}
+			if user.PasswordHash != "" {
+				t.Errorf("expected no hash to be stored, got %q", user.PasswordHash)
+			}
+		})
+	}
+}
+
+func TestUser_CheckPassword_WithoutPassword(t *testing.T) {
+	user := &User{}
+
+	if user.CheckPassword("") {
+		t.Error("expected a user without a password never to match")
+	}
+}
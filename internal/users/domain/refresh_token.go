@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"go-micro/pkg/errors"
+)
+
+// refreshTokenBytes is the amount of randomness in a refresh token
+const refreshTokenBytes = 32
+
+// RefreshToken is a server-side record of a refresh token. Only the hash of
+// the token is stored. Every rotation uses up the presented token and issues
+// a new one in the same family, so replaying a used token reveals that it
+// leaked and the whole family is revoked.
+type RefreshToken struct {
+	ID        uint
+	UserID    uint
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	// UsedAt is when the token was rotated; nil while it is current
+	UsedAt *time.Time
+	// RevokedAt is when the token's family was revoked; nil while active
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewRefreshToken creates a token for userID in familyID, valid for ttl from
+// now, and returns it with the secret value to hand to the client
+func NewRefreshToken(userID uint, familyID string, ttl time.Duration, now time.Time) (*RefreshToken, string, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", errors.NewInternal("failed to generate refresh token", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+
+	return &RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: HashRefreshToken(secret),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, secret, nil
+}
+
+// HashRefreshToken returns the stored form of a refresh token
+func HashRefreshToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsActive reports whether the token can still be used at now
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -5,6 +5,8 @@ import (
 
 	userspb "go-micro/api/gen/users/v1"
 	"go-micro/internal/users/application"
+	"go-micro/internal/users/domain"
+	"go-micro/pkg/pagination"
 )
 
 // GRPCServer implements the gRPC UserServiceServer
@@ -27,28 +29,119 @@ func (s *GRPCServer) GetUser(ctx context.Context, req *userspb.GetUserRequest) (
 		return nil, err
 	}
 
-	return &userspb.UserResponse{
-		Id:        uint64(output.User.ID),
-		Name:      output.User.Name,
-		Email:     output.User.Email,
-		CreatedAt: output.User.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}, nil
+	return toUserResponse(output.User), nil
 }
 
 // CreateUser implements UserServiceServer.CreateUser
 func (s *GRPCServer) CreateUser(ctx context.Context, req *userspb.CreateUserRequest) (*userspb.UserResponse, error) {
 	output, err := s.useCase.CreateUser(ctx, application.CreateUserInput{
-		Name:  req.GetName(),
-		Email: req.GetEmail(),
+		Name:     req.GetName(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &userspb.UserResponse{
-		Id:        uint64(output.User.ID),
-		Name:      output.User.Name,
-		Email:     output.User.Email,
-		CreatedAt: output.User.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	return toUserResponse(output.User), nil
+}
+
+// ListUsers implements UserServiceServer.ListUsers
+func (s *GRPCServer) ListUsers(ctx context.Context, req *userspb.ListUsersRequest) (*userspb.ListUsersResponse, error) {
+	page, err := pagination.DecodeToken(req.GetPage().GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.useCase.ListUsers(ctx, application.ListUsersInput{
+		Page:     page,
+		PageSize: int(req.GetPage().GetPageSize()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*userspb.UserResponse, len(output.Users))
+	for i, user := range output.Users {
+		users[i] = toUserResponse(user)
+	}
+
+	return &userspb.ListUsersResponse{
+		Users: users,
+		Page: &userspb.PageInfo{
+			Total:         output.Total,
+			NextPageToken: pagination.NextToken(output.Page, output.PageSize, output.Total),
+		},
 	}, nil
 }
+
+// VerifyCredentials implements UserServiceServer.VerifyCredentials
+func (s *GRPCServer) VerifyCredentials(ctx context.Context, req *userspb.VerifyCredentialsRequest) (*userspb.UserResponse, error) {
+	output, err := s.useCase.Authenticate(ctx, application.AuthenticateInput{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserResponse(output.User), nil
+}
+
+// IssueRefreshToken implements UserServiceServer.IssueRefreshToken
+func (s *GRPCServer) IssueRefreshToken(ctx context.Context, req *userspb.IssueRefreshTokenRequest) (*userspb.RefreshTokenResponse, error) {
+	output, err := s.useCase.IssueRefreshToken(ctx, application.IssueRefreshTokenInput{
+		UserID: uint(req.GetUserId()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toRefreshTokenResponse(output), nil
+}
+
+// RotateRefreshToken implements UserServiceServer.RotateRefreshToken
+func (s *GRPCServer) RotateRefreshToken(ctx context.Context, req *userspb.RotateRefreshTokenRequest) (*userspb.RefreshTokenResponse, error) {
+	output, err := s.useCase.RotateRefreshToken(ctx, application.RotateRefreshTokenInput{
+		Token: req.GetRefreshToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toRefreshTokenResponse(output), nil
+}
+
+// RevokeRefreshToken implements UserServiceServer.RevokeRefreshToken
+func (s *GRPCServer) RevokeRefreshToken(ctx context.Context, req *userspb.RevokeRefreshTokenRequest) (*userspb.RevokeRefreshTokenResponse, error) {
+	err := s.useCase.RevokeRefreshToken(ctx, application.RevokeRefreshTokenInput{
+		Token: req.GetRefreshToken(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &userspb.RevokeRefreshTokenResponse{}, nil
+}
+
+// toRefreshTokenResponse converts an issued refresh token to its gRPC representation
+func toRefreshTokenResponse(output *application.RefreshTokenOutput) *userspb.RefreshTokenResponse {
+	return &userspb.RefreshTokenResponse{
+		RefreshToken: output.Token,
+		ExpiresAt:    userspb.FormatTime(output.ExpiresAt),
+		User:         toUserResponse(output.User),
+	}
+}
+
+// toUserResponse converts a domain user to its gRPC representation
+func toUserResponse(user *domain.User) *userspb.UserResponse {
+	return &userspb.UserResponse{
+		Id:        uint64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: userspb.FormatTime(user.CreatedAt),
+		UpdatedAt: userspb.FormatTime(user.UpdatedAt),
+		Role:      string(user.Role),
+		TenantId:  user.TenantID,
+	}
+}
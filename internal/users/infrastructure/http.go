@@ -3,9 +3,11 @@ package infrastructure
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	userspb "go-micro/api/gen/users/v1"
 	"go-micro/internal/users/application"
 	"go-micro/pkg/errors"
 	"go-micro/pkg/middleware"
@@ -13,27 +15,38 @@ import (
 
 // HTTPHandler handles HTTP requests for users
 type HTTPHandler struct {
-	useCase *application.UserUseCase
+	useCase     *application.UserUseCase
+	adminAPIKey string
 }
 
 // NewHTTPHandler creates a new HTTP handler
-func NewHTTPHandler(useCase *application.UserUseCase) *HTTPHandler {
-	return &HTTPHandler{useCase: useCase}
+func NewHTTPHandler(useCase *application.UserUseCase, adminAPIKey string) *HTTPHandler {
+	return &HTTPHandler{useCase: useCase, adminAPIKey: adminAPIKey}
 }
 
 // RegisterRoutes registers the user routes
 func (h *HTTPHandler) RegisterRoutes(r *gin.RouterGroup) {
 	users := r.Group("/users")
+	middleware.CORSGroup(users, http.MethodGet, http.MethodPost)
 	{
 		users.POST("", h.CreateUser)
 		users.GET("/:id", h.GetUser)
 	}
+
+	admin := r.Group("/admin")
+	middleware.CORSGroup(admin, http.MethodGet)
+	admin.Use(middleware.AdminAuth(h.adminAPIKey))
+	{
+		admin.GET("/users", h.ListUsers)
+	}
 }
 
 // CreateUserRequest is the request body for creating a user
 type CreateUserRequest struct {
 	Name  string `json:"name" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
+	// Password is optional; users without one can't log in
+	Password string `json:"password"`
 }
 
 // UserResponse is the response body for user operations
@@ -41,34 +54,34 @@ type UserResponse struct {
 	ID        uint   `json:"id"`
 	Name      string `json:"name"`
 	Email     string `json:"email"`
-	CreatedAt string `json:"created_at"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
 }
 
 // CreateUser handles POST /users
 func (h *HTTPHandler) CreateUser(c *gin.Context) {
 	var req CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.Error(errors.NewValidation("invalid request body", err.Error()))
+	if err := middleware.BindJSON(c, &req); err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
 	output, err := h.useCase.CreateUser(c.Request.Context(), application.CreateUserInput{
-		Name:  req.Name,
-		Email: req.Email,
+		Name:     req.Name,
+		Email:    req.Email,
+		Password: req.Password,
 	})
 	if err != nil {
-		c.Error(err)
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"data": UserResponse{
-			ID:        output.User.ID,
-			Name:      output.User.Name,
-			Email:     output.User.Email,
-			CreatedAt: output.User.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
-		"trace_id": c.GetString(middleware.TraceIDKey),
+	middleware.RespondCreated(c, uint64(output.User.ID), UserResponse{
+		ID:        output.User.ID,
+		Name:      output.User.Name,
+		Email:     output.User.Email,
+		CreatedAt: userspb.FormatTime(output.User.CreatedAt),
+		UpdatedAt: userspb.FormatTime(output.User.UpdatedAt),
 	})
 }
 
@@ -77,7 +90,7 @@ func (h *HTTPHandler) GetUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		c.Error(errors.NewValidation("invalid user id", nil))
+		middleware.RespondError(c, errors.NewValidation("invalid user id", nil))
 		return
 	}
 
@@ -85,17 +98,105 @@ func (h *HTTPHandler) GetUser(c *gin.Context) {
 		ID: uint(id),
 	})
 	if err != nil {
-		c.Error(err)
+		middleware.RespondError(c, err)
+		return
+	}
+
+	user := UserResponse{
+		ID:        output.User.ID,
+		Name:      output.User.Name,
+		Email:     output.User.Email,
+		CreatedAt: userspb.FormatTime(output.User.CreatedAt),
+		UpdatedAt: userspb.FormatTime(output.User.UpdatedAt),
+	}
+	if middleware.NotModified(c, user) {
+		return
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, user)
+}
+
+// ListUsersQuery is the query string for listing users
+type ListUsersQuery struct {
+	Name        string `form:"name"`
+	CreatedFrom string `form:"created_from"`
+	CreatedTo   string `form:"created_to"`
+	Sort        string `form:"sort"`
+	Order       string `form:"order"`
+	Page        int    `form:"page"`
+	PageSize    int    `form:"page_size"`
+}
+
+// ListUsersResponse is the response body for user listings
+type ListUsersResponse struct {
+	Items    []UserResponse `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// ListUsers handles GET /admin/users
+func (h *HTTPHandler) ListUsers(c *gin.Context) {
+	var query ListUsersQuery
+	if err := middleware.BindQuery(c, &query); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	input := application.ListUsersInput{
+		NameContains: query.Name,
+		SortBy:       query.Sort,
+		Page:         query.Page,
+		PageSize:     query.PageSize,
+	}
+
+	switch query.Order {
+	case "", "asc":
+	case "desc":
+		input.SortDesc = true
+	default:
+		middleware.RespondError(c, errors.NewValidation("order must be asc or desc", nil))
+		return
+	}
+
+	if query.CreatedFrom != "" {
+		t, err := time.Parse(time.RFC3339, query.CreatedFrom)
+		if err != nil {
+			middleware.RespondError(c, errors.NewValidation("created_from must be an RFC3339 timestamp", nil))
+			return
+		}
+		input.CreatedFrom = &t
+	}
+	if query.CreatedTo != "" {
+		t, err := time.Parse(time.RFC3339, query.CreatedTo)
+		if err != nil {
+			middleware.RespondError(c, errors.NewValidation("created_to must be an RFC3339 timestamp", nil))
+			return
+		}
+		input.CreatedTo = &t
+	}
+
+	output, err := h.useCase.ListUsers(c.Request.Context(), input)
+	if err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": UserResponse{
-			ID:        output.User.ID,
-			Name:      output.User.Name,
-			Email:     output.User.Email,
-			CreatedAt: output.User.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		},
-		"trace_id": c.GetString(middleware.TraceIDKey),
+	items := make([]UserResponse, len(output.Users))
+	for i, user := range output.Users {
+		items[i] = UserResponse{
+			ID:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			CreatedAt: userspb.FormatTime(user.CreatedAt),
+			UpdatedAt: userspb.FormatTime(user.UpdatedAt),
+		}
+	}
+
+	middleware.RespondSuccess(c, http.StatusOK, ListUsersResponse{
+		Items:    items,
+		Total:    output.Total,
+		Page:     output.Page,
+		PageSize: output.PageSize,
 	})
 }
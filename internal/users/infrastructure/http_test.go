@@ -0,0 +1,214 @@
+package infrastructure
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go-micro/internal/users/application"
+	"go-micro/internal/users/domain"
+	"go-micro/internal/users/ports"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/middleware"
+	"go-micro/pkg/testutil"
+)
+
+// inMemoryUserRepository stores users in a map
+type inMemoryUserRepository struct {
+	users map[uint]*domain.User
+}
+
+func (r *inMemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *inMemoryUserRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.NewUserNotFound(id)
+	}
+	return user, nil
+}
+
+func (r *inMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.NewNotFound("user", email)
+}
+
+func (r *inMemoryUserRepository) ExistsByID(ctx context.Context, id uint) (bool, error) {
+	_, ok := r.users[id]
+	return ok, nil
+}
+
+func (r *inMemoryUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *inMemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *inMemoryUserRepository) Delete(ctx context.Context, id uint) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *inMemoryUserRepository) List(ctx context.Context, filter ports.UserListFilter) ([]*domain.User, int64, error) {
+	return nil, 0, nil
+}
+
+// newTestRouter serves the user routes backed by an empty repository
+func newTestRouter() http.Handler {
+	repo := &inMemoryUserRepository{users: map[uint]*domain.User{}}
+	useCase := application.NewUserUseCase(repo, nil, logger.New("test", "debug"))
+
+	router := testutil.NewRouter()
+	NewHTTPHandler(useCase, "").RegisterRoutes(router.Group("/api/v1"))
+	return router
+}
+
+func TestCreateUser(t *testing.T) {
+	// Arrange
+	router := newTestRouter()
+
+	// Act
+	resp := testutil.Do(t, router, http.MethodPost, "/api/v1/users", CreateUserRequest{
+		Name:  "John Doe",
+		Email: "john@example.com",
+	})
+
+	// Assert
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if location := resp.Header().Get("Location"); location != "/api/v1/users/1" {
+		t.Errorf("expected Location /api/v1/users/1, got %q", location)
+	}
+
+	var user UserResponse
+	traceID := resp.Data(&user)
+	if user.ID != 1 || user.Name != "John Doe" || user.Email != "john@example.com" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if user.CreatedAt == "" || user.UpdatedAt == "" {
+		t.Errorf("expected timestamps, got %+v", user)
+	}
+	if traceID == "" || traceID != resp.Header().Get(middleware.TraceIDHeader) {
+		t.Errorf("expected the trace ID header %q in the body, got %q", resp.Header().Get(middleware.TraceIDHeader), traceID)
+	}
+}
+
+func TestCreateUser_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"malformed JSON", `{"name":`, http.StatusBadRequest, errors.CodeValidation},
+		{"missing email", CreateUserRequest{Name: "John Doe"}, http.StatusBadRequest, errors.CodeValidation},
+		{"duplicate email", CreateUserRequest{Name: "Jane Doe", Email: "john@example.com"}, http.StatusConflict, errors.CodeConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newTestRouter()
+			testutil.Do(t, router, http.MethodPost, "/api/v1/users", CreateUserRequest{
+				Name:  "John Doe",
+				Email: "john@example.com",
+			})
+
+			// Act
+			resp := testutil.Do(t, router, http.MethodPost, "/api/v1/users", tt.body)
+
+			// Assert
+			if resp.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+			body := resp.Error()
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, body.Error.Code)
+			}
+			if body.TraceID == "" {
+				t.Error("expected a trace ID in the error body")
+			}
+		})
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"existing user", "/api/v1/users/1", http.StatusOK, ""},
+		{"unknown user", "/api/v1/users/42", http.StatusNotFound, errors.CodeNotFound},
+		{"invalid id", "/api/v1/users/abc", http.StatusBadRequest, errors.CodeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := newTestRouter()
+			testutil.Do(t, router, http.MethodPost, "/api/v1/users", CreateUserRequest{
+				Name:  "John Doe",
+				Email: "john@example.com",
+			})
+
+			// Act
+			resp := testutil.Do(t, router, http.MethodGet, tt.path, nil)
+
+			// Assert
+			if resp.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, resp.Code, resp.Body.String())
+			}
+			if tt.wantCode != "" {
+				if code := resp.Error().Error.Code; code != tt.wantCode {
+					t.Errorf("expected code %s, got %s", tt.wantCode, code)
+				}
+				return
+			}
+
+			var user UserResponse
+			resp.Data(&user)
+			if user.ID != 1 || user.Email != "john@example.com" {
+				t.Errorf("unexpected user %+v", user)
+			}
+		})
+	}
+}
+
+func TestGetUser_ZeroTimestampsLeftOut(t *testing.T) {
+	// Arrange: a user loaded without its timestamps
+	repo := &inMemoryUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Name: "John Doe", Email: "john@example.com"},
+	}}
+	useCase := application.NewUserUseCase(repo, nil, logger.New("test", "debug"))
+	router := testutil.NewRouter()
+	NewHTTPHandler(useCase, "").RegisterRoutes(router.Group("/api/v1"))
+
+	// Act
+	resp := testutil.Do(t, router, http.MethodGet, "/api/v1/users/1", nil)
+
+	// Assert: the zero time is neither written as year 1 nor as ""
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	body := resp.Body.String()
+	if strings.Contains(body, "0001-01-01") || strings.Contains(body, "created_at") || strings.Contains(body, "updated_at") {
+		t.Errorf("expected the zero timestamps to be left out, got %s", body)
+	}
+}
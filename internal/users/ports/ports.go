@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"go-micro/internal/users/domain"
 )
@@ -17,11 +18,48 @@ type UserRepository interface {
 	// GetByEmail retrieves a user by email
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 
+	// ExistsByID reports whether a user with the given ID exists
+	ExistsByID(ctx context.Context, id uint) (bool, error)
+
+	// ExistsByEmail reports whether a user with the given email exists
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+
 	// Update updates an existing user
 	Update(ctx context.Context, user *domain.User) error
 
 	// Delete deletes a user by ID
 	Delete(ctx context.Context, id uint) error
+
+	// List retrieves a filtered, sorted page of users and the total match count
+	List(ctx context.Context, filter UserListFilter) ([]*domain.User, int64, error)
+}
+
+// UserListFilter describes filtering, sorting and pagination for user listings
+type UserListFilter struct {
+	NameContains string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	SortBy       string
+	SortDesc     bool
+	Page         int
+	PageSize     int
+}
+
+// RefreshTokenRepository defines the interface for refresh token persistence
+type RefreshTokenRepository interface {
+	// Create stores a new refresh token
+	Create(ctx context.Context, token *domain.RefreshToken) error
+
+	// GetByHash retrieves a refresh token by the hash of its value
+	GetByHash(ctx context.Context, hash string) (*domain.RefreshToken, error)
+
+	// MarkUsed records that the token was rotated, unless it already was or
+	// has been revoked, and reports whether it did. It is atomic, so of two
+	// concurrent rotations of the same token only one succeeds.
+	MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error)
+
+	// RevokeFamily revokes every token of a family that isn't revoked yet
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
 }
 
 // EventPublisher defines the interface for publishing domain events
@@ -0,0 +1,30 @@
+package auth
+
+import "context"
+
+type ctxKey struct{}
+
+// WithClaims returns a context carrying the caller's claims, so code that only
+// receives a context.Context, such as use cases, knows who is calling. Nil
+// claims (an anonymous caller) leave ctx unchanged.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	if claims == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, claims)
+}
+
+// ClaimsFromContext returns the caller's claims, or nil for anonymous callers
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(ctxKey{}).(*Claims)
+	return claims
+}
+
+// SubjectFromContext returns the authenticated caller's subject (their user
+// ID), or "" for anonymous callers
+func SubjectFromContext(ctx context.Context) string {
+	if claims := ClaimsFromContext(ctx); claims != nil {
+		return claims.Subject
+	}
+	return ""
+}
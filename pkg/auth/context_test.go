@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubjectFromContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		wantSubject string
+	}{
+		{"authenticated", WithClaims(context.Background(), &Claims{Subject: "42", Role: "user"}), "42"},
+		{"anonymous", WithClaims(context.Background(), nil), ""},
+		{"no claims", context.Background(), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			subject := SubjectFromContext(tt.ctx)
+
+			// Assert
+			if subject != tt.wantSubject {
+				t.Errorf("expected subject %q, got %q", tt.wantSubject, subject)
+			}
+			if claims := ClaimsFromContext(tt.ctx); (claims != nil) != (tt.wantSubject != "") {
+				t.Errorf("expected claims only when authenticated, got %+v", claims)
+			}
+		})
+	}
+}
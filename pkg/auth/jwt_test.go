@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"go-micro/pkg/errors"
+)
+
+func TestTokenIssuer_IssueAndVerify(t *testing.T) {
+	// Arrange
+	issuer := NewTokenIssuer("secret", time.Hour)
+
+	// Act
+	token, expiresAt, err := issuer.Issue("42", "user")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	claims, err := issuer.Verify(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected token to verify, got %v", err)
+	}
+	if claims.Subject != "42" || claims.Role != "user" || claims.ExpiresAt != expiresAt.Unix() {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestTokenIssuer_IssueForTenant(t *testing.T) {
+	// Arrange
+	issuer := NewTokenIssuer("secret", time.Hour)
+
+	// Act
+	token, _, err := issuer.IssueForTenant("42", "user", "acme")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	claims, err := issuer.Verify(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected token to verify, got %v", err)
+	}
+	if claims.Tenant != "acme" {
+		t.Errorf("expected tenant acme, got %q", claims.Tenant)
+	}
+}
+
+func TestTokenIssuer_VerifyRejects(t *testing.T) {
+	issuer := NewTokenIssuer("secret", time.Hour)
+	token, _, _ := issuer.Issue("42", "user")
+
+	expired := NewTokenIssuer("secret", time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expiredToken, _, _ := expired.Issue("42", "user")
+
+	otherToken, _, _ := NewTokenIssuer("other", time.Hour).Issue("42", "user")
+
+	parts := strings.Split(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"other secret", otherToken},
+		{"tampered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`)) + "." + parts[2]},
+		{"expired", expiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.Verify(tt.token)
+
+			if !errors.Is(err, errors.CodeUnauthorized) {
+				t.Errorf("expected unauthorized, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package buildinfo describes what exactly is running: the build, stamped in
+// at build time, and the process running it.
+package buildinfo
+
+import (
+	"runtime"
+	"time"
+)
+
+// Set at build time with
+//
+//	-ldflags "-X go-micro/pkg/buildinfo.Version=... -X go-micro/pkg/buildinfo.Commit=... -X go-micro/pkg/buildinfo.BuildTime=..."
+//
+// (see the Makefile); binaries built without them report the defaults
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// startedAt approximates when the process started
+var startedAt = time.Now()
+
+// Info is the build and runtime information of a service
+type Info struct {
+	Service   string `json:"service"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	StartedAt string `json:"started_at"`
+	// Uptime is in whole seconds
+	Uptime int64 `json:"uptime_seconds"`
+	// Features are the service's feature flags as resolved at startup
+	Features map[string]bool `json:"features"`
+}
+
+// Get returns the information of service, with its feature flags
+func Get(service string, features map[string]bool) Info {
+	return Info{
+		Service:   service,
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		StartedAt: startedAt.UTC().Format(time.RFC3339),
+		Uptime:    int64(time.Since(startedAt).Seconds()),
+		Features:  features,
+	}
+}
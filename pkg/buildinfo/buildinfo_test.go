@@ -0,0 +1,28 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	// Arrange
+	features := map[string]bool{"login": true}
+
+	// Act
+	info := Get("gateway", features)
+
+	// Assert
+	if info.Service != "gateway" || info.Version != Version || info.Commit != Commit || info.BuildTime != BuildTime {
+		t.Errorf("unexpected build info %+v", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("expected Go version %q, got %q", runtime.Version(), info.GoVersion)
+	}
+	if info.Uptime < 0 || info.StartedAt == "" {
+		t.Errorf("unexpected uptime %d since %q", info.Uptime, info.StartedAt)
+	}
+	if !info.Features["login"] {
+		t.Errorf("expected features to be passed through, got %v", info.Features)
+	}
+}
@@ -0,0 +1,148 @@
+// Package client is a Go client for the gateway REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times a throttled request is retried
+	DefaultMaxRetries = 3
+	// DefaultMaxRetryWait caps how long a single Retry-After is honored
+	DefaultMaxRetryWait = 30 * time.Second
+	// defaultRetryWait is used when a throttled response has no usable Retry-After
+	defaultRetryWait = time.Second
+)
+
+// Client calls the gateway. Responses with 429 Too Many Requests or 503
+// Service Unavailable are retried a bounded number of times, waiting for the
+// duration given by their Retry-After header.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	maxRetries   int
+	maxRetryWait time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithMaxRetries sets how many times a throttled request is retried; 0 disables retries
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+	}
+}
+
+// WithMaxRetryWait caps the wait before a retry. A Retry-After longer than
+// this is not honored and the throttled response is returned instead.
+func WithMaxRetryWait(d time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetryWait = d
+	}
+}
+
+// New creates a client for the gateway at baseURL (e.g. "https://localhost:8443")
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	c := &Client{
+		baseURL:      u,
+		httpClient:   http.DefaultClient,
+		maxRetries:   DefaultMaxRetries,
+		maxRetryWait: DefaultMaxRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Do sends a request to path, relative to the base URL, with an optional
+// JSON body. The caller must close the response body.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	target := c.baseURL.JoinPath(path).String()
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, target, reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !retryable(resp.StatusCode) || attempt >= c.maxRetries {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if wait > c.maxRetryWait {
+			return resp, nil
+		}
+
+		// Drain so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether a response signals transient throttling
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP
+// date, falling back to a default wait when it is missing or invalid
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return defaultRetryWait
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return defaultRetryWait
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return defaultRetryWait
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+)
+
+// Hot-reloadable settings. On SIGHUP the services re-read their configuration
+// and apply only these fields:
+//
+//	LOG_LEVEL                   all services
+//	LOG_HTTP_BODIES             all services
+//	GRPC_RATE_LIMIT             users and orders services
+//	GRPC_RATE_LIMIT_BURST       users and orders services
+//	USER_RATE_LIMIT             gateway
+//	USER_RATE_LIMIT_BURST       gateway
+//	ANONYMOUS_RATE_LIMIT        gateway
+//	ANONYMOUS_RATE_LIMIT_BURST  gateway
+//
+// Every other setting (ports, addresses, credentials, TLS, timeouts, pool and
+// batch sizes, GRPC_RATE_LIMIT_PER_METHOD, ...) is read once at startup and
+// needs a restart to change.
+var hotReloadKeys = []string{
+	"LOG_LEVEL",
+	"LOG_HTTP_BODIES",
+	"GRPC_RATE_LIMIT",
+	"GRPC_RATE_LIMIT_BURST",
+	"USER_RATE_LIMIT",
+	"USER_RATE_LIMIT_BURST",
+	"ANONYMOUS_RATE_LIMIT",
+	"ANONYMOUS_RATE_LIMIT_BURST",
+}
+
+// processEnv holds the variables set in the process environment at startup,
+// before Load adds the .env file's
+var processEnv = environKeys()
+
+// environKeys returns the names of the variables currently set
+func environKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		if name, _, ok := strings.Cut(kv, "="); ok {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// Reload re-reads the hot-reloadable settings from the .env file and returns
+// the resulting configuration. As with Load, variables set in the process
+// environment win over the file, so only settings that come from .env can be
+// changed without a restart.
+func Reload() *Config {
+	reloadEnv(".env", processEnv)
+	return Load()
+}
+
+// reloadEnv updates the hot-reloadable variables not in processEnv to their
+// value in filename, unsetting those the file no longer has so they fall back
+// to their defaults. A missing or unreadable file changes nothing.
+func reloadEnv(filename string, processEnv map[string]bool) {
+	values, err := godotenv.Read(filename)
+	if err != nil {
+		return
+	}
+	for _, key := range hotReloadKeys {
+		if processEnv[key] {
+			continue
+		}
+		if value, ok := values[key]; ok {
+			_ = os.Setenv(key, value)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	}
+}
+
+// WatchReload calls apply with a freshly reloaded configuration each time the
+// process receives SIGHUP, until ctx is done
+func WatchReload(ctx context.Context, apply func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				apply(Reload())
+			}
+		}
+	}()
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReloadEnv_KeepsProcessEnvironment(t *testing.T) {
+	// Arrange: LOG_LEVEL comes from the process environment, the rate limits
+	// from an earlier .env file
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("GRPC_RATE_LIMIT", "10")
+	t.Setenv("GRPC_RATE_LIMIT_BURST", "20")
+	t.Setenv("GRPC_PORT", "50051")
+	processEnv := map[string]bool{"LOG_LEVEL": true, "GRPC_PORT": true}
+
+	filename := filepath.Join(t.TempDir(), ".env")
+	content := "LOG_LEVEL=debug\nGRPC_RATE_LIMIT=50\nGRPC_PORT=6000\n"
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+
+	// Act
+	reloadEnv(filename, processEnv)
+
+	// Assert
+	if got := os.Getenv("LOG_LEVEL"); got != "warn" {
+		t.Errorf("expected the process environment's LOG_LEVEL to win, got %q", got)
+	}
+	if got := os.Getenv("GRPC_RATE_LIMIT"); got != "50" {
+		t.Errorf("expected GRPC_RATE_LIMIT to be reloaded from the file, got %q", got)
+	}
+	if _, ok := os.LookupEnv("GRPC_RATE_LIMIT_BURST"); ok {
+		t.Error("expected GRPC_RATE_LIMIT_BURST, gone from the file, to fall back to its default")
+	}
+	if got := os.Getenv("GRPC_PORT"); got != "50051" {
+		t.Errorf("expected a setting that is not hot-reloadable to stay, got %q", got)
+	}
+}
@@ -7,7 +7,9 @@ import (
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
+
+	"go-micro/pkg/logger"
 )
 
 // Config holds database configuration
@@ -19,17 +21,25 @@ type Config struct {
 	DBName   string
 	SSLMode  string
 	Timeout  time.Duration
+
+	// StatementTimeout makes Postgres abort any statement running longer
+	// than this; zero leaves the server default (no limit)
+	StatementTimeout time.Duration
+
+	// Logger enables query logging with trace correlation; nil keeps GORM silent
+	Logger        *logger.Logger
+	SlowThreshold time.Duration
 }
 
 // NewConnection creates a new database connection
 func NewConnection(cfg Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
-	)
+	var gormLog gormlogger.Interface = gormlogger.Default.LogMode(gormlogger.Silent)
+	if cfg.Logger != nil {
+		gormLog = NewGormLogger(cfg.Logger, cfg.SlowThreshold)
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
+	db, err := gorm.Open(postgres.Open(dsn(cfg)), &gorm.Config{
+		Logger: gormLog,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -56,6 +66,44 @@ func NewConnection(cfg Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// Probe checks that db can serve requests within ctx. By default it pings,
+// which only proves a connection can be used; with query it runs SELECT 1
+// through GORM, which also fails when the server accepts connections but
+// can't answer queries (e.g. during a failover).
+func Probe(ctx context.Context, db *gorm.DB, query bool) error {
+	if query {
+		if err := db.WithContext(ctx).Exec("SELECT 1").Error; err != nil {
+			return fmt.Errorf("database query failed: %w", err)
+		}
+		return nil
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// dsn builds the connection string. The statement timeout is sent as a
+// runtime parameter, so it applies to every connection in the pool
+func dsn(cfg Config) string {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
+	)
+	if cfg.StatementTimeout > 0 {
+		// Postgres reads a bare number as milliseconds; round sub-millisecond
+		// values up so they don't become 0, which disables the timeout
+		ms := (cfg.StatementTimeout + time.Millisecond - 1) / time.Millisecond
+		dsn += fmt.Sprintf(" statement_timeout=%d", ms)
+	}
+	return dsn
+}
+
 // WithContext returns a db with context applied
 func WithContext(db *gorm.DB, ctx context.Context) *gorm.DB {
 	return db.WithContext(ctx)
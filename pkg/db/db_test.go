@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestDSN_StatementTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    string
+	}{
+		{"disabled", 0, ""},
+		{"seconds", 30 * time.Second, " statement_timeout=30000"},
+		{"sub-millisecond rounds up", 500 * time.Microsecond, " statement_timeout=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				Host: "localhost", Port: "5432", User: "postgres", Password: "secret",
+				DBName: "orders_db", SSLMode: "disable", StatementTimeout: tt.timeout,
+			}
+
+			got := dsn(cfg)
+
+			base := "host=localhost port=5432 user=postgres password=secret dbname=orders_db sslmode=disable"
+			if !strings.HasPrefix(got, base) {
+				t.Fatalf("expected DSN to start with %q, got %q", base, got)
+			}
+			if suffix := strings.TrimPrefix(got, base); suffix != tt.want {
+				t.Errorf("expected %q after the connection settings, got %q", tt.want, suffix)
+			}
+		})
+	}
+}
+
+func TestRegisterPoolMetrics_TwiceInOneProcess(t *testing.T) {
+	// Arrange: two services' pools with the same labels, as when tests start
+	// both in one process
+	open := func() *gorm.DB {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+			DisableAutomaticPing: true,
+		})
+		if err != nil {
+			t.Fatalf("failed to open db: %v", err)
+		}
+		return db
+	}
+
+	// Act & Assert
+	for i := 0; i < 2; i++ {
+		if err := RegisterPoolMetrics(open(), "test", "test_db"); err != nil {
+			t.Fatalf("registration %d: expected no error, got %v", i+1, err)
+		}
+	}
+}
+
+func TestProbe_Unreachable(t *testing.T) {
+	// Arrange: nothing listens on port 1, so every connection is refused
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1 connect_timeout=1"}), &gorm.Config{
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		query      bool
+		wantPrefix string
+	}{
+		{"ping", false, "failed to ping database"},
+		{"query", true, "database query failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			// Act
+			err := Probe(ctx, db, tt.query)
+
+			// Assert
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantPrefix) {
+				t.Errorf("expected error starting with %q, got %v", tt.wantPrefix, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	apperrors "go-micro/pkg/errors"
+)
+
+// Postgres error codes meaning the server is going away or not accepting
+// connections yet, e.g. during a restart or failover
+const (
+	pgAdminShutdown = "57P01"
+	pgCrashShutdown = "57P02"
+	pgCannotConnect = "57P03"
+)
+
+// IsConnectionError reports whether err means the database could not be
+// reached, so the statement provably never ran: dialing failed, the
+// connection was unusable before the statement was sent, or the server
+// refused it while shutting down, starting up or dropping the connection.
+// A connection that broke mid-statement is not one: the statement may have
+// run, so retrying it could apply it twice.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// database/sql and pgx only report these before sending anything
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var retry interface{ SafeToRetry() bool }
+	if errors.As(err, &retry) && retry.SafeToRetry() {
+		return true
+	}
+
+	// Dial failures, including pgx's connect errors, which wrap the dial error
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	// Class 08 is "connection exception"
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgAdminShutdown, pgCrashShutdown, pgCannotConnect:
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+	return false
+}
+
+// NewError describes a failed database call: connection failures, as
+// reported by IsConnectionError, are unavailable errors (503 over HTTP,
+// Unavailable over gRPC) so load balancers and clients retry them, and
+// anything else is an internal error. message
+// says what failed, e.g. "failed to get order", and is kept in the cause.
+func NewError(message string, err error) *apperrors.AppError {
+	if IsConnectionError(err) {
+		return apperrors.New(apperrors.CodeUnavailable, "database is unavailable, please retry later",
+			apperrors.WithCause(fmt.Errorf("%s: %w", message, err)))
+	}
+	return apperrors.NewInternal(message, err)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	apperrors "go-micro/pkg/errors"
+)
+
+// retryableError is reported by pgx for failures before anything was sent
+type retryableError struct{}
+
+func (retryableError) Error() string     { return "conn busy" }
+func (retryableError) SafeToRetry() bool { return true }
+
+func TestNewError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode string
+	}{
+		{"bad connection", driver.ErrBadConn, apperrors.CodeUnavailable},
+		{"connection refused", fmt.Errorf("failed to connect to `host=db`: %w", refused), apperrors.CodeUnavailable},
+		{"safe to retry", fmt.Errorf("query: %w", retryableError{}), apperrors.CodeUnavailable},
+		{"connection reset mid-query", syscall.ECONNRESET, apperrors.CodeInternal},
+		{"connection dropped mid-query", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), apperrors.CodeInternal},
+		{"read timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, apperrors.CodeInternal},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, apperrors.CodeUnavailable},
+		{"server shutting down", &pgconn.PgError{Code: "57P01"}, apperrors.CodeUnavailable},
+		{"server starting up", &pgconn.PgError{Code: "57P03"}, apperrors.CodeUnavailable},
+		{"undefined column", &pgconn.PgError{Code: "42703"}, apperrors.CodeInternal},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, apperrors.CodeInternal},
+		{"statement timeout", &pgconn.PgError{Code: "57014"}, apperrors.CodeInternal},
+		{"gorm error", gorm.ErrInvalidData, apperrors.CodeInternal},
+		{"cancelled", context.Canceled, apperrors.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewError("failed to get order", tt.err)
+
+			if err.Code != tt.wantCode {
+				t.Errorf("expected %s, got %v", tt.wantCode, err)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected the cause to be kept, got %v", err)
+			}
+		})
+	}
+}
+
+func TestIsConnectionError_Nil(t *testing.T) {
+	if IsConnectionError(nil) {
+		t.Error("expected nil not to be a connection error")
+	}
+}
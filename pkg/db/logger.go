@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"go-micro/pkg/logger"
+)
+
+// defaultSlowThreshold is used when no slow query threshold is configured
+const defaultSlowThreshold = 200 * time.Millisecond
+
+// GormLogger adapts the application logger to GORM so every logged query
+// carries the trace ID of the request that issued it. Queries are logged with
+// their placeholders rather than the parameter values, which may be personal
+// data such as emails or password hashes.
+type GormLogger struct {
+	log           *logger.Logger
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// NewGormLogger creates a GORM logger backed by the application logger.
+// Failed queries are logged as errors, slow queries as warnings and all
+// other queries at debug level.
+func NewGormLogger(log *logger.Logger, slowThreshold time.Duration) *GormLogger {
+	if slowThreshold <= 0 {
+		slowThreshold = defaultSlowThreshold
+	}
+	return &GormLogger{
+		log:           log,
+		level:         gormlogger.Info,
+		slowThreshold: slowThreshold,
+	}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.log.WithContext(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.log.WithContext(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.log.WithContext(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter, dropping the parameter values
+// so Trace logs queries with their placeholders
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// unfilledPlaceholder matches the "$1$" GORM's postgres dialector leaves for
+// a placeholder without a value
+var unfilledPlaceholder = regexp.MustCompile(`\$(\d+)\$`)
+
+// Trace implements gormlogger.Interface
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+	sql = unfilledPlaceholder.ReplaceAllString(sql, "$$$1")
+	fields := []zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+	}
+
+	log := l.log.WithContext(ctx)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		log.Error("database query failed", append(fields, zap.Error(err))...)
+	case elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		log.Warn("slow database query", append(fields, zap.Duration("threshold", l.slowThreshold))...)
+	case l.level >= gormlogger.Info:
+		log.Debug("database query", fields...)
+	}
+}
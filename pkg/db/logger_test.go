@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"go-micro/pkg/logger"
+)
+
+func newObservedLogger() (*logger.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return &logger.Logger{Logger: zap.New(core)}, logs
+}
+
+func TestGormLogger_TraceIncludesTraceID(t *testing.T) {
+	log, logs := newObservedLogger()
+	gormLog := NewGormLogger(log, time.Second)
+
+	ctx := logger.WithTraceIDContext(context.Background(), "trace-123")
+	gormLog.Trace(ctx, time.Now(), func() (string, int64) {
+		return "SELECT * FROM orders WHERE id = 1", 1
+	}, nil)
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["trace_id"] != "trace-123" {
+		t.Errorf("expected trace_id 'trace-123', got %v", fields["trace_id"])
+	}
+	if fields["sql"] != "SELECT * FROM orders WHERE id = 1" {
+		t.Errorf("expected sql field, got %v", fields["sql"])
+	}
+}
+
+func TestGormLogger_SlowAndFailedQueries(t *testing.T) {
+	log, logs := newObservedLogger()
+	gormLog := NewGormLogger(log, time.Millisecond)
+	ctx := logger.WithTraceIDContext(context.Background(), "trace-456")
+	fc := func() (string, int64) { return "SELECT 1", 0 }
+
+	gormLog.Trace(ctx, time.Now().Add(-time.Second), fc, nil)
+	gormLog.Trace(ctx, time.Now(), fc, errors.New("connection refused"))
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+	if entries[0].Level != zapcore.WarnLevel {
+		t.Errorf("expected slow query at warn level, got %s", entries[0].Level)
+	}
+	if entries[1].Level != zapcore.ErrorLevel {
+		t.Errorf("expected failed query at error level, got %s", entries[1].Level)
+	}
+	for _, entry := range entries {
+		if entry.ContextMap()["trace_id"] != "trace-456" {
+			t.Errorf("expected trace_id on %q entry", entry.Message)
+		}
+	}
+}
+
+func TestGormLogger_OmitsParameterValues(t *testing.T) {
+	// Arrange
+	log, logs := newObservedLogger()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               NewGormLogger(log, time.Second),
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+
+	// Act
+	var count int64
+	db.Table("users").Where("email = ?", "john@example.com").Count(&count)
+
+	// Assert
+	entries := logs.FilterMessage("database query").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 query log entry, got %d", len(entries))
+	}
+	sql, _ := entries[0].ContextMap()["sql"].(string)
+	if sql != `SELECT count(*) FROM "users" WHERE email = $1` {
+		t.Errorf("expected the query with its placeholder, got %q", sql)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+
+	"go-micro/pkg/metrics"
+)
+
+// RegisterPoolMetrics exposes the connection pool stats of db (open, in use,
+// idle, wait count, wait duration, ...) as go_sql_* metrics on the default
+// Prometheus registry, labelled with the service and database name. If the
+// same service and database are registered twice in one process, the pool
+// registered first keeps being reported.
+func RegisterPoolMetrics(db *gorm.DB, service, dbName string) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get sql.DB: %w", err)
+	}
+
+	registerer := prometheus.WrapRegistererWith(prometheus.Labels{"service": service}, prometheus.DefaultRegisterer)
+	if _, err := metrics.RegisterWith(registerer, collectors.NewDBStatsCollector(sqlDB, dbName)); err != nil {
+		return fmt.Errorf("failed to register pool metrics: %w", err)
+	}
+	return nil
+}
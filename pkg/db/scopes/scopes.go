@@ -0,0 +1,77 @@
+// Package scopes provides reusable GORM query fragments for repositories.
+package scopes
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"go-micro/pkg/tenant"
+)
+
+// Scope is a reusable GORM query fragment
+type Scope = func(*gorm.DB) *gorm.DB
+
+// Paginate applies limit/offset for a 1-based page. A pageSize <= 0 leaves
+// the query unbounded.
+func Paginate(page, pageSize int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if pageSize <= 0 {
+			return db
+		}
+		if page < 1 {
+			page = 1
+		}
+		return db.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+}
+
+// Limit caps the number of returned rows. A limit <= 0 leaves the query unbounded.
+func Limit(limit int) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if limit <= 0 {
+			return db
+		}
+		return db.Limit(limit)
+	}
+}
+
+// IncludeDeleted includes soft-deleted rows when include is true
+func IncludeDeleted(include bool) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if include {
+			return db.Unscoped()
+		}
+		return db
+	}
+}
+
+// OrderBy sorts by the column mapped to field in allowed. Unknown fields fall
+// back to defaultField, so user input never reaches the SQL as a column name.
+func OrderBy(field string, desc bool, allowed map[string]string, defaultField string) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		column, ok := allowed[field]
+		if !ok {
+			column, ok = allowed[defaultField]
+			if !ok {
+				return db
+			}
+		}
+		return db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
+
+// Tenant restricts a query to the rows of the tenant ctx acts for. Without a
+// tenant that is the default, empty one (single-tenant deployments store
+// every row there), so a request that names no tenant never sees another
+// tenant's rows. Only contexts from tenant.AllTenants, as background jobs
+// use, see every row.
+func Tenant(ctx context.Context) Scope {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenant.IsAllTenants(ctx) {
+			return db
+		}
+		return db.Where("tenant_id = ?", tenant.FromContext(ctx))
+	}
+}
@@ -0,0 +1,93 @@
+package scopes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"go-micro/pkg/tenant"
+)
+
+type testModel struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+	return db
+}
+
+func toSQL(t *testing.T, scopes ...Scope) string {
+	db := newDryRunDB(t)
+	return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var models []testModel
+		return tx.Scopes(scopes...).Find(&models)
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	sql := toSQL(t, Paginate(3, 20))
+	if !strings.Contains(sql, "LIMIT 20 OFFSET 40") {
+		t.Errorf("expected LIMIT 20 OFFSET 40, got %s", sql)
+	}
+
+	sql = toSQL(t, Paginate(0, 10))
+	if !strings.Contains(sql, "LIMIT 10") || strings.Contains(sql, "OFFSET") {
+		t.Errorf("expected first page without offset, got %s", sql)
+	}
+}
+
+func TestOrderBy_Allowlist(t *testing.T) {
+	allowed := map[string]string{"name": "name", "created_at": "created_at"}
+
+	sql := toSQL(t, OrderBy("name", true, allowed, "created_at"))
+	if !strings.Contains(sql, `ORDER BY "name" DESC`) {
+		t.Errorf("expected ORDER BY name DESC, got %s", sql)
+	}
+
+	sql = toSQL(t, OrderBy("id; DROP TABLE users", false, allowed, "created_at"))
+	if !strings.Contains(sql, `ORDER BY "created_at"`) || strings.Contains(sql, "DROP") {
+		t.Errorf("expected fallback to created_at, got %s", sql)
+	}
+}
+
+func TestIncludeDeleted(t *testing.T) {
+	sql := toSQL(t, IncludeDeleted(false))
+	if !strings.Contains(sql, "deleted_at") {
+		t.Errorf("expected soft-delete filter, got %s", sql)
+	}
+
+	sql = toSQL(t, IncludeDeleted(true))
+	if strings.Contains(sql, "deleted_at") {
+		t.Errorf("expected no soft-delete filter, got %s", sql)
+	}
+}
+
+func TestTenant(t *testing.T) {
+	sql := toSQL(t, Tenant(tenant.WithContext(context.Background(), "acme")))
+	if !strings.Contains(sql, "tenant_id = 'acme'") {
+		t.Errorf("expected tenant filter, got %s", sql)
+	}
+
+	sql = toSQL(t, Tenant(context.Background()))
+	if !strings.Contains(sql, "tenant_id = ''") {
+		t.Errorf("expected the default tenant's rows without a tenant, got %s", sql)
+	}
+
+	sql = toSQL(t, Tenant(tenant.AllTenants(context.Background())))
+	if strings.Contains(sql, "tenant_id") {
+		t.Errorf("expected no tenant filter for every tenant, got %s", sql)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -18,6 +20,11 @@ const (
 	CodeInternal     = "INTERNAL_ERROR"
 	CodeUnauthorized = "UNAUTHORIZED"
 	CodeForbidden    = "FORBIDDEN"
+	CodeUnavailable  = "SERVICE_UNAVAILABLE"
+	CodeTimeout      = "DEADLINE_EXCEEDED"
+	CodeRateLimited  = "RATE_LIMITED"
+	// CodeRequestTooLarge is a request body over the size limit
+	CodeRequestTooLarge = "REQUEST_TOO_LARGE"
 )
 
 // AppError represents an application error
@@ -26,6 +33,34 @@ type AppError struct {
 	Message string      `json:"message"`
 	Details interface{} `json:"details,omitempty"`
 	Err     error       `json:"-"`
+
+	// RetryAfter, when set, is how long clients should wait before retrying
+	RetryAfter time.Duration `json:"-"`
+	// Status, when set, overrides the HTTP status derived from Code
+	Status int `json:"-"`
+	// Dependency names the downstream service whose failure caused the
+	// error, e.g. "users-service"; empty when the failure is our own
+	Dependency string `json:"dependency,omitempty"`
+}
+
+// ErrCircuitOpen matches a CircuitOpenError with errors.Is
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned by a client-side circuit breaker that rejects
+// a call without sending it, because the backend has been failing
+type CircuitOpenError struct {
+	// RetryAfter is how long until the breaker lets a call through again
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *CircuitOpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
 }
 
 // Error implements the error interface
@@ -49,13 +84,22 @@ type ErrorResponse struct {
 
 // ErrorBody contains error details
 type ErrorBody struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Details interface{} `json:"details,omitempty"`
+	Code       string      `json:"code"`
+	Message    string      `json:"message"`
+	Details    interface{} `json:"details,omitempty"`
+	Dependency string      `json:"dependency,omitempty"`
 }
 
 // ToJSON converts an error to the standard JSON response
 func ToJSON(err error, traceID string) (int, []byte) {
+	statusCode, data, _ := Encode(err, traceID)
+	return statusCode, data
+}
+
+// Encode is like ToJSON but also returns the marshal error, if any. When the
+// response can't be marshaled (e.g. Details holds a channel) it falls back to
+// a minimal internal error body so the client never gets an empty response.
+func Encode(err error, traceID string) (int, []byte, error) {
 	var appErr *AppError
 	if !errors.As(err, &appErr) {
 		appErr = &AppError{
@@ -66,15 +110,26 @@ func ToJSON(err error, traceID string) (int, []byte) {
 
 	response := ErrorResponse{
 		Error: ErrorBody{
-			Code:    appErr.Code,
-			Message: appErr.Message,
-			Details: appErr.Details,
+			Code:       appErr.Code,
+			Message:    appErr.Message,
+			Details:    appErr.Details,
+			Dependency: appErr.Dependency,
 		},
 		TraceID: traceID,
 	}
 
-	data, _ := json.Marshal(response)
-	return HTTPStatus(appErr), data
+	data, marshalErr := json.Marshal(response)
+	if marshalErr != nil {
+		return http.StatusInternalServerError, fallbackJSON(traceID), marshalErr
+	}
+	return HTTPStatus(appErr), data, nil
+}
+
+// fallbackJSON builds the minimal error body without going through the
+// response structs, so it cannot fail
+func fallbackJSON(traceID string) []byte {
+	quoted, _ := json.Marshal(traceID)
+	return []byte(`{"error":{"code":"` + CodeInternal + `","message":"An internal error occurred"},"trace_id":` + string(quoted) + `}`)
 }
 
 // HTTPStatus returns the HTTP status code for an error
@@ -83,6 +138,9 @@ func HTTPStatus(err error) int {
 	if !errors.As(err, &appErr) {
 		return http.StatusInternalServerError
 	}
+	if appErr.Status != 0 {
+		return appErr.Status
+	}
 
 	switch appErr.Code {
 	case CodeValidation:
@@ -95,6 +153,14 @@ func HTTPStatus(err error) int {
 		return http.StatusUnauthorized
 	case CodeForbidden:
 		return http.StatusForbidden
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	case CodeRequestTooLarge:
+		return http.StatusRequestEntityTooLarge
 	default:
 		return http.StatusInternalServerError
 	}
@@ -119,6 +185,12 @@ func GRPCStatus(err error) error {
 		code = codes.Unauthenticated
 	case CodeForbidden:
 		code = codes.PermissionDenied
+	case CodeUnavailable:
+		code = codes.Unavailable
+	case CodeTimeout:
+		code = codes.DeadlineExceeded
+	case CodeRateLimited, CodeRequestTooLarge:
+		code = codes.ResourceExhausted
 	default:
 		code = codes.Internal
 	}
@@ -128,6 +200,28 @@ func GRPCStatus(err error) error {
 
 // FromGRPCStatus converts a gRPC status to an AppError
 func FromGRPCStatus(err error) *AppError {
+	// Errors already converted, e.g. by the client interceptor, are kept
+	// along with what was added to them since
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	// A call rejected by an open circuit never reached the backend: report
+	// when to come back instead of a generic internal error
+	var open *CircuitOpenError
+	if errors.As(err, &open) {
+		return &AppError{
+			Code:    CodeUnavailable,
+			Message: "service is temporarily unavailable, please retry later",
+			Details: map[string]interface{}{
+				"retry_after_seconds": retryAfterSeconds(open.RetryAfter),
+			},
+			Err:        err,
+			RetryAfter: open.RetryAfter,
+		}
+	}
+
 	st, ok := status.FromError(err)
 	if !ok {
 		return NewInternal("unknown error", err)
@@ -145,6 +239,12 @@ func FromGRPCStatus(err error) *AppError {
 		code = CodeUnauthorized
 	case codes.PermissionDenied:
 		code = CodeForbidden
+	case codes.Unavailable:
+		code = CodeUnavailable
+	case codes.DeadlineExceeded:
+		code = CodeTimeout
+	case codes.ResourceExhausted:
+		code = CodeRateLimited
 	default:
 		code = CodeInternal
 	}
@@ -158,46 +258,102 @@ func FromGRPCStatus(err error) *AppError {
 
 // Constructor functions
 
-// NewValidation creates a validation error
-func NewValidation(message string, details interface{}) *AppError {
-	return &AppError{
-		Code:    CodeValidation,
+// Option sets an optional field of an AppError built by New
+type Option func(*AppError)
+
+// WithDetails attaches details for the client, such as invalid fields
+func WithDetails(details interface{}) Option {
+	return func(e *AppError) {
+		e.Details = details
+	}
+}
+
+// WithCause wraps the underlying error, which is logged but never sent to
+// the client
+func WithCause(err error) Option {
+	return func(e *AppError) {
+		e.Err = err
+	}
+}
+
+// WithHTTPStatus answers the error with status instead of the one its code
+// maps to
+func WithHTTPStatus(status int) Option {
+	return func(e *AppError) {
+		e.Status = status
+	}
+}
+
+// WithDependency names the downstream service whose failure caused the error
+func WithDependency(name string) Option {
+	return func(e *AppError) {
+		e.Dependency = name
+	}
+}
+
+// WithRetryAfter tells clients how long to wait before retrying
+func WithRetryAfter(d time.Duration) Option {
+	return func(e *AppError) {
+		e.RetryAfter = d
+	}
+}
+
+// New creates an error with code and message, and whatever the options add.
+// The NewX constructors cover the common cases.
+func New(code, message string, opts ...Option) *AppError {
+	e := &AppError{
+		Code:    code,
 		Message: message,
-		Details: details,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// NewValidation creates a validation error
+func NewValidation(message string, details interface{}) *AppError {
+	return New(CodeValidation, message, WithDetails(details))
 }
 
 // NewNotFound creates a not found error
 func NewNotFound(resource string, id interface{}) *AppError {
-	return &AppError{
-		Code:    CodeNotFound,
-		Message: fmt.Sprintf("%s with id '%v' not found", resource, id),
-	}
+	return New(CodeNotFound, fmt.Sprintf("%s with id '%v' not found", resource, id))
 }
 
 // NewConflict creates a conflict error
 func NewConflict(message string) *AppError {
-	return &AppError{
-		Code:    CodeConflict,
-		Message: message,
-	}
+	return New(CodeConflict, message)
 }
 
 // NewInternal creates an internal error
 func NewInternal(message string, err error) *AppError {
-	return &AppError{
-		Code:    CodeInternal,
-		Message: message,
-		Err:     err,
-	}
+	return New(CodeInternal, message, WithCause(err))
 }
 
 // NewUnauthorized creates an unauthorized error
 func NewUnauthorized(message string) *AppError {
-	return &AppError{
-		Code:    CodeUnauthorized,
-		Message: message,
-	}
+	return New(CodeUnauthorized, message)
+}
+
+// NewForbidden creates a forbidden error
+func NewForbidden(message string) *AppError {
+	return New(CodeForbidden, message)
+}
+
+// NewUnavailable creates a service unavailable error
+func NewUnavailable(message string) *AppError {
+	return New(CodeUnavailable, message)
+}
+
+// NewRateLimited creates a rate limit exceeded error
+func NewRateLimited(message string) *AppError {
+	return New(CodeRateLimited, message)
+}
+
+// NewRequestTooLarge creates an error for a request body over the size limit
+func NewRequestTooLarge(message string, details interface{}) *AppError {
+	return New(CodeRequestTooLarge, message, WithDetails(details))
 }
 
 // Is checks if an error matches a specific code
@@ -214,11 +370,31 @@ func Wrap(err error, message string) *AppError {
 	var appErr *AppError
 	if errors.As(err, &appErr) {
 		return &AppError{
-			Code:    appErr.Code,
-			Message: message + ": " + appErr.Message,
-			Details: appErr.Details,
-			Err:     err,
+			Code:       appErr.Code,
+			Message:    message + ": " + appErr.Message,
+			Details:    appErr.Details,
+			Err:        err,
+			RetryAfter: appErr.RetryAfter,
+			Status:     appErr.Status,
+			Dependency: appErr.Dependency,
 		}
 	}
 	return NewInternal(message, err)
 }
+
+// RetryAfterSeconds returns the Retry-After of err in whole seconds, rounded
+// up, or 0 when err does not carry one
+func RetryAfterSeconds(err error) int64 {
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		return 0
+	}
+	return retryAfterSeconds(appErr.RetryAfter)
+}
+
+func retryAfterSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
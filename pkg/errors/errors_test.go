@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToJSON(t *testing.T) {
+	// Arrange
+	err := NewValidation("invalid input", map[string]string{"field": "name"})
+
+	// Act
+	status, body := ToJSON(err, "trace-1")
+
+	// Assert
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", status)
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if response.Error.Code != CodeValidation || response.TraceID != "trace-1" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestEncode_UnmarshalableDetailsFallsBack(t *testing.T) {
+	// Arrange
+	err := NewValidation("invalid input", map[string]interface{}{"ch": make(chan int)})
+
+	// Act
+	status, body, marshalErr := Encode(err, `trace-"2"`)
+
+	// Assert
+	if marshalErr == nil {
+		t.Error("expected marshal error to be reported")
+	}
+	if status != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", status)
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("expected valid fallback JSON, got %q: %v", body, err)
+	}
+	if response.Error.Code != CodeInternal {
+		t.Errorf("expected code %s, got %s", CodeInternal, response.Error.Code)
+	}
+	if response.TraceID != `trace-"2"` {
+		t.Errorf("expected trace ID to be preserved, got %q", response.TraceID)
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	cause := errors.New("connection reset")
+	details := map[string]string{"field": "email"}
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantDetails interface{}
+		wantCause   error
+		wantStatus  int
+		wantRetry   time.Duration
+	}{
+		{
+			name:       "no options",
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:        "details",
+			opts:        []Option{WithDetails(details)},
+			wantDetails: details,
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:       "cause",
+			opts:       []Option{WithCause(cause)},
+			wantCause:  cause,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:        "details and cause",
+			opts:        []Option{WithDetails(details), WithCause(cause)},
+			wantDetails: details,
+			wantCause:   cause,
+			wantStatus:  http.StatusConflict,
+		},
+		{
+			name:       "status override",
+			opts:       []Option{WithHTTPStatus(http.StatusUnprocessableEntity)},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "all",
+			opts: []Option{
+				WithDetails(details), WithCause(cause),
+				WithHTTPStatus(http.StatusPreconditionFailed), WithRetryAfter(time.Second),
+			},
+			wantDetails: details,
+			wantCause:   cause,
+			wantStatus:  http.StatusPreconditionFailed,
+			wantRetry:   time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := New(CodeConflict, "email already registered", tt.opts...)
+
+			// Assert
+			if err.Code != CodeConflict || err.Message != "email already registered" {
+				t.Errorf("unexpected code or message %+v", err)
+			}
+			if !reflect.DeepEqual(err.Details, tt.wantDetails) {
+				t.Errorf("expected details %v, got %v", tt.wantDetails, err.Details)
+			}
+			if errors.Unwrap(err) != tt.wantCause {
+				t.Errorf("expected cause %v, got %v", tt.wantCause, errors.Unwrap(err))
+			}
+			if got := HTTPStatus(err); got != tt.wantStatus {
+				t.Errorf("expected HTTP status %d, got %d", tt.wantStatus, got)
+			}
+			if err.RetryAfter != tt.wantRetry {
+				t.Errorf("expected retry after %v, got %v", tt.wantRetry, err.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestWrap_KeepsHTTPStatus(t *testing.T) {
+	err := Wrap(New(CodeValidation, "invalid", WithHTTPStatus(http.StatusUnprocessableEntity)), "create order")
+
+	if got := HTTPStatus(err); got != http.StatusUnprocessableEntity {
+		t.Errorf("expected wrapped error to keep status 422, got %d", got)
+	}
+}
+
+func TestEncode_Dependency(t *testing.T) {
+	// Arrange: a converted downstream failure, as the gateway handlers see it
+	err := FromGRPCStatus(New(CodeUnavailable, "connection refused", WithDependency("users-service")))
+
+	// Act
+	_, data := ToJSON(err, "trace-1")
+
+	// Assert
+	var response ErrorResponse
+	if unmarshalErr := json.Unmarshal(data, &response); unmarshalErr != nil {
+		t.Fatalf("failed to decode response: %v", unmarshalErr)
+	}
+	if response.Error.Dependency != "users-service" || response.Error.Message != "connection refused" {
+		t.Errorf("expected the dependency and original message, got %+v", response.Error)
+	}
+	if _, data := ToJSON(NewValidation("invalid", nil), ""); strings.Contains(string(data), "dependency") {
+		t.Errorf("expected no dependency for our own errors, got %s", data)
+	}
+}
+
+func TestRequestTooLarge_Statuses(t *testing.T) {
+	err := NewRequestTooLarge("request body too large", nil)
+
+	if got := HTTPStatus(err); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected HTTP 413, got %d", got)
+	}
+	if got := status.Code(GRPCStatus(err)); got != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %s", got)
+	}
+}
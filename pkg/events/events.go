@@ -1,24 +1,37 @@
 package events
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // Exchange names
 const (
-	ExchangeUsers  = "users.events"
-	ExchangeOrders = "orders.events"
+	ExchangeUsers    = "users.events"
+	ExchangeOrders   = "orders.events"
+	ExchangePayments = "payments.events"
 )
 
 // Routing keys
 const (
-	RoutingKeyUserCreated  = "user.created"
-	RoutingKeyOrderCreated = "order.created"
+	RoutingKeyUserCreated       = "user.created"
+	RoutingKeyUserDeleted       = "user.deleted"
+	RoutingKeyOrderCreated      = "order.created"
+	RoutingKeyOrderConfirmed    = "order.confirmed"
+	RoutingKeyOrderCancelled    = "order.cancelled"
+	RoutingKeyOrderReopened     = "order.reopened"
+	RoutingKeyOrderTotalChanged = "order.total_changed"
+	RoutingKeyPaymentSucceeded  = "payment.succeeded"
 )
 
 // UserCreatedEvent is published when a user is created
 type UserCreatedEvent struct {
+	EventID   string             `json:"event_id"`
 	Version   string             `json:"version"`
 	EventType string             `json:"event_type"`
 	Timestamp time.Time          `json:"timestamp"`
+	Sequence  uint64             `json:"sequence,omitempty"`
 	TraceID   string             `json:"trace_id"`
 	Payload   UserCreatedPayload `json:"payload"`
 }
@@ -34,9 +47,11 @@ type UserCreatedPayload struct {
 // NewUserCreatedEvent creates a new UserCreatedEvent
 func NewUserCreatedEvent(id uint, name, email string, createdAt time.Time, traceID string) *UserCreatedEvent {
 	return &UserCreatedEvent{
+		EventID:   uuid.New().String(),
 		Version:   "1.0",
 		EventType: "user.created",
 		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
 		TraceID:   traceID,
 		Payload: UserCreatedPayload{
 			ID:        id,
@@ -47,11 +62,47 @@ func NewUserCreatedEvent(id uint, name, email string, createdAt time.Time, trace
 	}
 }
 
+// UserDeletedEvent is published when a user is deleted; consumers must erase
+// or detach the data they hold about the user
+type UserDeletedEvent struct {
+	EventID   string             `json:"event_id"`
+	Version   string             `json:"version"`
+	EventType string             `json:"event_type"`
+	Timestamp time.Time          `json:"timestamp"`
+	Sequence  uint64             `json:"sequence,omitempty"`
+	TraceID   string             `json:"trace_id"`
+	Payload   UserDeletedPayload `json:"payload"`
+}
+
+// UserDeletedPayload identifies the deleted user
+type UserDeletedPayload struct {
+	ID        uint      `json:"id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// NewUserDeletedEvent creates a new UserDeletedEvent
+func NewUserDeletedEvent(id uint, deletedAt time.Time, traceID string) *UserDeletedEvent {
+	return &UserDeletedEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "user.deleted",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: UserDeletedPayload{
+			ID:        id,
+			DeletedAt: deletedAt,
+		},
+	}
+}
+
 // OrderCreatedEvent is published when an order is created
 type OrderCreatedEvent struct {
+	EventID   string              `json:"event_id"`
 	Version   string              `json:"version"`
 	EventType string              `json:"event_type"`
 	Timestamp time.Time           `json:"timestamp"`
+	Sequence  uint64              `json:"sequence,omitempty"`
 	TraceID   string              `json:"trace_id"`
 	Payload   OrderCreatedPayload `json:"payload"`
 }
@@ -68,9 +119,11 @@ type OrderCreatedPayload struct {
 // NewOrderCreatedEvent creates a new OrderCreatedEvent
 func NewOrderCreatedEvent(id, userID uint, total float64, status string, createdAt time.Time, traceID string) *OrderCreatedEvent {
 	return &OrderCreatedEvent{
+		EventID:   uuid.New().String(),
 		Version:   "1.0",
 		EventType: "order.created",
 		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
 		TraceID:   traceID,
 		Payload: OrderCreatedPayload{
 			ID:        id,
@@ -81,3 +134,189 @@ func NewOrderCreatedEvent(id, userID uint, total float64, status string, created
 		},
 	}
 }
+
+// OrderConfirmedEvent is published when an operator confirms an order
+type OrderConfirmedEvent struct {
+	EventID   string                `json:"event_id"`
+	Version   string                `json:"version"`
+	EventType string                `json:"event_type"`
+	Timestamp time.Time             `json:"timestamp"`
+	Sequence  uint64                `json:"sequence,omitempty"`
+	TraceID   string                `json:"trace_id"`
+	Payload   OrderConfirmedPayload `json:"payload"`
+}
+
+// OrderConfirmedPayload contains confirmed order data
+type OrderConfirmedPayload struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	ConfirmedAt time.Time `json:"confirmed_at"`
+}
+
+// NewOrderConfirmedEvent creates a new OrderConfirmedEvent
+func NewOrderConfirmedEvent(id, userID uint, confirmedAt time.Time, traceID string) *OrderConfirmedEvent {
+	return &OrderConfirmedEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "order.confirmed",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: OrderConfirmedPayload{
+			ID:          id,
+			UserID:      userID,
+			ConfirmedAt: confirmedAt,
+		},
+	}
+}
+
+// OrderCancelledEvent is published when an order is cancelled
+type OrderCancelledEvent struct {
+	EventID   string                `json:"event_id"`
+	Version   string                `json:"version"`
+	EventType string                `json:"event_type"`
+	Timestamp time.Time             `json:"timestamp"`
+	Sequence  uint64                `json:"sequence,omitempty"`
+	TraceID   string                `json:"trace_id"`
+	Payload   OrderCancelledPayload `json:"payload"`
+}
+
+// OrderCancelledPayload contains cancelled order data
+type OrderCancelledPayload struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	Reason      string    `json:"reason"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// NewOrderCancelledEvent creates a new OrderCancelledEvent
+func NewOrderCancelledEvent(id, userID uint, reason string, cancelledAt time.Time, traceID string) *OrderCancelledEvent {
+	return &OrderCancelledEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "order.cancelled",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: OrderCancelledPayload{
+			ID:          id,
+			UserID:      userID,
+			Reason:      reason,
+			CancelledAt: cancelledAt,
+		},
+	}
+}
+
+// OrderReopenedEvent is published when a cancelled order is reopened
+type OrderReopenedEvent struct {
+	EventID   string               `json:"event_id"`
+	Version   string               `json:"version"`
+	EventType string               `json:"event_type"`
+	Timestamp time.Time            `json:"timestamp"`
+	Sequence  uint64               `json:"sequence,omitempty"`
+	TraceID   string               `json:"trace_id"`
+	Payload   OrderReopenedPayload `json:"payload"`
+}
+
+// OrderReopenedPayload contains reopened order data
+type OrderReopenedPayload struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Status     string    `json:"status"`
+	ReopenedAt time.Time `json:"reopened_at"`
+}
+
+// NewOrderReopenedEvent creates a new OrderReopenedEvent
+func NewOrderReopenedEvent(id, userID uint, status string, reopenedAt time.Time, traceID string) *OrderReopenedEvent {
+	return &OrderReopenedEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "order.reopened",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: OrderReopenedPayload{
+			ID:         id,
+			UserID:     userID,
+			Status:     status,
+			ReopenedAt: reopenedAt,
+		},
+	}
+}
+
+// OrderTotalChangedEvent is published when an order's total is changed.
+// Orders have no way to change their total yet, so nothing publishes it.
+type OrderTotalChangedEvent struct {
+	EventID   string                   `json:"event_id"`
+	Version   string                   `json:"version"`
+	EventType string                   `json:"event_type"`
+	Timestamp time.Time                `json:"timestamp"`
+	Sequence  uint64                   `json:"sequence,omitempty"`
+	TraceID   string                   `json:"trace_id"`
+	Payload   OrderTotalChangedPayload `json:"payload"`
+}
+
+// OrderTotalChangedPayload contains the old and new order totals
+type OrderTotalChangedPayload struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	OldTotal  float64   `json:"old_total"`
+	NewTotal  float64   `json:"new_total"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// NewOrderTotalChangedEvent creates a new OrderTotalChangedEvent
+func NewOrderTotalChangedEvent(id, userID uint, oldTotal, newTotal float64, changedAt time.Time, traceID string) *OrderTotalChangedEvent {
+	return &OrderTotalChangedEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "order.total_changed",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: OrderTotalChangedPayload{
+			ID:        id,
+			UserID:    userID,
+			OldTotal:  oldTotal,
+			NewTotal:  newTotal,
+			ChangedAt: changedAt,
+		},
+	}
+}
+
+// PaymentSucceededEvent is published by the payments provider when an order is paid
+type PaymentSucceededEvent struct {
+	EventID   string                  `json:"event_id"`
+	Version   string                  `json:"version"`
+	EventType string                  `json:"event_type"`
+	Timestamp time.Time               `json:"timestamp"`
+	Sequence  uint64                  `json:"sequence,omitempty"`
+	TraceID   string                  `json:"trace_id"`
+	Payload   PaymentSucceededPayload `json:"payload"`
+}
+
+// PaymentSucceededPayload contains payment data
+type PaymentSucceededPayload struct {
+	PaymentID string    `json:"payment_id"`
+	OrderID   uint      `json:"order_id"`
+	Amount    float64   `json:"amount"`
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+// NewPaymentSucceededEvent creates a new PaymentSucceededEvent
+func NewPaymentSucceededEvent(paymentID string, orderID uint, amount float64, paidAt time.Time, traceID string) *PaymentSucceededEvent {
+	return &PaymentSucceededEvent{
+		EventID:   uuid.New().String(),
+		Version:   "1.0",
+		EventType: "payment.succeeded",
+		Timestamp: time.Now(),
+		Sequence:  sequencer.Next(),
+		TraceID:   traceID,
+		Payload: PaymentSucceededPayload{
+			PaymentID: paymentID,
+			OrderID:   orderID,
+			Amount:    amount,
+			PaidAt:    paidAt,
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package events
+
+import "strings"
+
+// Routing key convention
+//
+// Routing keys are hierarchical: the event type (e.g. "order.created")
+// optionally followed by attribute segments, most general first:
+//
+//	order.created          no attributes
+//	order.created.eu       region
+//	order.created.eu.vip   region, then customer tier
+//
+// Consumers bind with BindingKey to receive every variant of an event, or
+// with their own pattern (e.g. "order.created.eu.*") to narrow it down.
+
+// RoutingKeyFunc computes the routing key for an event from its base routing
+// key (the event type) and the event itself
+type RoutingKeyFunc func(base string, event interface{}) string
+
+// DefaultRoutingKey publishes every event under its base routing key
+func DefaultRoutingKey(base string, _ interface{}) string {
+	return base
+}
+
+// RoutingKey builds a hierarchical routing key from base and attribute
+// segments. Segments are lower-cased, empty segments are skipped, and the
+// characters that are special in topic routing ('.', '*', '#') are replaced
+// with '_' so one attribute always maps to exactly one word.
+func RoutingKey(base string, segments ...string) string {
+	var b strings.Builder
+	b.WriteString(base)
+
+	for _, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		b.WriteByte('.')
+		b.WriteString(keySegmentReplacer.Replace(strings.ToLower(segment)))
+	}
+
+	return b.String()
+}
+
+var keySegmentReplacer = strings.NewReplacer(".", "_", "*", "_", "#", "_", " ", "_")
+
+// BindingKey returns the topic binding that matches base with any number of
+// attribute segments, including none
+func BindingKey(base string) string {
+	return base + ".#"
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		segments []string
+		want     string
+	}{
+		{"no segments", RoutingKeyOrderCreated, nil, "order.created"},
+		{"one segment", RoutingKeyOrderCreated, []string{"eu"}, "order.created.eu"},
+		{"several segments", RoutingKeyOrderCreated, []string{"EU", "vip"}, "order.created.eu.vip"},
+		{"empty segments skipped", RoutingKeyOrderCreated, []string{"", "us", " "}, "order.created.us"},
+		{"special characters replaced", RoutingKeyOrderCreated, []string{"us.east", "a*b#c", "new york"}, "order.created.us_east.a_b_c.new_york"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoutingKey(tt.base, tt.segments...); got != tt.want {
+				t.Errorf("RoutingKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRoutingKey(t *testing.T) {
+	event := NewOrderCreatedEvent(1, 1, 10, "pending", time.Now(), "")
+
+	if got := DefaultRoutingKey(RoutingKeyOrderCreated, event); got != RoutingKeyOrderCreated {
+		t.Errorf("DefaultRoutingKey() = %q, want %q", got, RoutingKeyOrderCreated)
+	}
+}
+
+func TestBindingKey(t *testing.T) {
+	if got := BindingKey(RoutingKeyUserCreated); got != "user.created.#" {
+		t.Errorf("BindingKey() = %q, want %q", got, "user.created.#")
+	}
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event sequence numbers
+//
+// Every event carries a Sequence alongside its Timestamp. Within one producer
+// process sequences strictly increase, even if the host clock steps
+// backwards, so consumers that need to order events should compare
+// sequences rather than timestamps. Each sequence is at least the producer's
+// wall clock in Unix nanoseconds, so sequences from different producers, or
+// from one producer across restarts, still order like timestamps do.
+// Sequence 0 means the producer predates sequences; fall back to Timestamp.
+
+// Sequencer hands out increasing sequence numbers for one producer
+type Sequencer struct {
+	mu   sync.Mutex
+	last uint64
+	now  func() time.Time
+}
+
+// NewSequencer creates a Sequencer driven by the wall clock
+func NewSequencer() *Sequencer {
+	return &Sequencer{now: time.Now}
+}
+
+// Next returns a sequence number greater than any returned before
+func (s *Sequencer) Next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := s.last + 1
+	if wall := uint64(s.now().UnixNano()); wall > next {
+		next = wall
+	}
+	s.last = next
+	return next
+}
+
+// sequencer numbers the events built by this process
+var sequencer = NewSequencer()
+
+// CompareOrder orders two events by sequence when both have one, and by
+// timestamp otherwise. It returns a negative number when a comes first,
+// positive when b does, and 0 when they can't be told apart.
+func CompareOrder(aSequence uint64, aTimestamp time.Time, bSequence uint64, bTimestamp time.Time) int {
+	if aSequence != 0 && bSequence != 0 {
+		switch {
+		case aSequence < bSequence:
+			return -1
+		case aSequence > bSequence:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return aTimestamp.Compare(bTimestamp)
+}
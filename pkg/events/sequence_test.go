@@ -0,0 +1,55 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSequencer_IncreasesWhenClockStepsBack(t *testing.T) {
+	// Arrange
+	now := time.Unix(1700000000, 0)
+	s := NewSequencer()
+	s.now = func() time.Time { return now }
+
+	// Act
+	first := s.Next()
+	now = now.Add(-time.Hour)
+	second := s.Next()
+	third := s.Next()
+
+	// Assert
+	if first != uint64(time.Unix(1700000000, 0).UnixNano()) {
+		t.Errorf("expected first sequence to follow the wall clock, got %d", first)
+	}
+	if second <= first || third <= second {
+		t.Errorf("expected increasing sequences, got %d, %d, %d", first, second, third)
+	}
+}
+
+func TestCompareOrder(t *testing.T) {
+	earlier := time.Unix(1700000000, 0)
+	later := earlier.Add(time.Second)
+
+	tests := []struct {
+		name       string
+		aSequence  uint64
+		aTimestamp time.Time
+		bSequence  uint64
+		bTimestamp time.Time
+		want       int
+	}{
+		{"sequence wins over timestamp", 1, later, 2, earlier, -1},
+		{"equal sequences", 2, earlier, 2, later, 0},
+		{"missing sequence uses timestamp", 0, later, 2, earlier, 1},
+		{"no sequences", 0, earlier, 0, later, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareOrder(tt.aSequence, tt.aTimestamp, tt.bSequence, tt.bTimestamp)
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/errors"
+)
+
+// halfOpenRetryAfter is suggested to callers rejected while a probe is in flight
+const halfOpenRetryAfter = time.Second
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker stops calling a backend after threshold consecutive
+// failures, so callers fail fast instead of waiting on timeouts. Once
+// cooldown has passed a single probe call is let through: its success closes
+// the circuit again, its failure reopens it for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker that opens after
+// threshold (at least 1) consecutive failures
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may proceed. When it may not, it also returns
+// how long the caller should wait before trying again.
+func (b *CircuitBreaker) Allow() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return 0, true
+	case breakerOpen:
+		if elapsed := b.now().Sub(b.openedAt); elapsed < b.cooldown {
+			return b.cooldown - elapsed, false
+		}
+		b.state = breakerHalfOpen
+		return 0, true
+	default:
+		// A probe is already in flight
+		return halfOpenRetryAfter, false
+	}
+}
+
+// Record reports the outcome of a call that Allow let through
+func (b *CircuitBreaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// isBackendFailure reports whether err means the backend is unhealthy, as
+// opposed to an application error such as NotFound
+func isBackendFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnaryClientCircuitBreakerInterceptor rejects calls with an
+// *errors.CircuitOpenError while breaker is open. It must run inside
+// UnaryClientInterceptor, which maps the rejection to a 503 with Retry-After,
+// and outside UnaryClientRetryInterceptor so a retried call counts once.
+// A nil breaker lets every call through.
+func UnaryClientCircuitBreakerInterceptor(breaker *CircuitBreaker) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if breaker == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		retryAfter, ok := breaker.Allow()
+		if !ok {
+			return &errors.CircuitOpenError{RetryAfter: retryAfter}
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		breaker.Record(isBackendFailure(err))
+		return err
+	}
+}
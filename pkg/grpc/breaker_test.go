@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/errors"
+)
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, 10*time.Second)
+	breaker.now = func() time.Time { return now }
+
+	// Act & Assert: two failures open the circuit
+	for i := 0; i < 2; i++ {
+		if _, ok := breaker.Allow(); !ok {
+			t.Fatalf("call %d: expected closed circuit to allow calls", i+1)
+		}
+		breaker.Record(true)
+	}
+	retryAfter, ok := breaker.Allow()
+	if ok || retryAfter != 10*time.Second {
+		t.Fatalf("expected open circuit to reject with 10s, got ok=%v retryAfter=%s", ok, retryAfter)
+	}
+
+	// After the cooldown a single probe goes through
+	now = now.Add(10 * time.Second)
+	if _, ok := breaker.Allow(); !ok {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	if _, ok := breaker.Allow(); ok {
+		t.Fatal("expected calls to wait for the probe")
+	}
+
+	// A successful probe closes the circuit
+	breaker.Record(false)
+	if _, ok := breaker.Allow(); !ok {
+		t.Error("expected circuit to close after a successful probe")
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, time.Second)
+	breaker.now = func() time.Time { return now }
+	breaker.Allow()
+	breaker.Record(true)
+	now = now.Add(time.Second)
+
+	// Act
+	breaker.Allow()
+	breaker.Record(true)
+
+	// Assert
+	if _, ok := breaker.Allow(); ok {
+		t.Error("expected a failed probe to reopen the circuit")
+	}
+}
+
+func TestUnaryClientCircuitBreakerInterceptor(t *testing.T) {
+	// Arrange
+	interceptor := UnaryClientCircuitBreakerInterceptor(NewCircuitBreaker(1, time.Minute))
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+
+	// Act
+	first := interceptor(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+	second := interceptor(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+
+	// Assert
+	if status.Code(first) != codes.Unavailable {
+		t.Errorf("expected the backend error first, got %v", first)
+	}
+	if !stderrors.Is(second, errors.ErrCircuitOpen) {
+		t.Errorf("expected circuit open error, got %v", second)
+	}
+	if calls != 1 {
+		t.Errorf("expected the open circuit to skip the backend, got %d calls", calls)
+	}
+
+	appErr := errors.FromGRPCStatus(second)
+	if appErr.Code != errors.CodeUnavailable || errors.RetryAfterSeconds(appErr) != 60 {
+		t.Errorf("expected unavailable with a 60s retry-after, got %+v", appErr)
+	}
+}
+
+func TestUnaryClientCircuitBreakerInterceptor_IgnoresApplicationErrors(t *testing.T) {
+	// Arrange
+	interceptor := UnaryClientCircuitBreakerInterceptor(NewCircuitBreaker(1, time.Minute))
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return status.Error(codes.NotFound, "user not found")
+	}
+
+	// Act
+	for i := 0; i < 3; i++ {
+		err := interceptor(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+
+		// Assert
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("call %d: expected not found, got %v", i+1, err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package grpc
 
 import (
 	"context"
+	stderrors "errors"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,7 @@ import (
 
 	"go-micro/pkg/errors"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/tenant"
 )
 
 const (
@@ -35,6 +38,8 @@ func UnaryServerInterceptor(log *logger.Logger, timeout time.Duration) grpc.Unar
 			traceID = uuid.New().String()
 		}
 		ctx = logger.WithTraceIDContext(ctx, traceID)
+		ctx = tenant.WithContext(ctx, metadataValue(ctx, tenant.MetadataKey))
+		ctx = logger.WithField(ctx, "grpc_method", info.FullMethod)
 
 		// Apply timeout
 		if timeout > 0 {
@@ -78,11 +83,14 @@ func UnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
 		invoker grpc.UnaryInvoker,
 		opts ...grpc.CallOption,
 	) error {
-		// Propagate trace ID
+		// Propagate trace ID and tenant
 		traceID := logger.GetTraceID(ctx)
 		if traceID != "" {
 			ctx = metadata.AppendToOutgoingContext(ctx, TraceIDMetadataKey, traceID)
 		}
+		if tenantID := tenant.FromContext(ctx); tenantID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, tenant.MetadataKey, tenantID)
+		}
 
 		// Apply timeout
 		if timeout > 0 {
@@ -101,6 +109,31 @@ func UnaryClientInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
 	}
 }
 
+// UnaryClientDependencyInterceptor names the backend behind a failed call:
+// errors it or the connection to it caused, those answered with a 5xx
+// status, get their Dependency set to dependency so clients can tell them
+// from the caller's own failures. It must run outside UnaryClientInterceptor,
+// which converts the errors to AppErrors.
+func UnaryClientDependencyInterceptor(dependency string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && errors.HTTPStatus(appErr) >= http.StatusInternalServerError {
+			withDependency := *appErr
+			withDependency.Dependency = dependency
+			return &withDependency
+		}
+		return err
+	}
+}
+
 // StreamServerInterceptor creates a stream server interceptor
 func StreamServerInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
 	return func(
@@ -133,12 +166,17 @@ func StreamServerInterceptor(log *logger.Logger) grpc.StreamServerInterceptor {
 }
 
 func extractTraceID(ctx context.Context) string {
+	return metadataValue(ctx, TraceIDMetadataKey)
+}
+
+// metadataValue returns the first incoming metadata value for key, or ""
+func metadataValue(ctx context.Context, key string) string {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return ""
 	}
 
-	values := md.Get(TraceIDMetadataKey)
+	values := md.Get(key)
 	if len(values) > 0 {
 		return values[0]
 	}
@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/errors"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/tenant"
+)
+
+func TestUnaryInterceptors_PropagateTenant(t *testing.T) {
+	// Arrange: the client side of a call made for tenant "acme"
+	ctx := tenant.WithContext(context.Background(), "acme")
+	var outgoing metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		outgoing, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	// Act: send it, then receive it on the server side
+	if err := UnaryClientInterceptor(time.Second)(ctx, "/test.Tenant/Call", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var received string
+	server := UnaryServerInterceptor(logger.New("test", "error"), time.Second)
+	_, err := server(metadata.NewIncomingContext(context.Background(), outgoing), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/test.Tenant/Call"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			received = tenant.FromContext(ctx)
+			return nil, nil
+		})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := outgoing.Get(tenant.MetadataKey); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("expected tenant metadata acme, got %v", got)
+	}
+	if received != "acme" {
+		t.Errorf("expected the handler to act for acme, got %q", received)
+	}
+}
+
+func TestUnaryClientDependencyInterceptor(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantDependency string
+	}{
+		{"backend unavailable", status.Error(codes.Unavailable, "connection refused"), "users-service"},
+		{"backend timed out", status.Error(codes.DeadlineExceeded, "deadline exceeded"), "users-service"},
+		{"backend internal error", status.Error(codes.Internal, "boom"), "users-service"},
+		{"caller's fault", status.Error(codes.InvalidArgument, "bad request"), ""},
+		{"not found", status.Error(codes.NotFound, "no such user"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: the gateway's order, outside the converting interceptor
+			chain := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return UnaryClientInterceptor(time.Second)(ctx, method, req, reply, cc,
+					func(context.Context, string, interface{}, interface{}, *grpc.ClientConn, ...grpc.CallOption) error {
+						return tt.err
+					}, opts...)
+			}
+
+			// Act
+			err := UnaryClientDependencyInterceptor("users-service")(context.Background(), "/test.Users/Get", nil, nil, nil, chain)
+
+			// Assert
+			if got := errors.FromGRPCStatus(err).Dependency; got != tt.wantDependency {
+				t.Errorf("expected dependency %q, got %q", tt.wantDependency, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/metrics"
+)
+
+func TestMessageSize(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  interface{}
+		want int
+	}{
+		{"proto message", &healthpb.HealthCheckRequest{Service: "users"}, 7},
+		{"empty proto message", &healthpb.HealthCheckRequest{}, 0},
+		{"stand-in message as JSON", struct {
+			ID uint64 `json:"id"`
+		}{ID: 1}, len(`{"id":1}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageSize(tt.msg); got != tt.want {
+				t.Errorf("messageSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryServerPayloadSizeInterceptor(t *testing.T) {
+	// Arrange
+	interceptor := UnaryServerPayloadSizeInterceptor()
+	call := func(method string, err error) {
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, _ = interceptor(context.Background(), &healthpb.HealthCheckRequest{}, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &healthpb.HealthCheckResponse{}, err
+			})
+	}
+	requests := testutil.CollectAndCount(metrics.GRPCServerRequestBytes)
+	responses := testutil.CollectAndCount(metrics.GRPCServerResponseBytes)
+
+	// Act
+	call("/test.Payload/Ok", nil)
+	call("/test.Payload/Failed", errors.New("boom"))
+
+	// Assert: both requests are recorded, only the successful response
+	if got := testutil.CollectAndCount(metrics.GRPCServerRequestBytes) - requests; got != 2 {
+		t.Errorf("expected 2 new request series, got %d", got)
+	}
+	if got := testutil.CollectAndCount(metrics.GRPCServerResponseBytes) - responses; got != 1 {
+		t.Errorf("expected 1 new response series, got %d", got)
+	}
+}
+
+func TestUnaryServerMetricsInterceptor(t *testing.T) {
+	// Arrange
+	interceptor := UnaryServerMetricsInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Metrics/Get"}
+	call := func(err error) {
+		_, _ = interceptor(context.Background(), nil, info,
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, err
+			})
+	}
+
+	// Act
+	call(nil)
+	call(status.Error(codes.NotFound, "missing"))
+	call(status.Error(codes.Unavailable, "down"))
+
+	// Assert
+	for _, want := range []struct{ code, outcome string }{
+		{"OK", metrics.OutcomeSuccess},
+		{"NotFound", metrics.OutcomeClientError},
+		{"Unavailable", metrics.OutcomeServerError},
+	} {
+		counter := metrics.GRPCServerRequests.WithLabelValues(info.FullMethod, want.code, want.outcome)
+		if got := testutil.ToFloat64(counter); got != 1 {
+			t.Errorf("expected 1 %s call recorded as %s, got %v", want.code, want.outcome, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"go-micro/pkg/errors"
+	"go-micro/pkg/ratelimit"
+)
+
+// RateLimiter is a token bucket limiting how many calls a server accepts.
+// With perMethod set every method gets its own bucket with the same limits;
+// otherwise all methods share one. The limits can be changed at runtime with
+// SetLimits.
+type RateLimiter struct {
+	limiter   *ratelimit.Limiter
+	perMethod bool
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rate calls per second with bursts
+// of up to burst calls (at least 1). Buckets start full. A rate <= 0 allows
+// every call.
+func NewRateLimiter(rate float64, burst int, perMethod bool) *RateLimiter {
+	return &RateLimiter{
+		limiter:   ratelimit.New(rate, burst),
+		perMethod: perMethod,
+		now:       time.Now,
+	}
+}
+
+// SetLimits replaces the rate and burst of every bucket. Tokens already
+// accumulated are kept, up to the new burst.
+func (l *RateLimiter) SetLimits(rate float64, burst int) {
+	l.limiter.SetLimits(rate, burst)
+}
+
+// Allow reports whether a call to method may proceed, consuming a token if so
+func (l *RateLimiter) Allow(method string) bool {
+	if !l.perMethod {
+		method = ""
+	}
+	return l.limiter.AllowAt(method, l.now())
+}
+
+// UnaryServerRateLimitInterceptor rejects calls once limiter is exhausted.
+// It must run inside UnaryServerInterceptor, which logs the rejection and
+// maps it to codes.ResourceExhausted. A nil limiter allows every call.
+func UnaryServerRateLimitInterceptor(limiter *RateLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if limiter != nil && !limiter.Allow(info.FullMethod) {
+			return nil, errors.NewRateLimited("rate limit exceeded, please retry later")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/errors"
+)
+
+func TestUnaryServerRateLimitInterceptor_RejectsBurstOverflow(t *testing.T) {
+	// Arrange: a burst of 3 and a refill too slow to matter during the test
+	const burst = 3
+	interceptor := UnaryServerRateLimitInterceptor(NewRateLimiter(0.001, burst, false))
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	// Act & Assert
+	for i := 0; i < burst; i++ {
+		if _, err := interceptor(context.Background(), nil, info, handler); err != nil {
+			t.Fatalf("call %d: expected no error, got %v", i+1, err)
+		}
+	}
+
+	_, err := interceptor(context.Background(), nil, info, handler)
+	if !errors.Is(err, errors.CodeRateLimited) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+	if code := status.Code(errors.GRPCStatus(err)); code != codes.ResourceExhausted {
+		t.Errorf("expected code %s, got %s", codes.ResourceExhausted, code)
+	}
+}
+
+func TestRateLimiter_PerMethod(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(0.001, 1, true)
+
+	// Act & Assert: each method has its own bucket
+	if !limiter.Allow("/test.Service/A") || !limiter.Allow("/test.Service/B") {
+		t.Fatal("expected first call to each method to be allowed")
+	}
+	if limiter.Allow("/test.Service/A") {
+		t.Error("expected second call to the same method to be rejected")
+	}
+}
+
+func TestRateLimiter_Refills(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	limiter := NewRateLimiter(10, 1, false)
+	limiter.now = func() time.Time { return now }
+
+	// Act & Assert
+	if !limiter.Allow("m") {
+		t.Fatal("expected first call to be allowed")
+	}
+	if limiter.Allow("m") {
+		t.Fatal("expected bucket to be empty")
+	}
+
+	now = now.Add(100 * time.Millisecond)
+	if !limiter.Allow("m") {
+		t.Error("expected a token after 100ms at 10 calls/s")
+	}
+}
+
+func TestRateLimiter_SetLimits(t *testing.T) {
+	// Arrange: start disabled
+	limiter := NewRateLimiter(0, 1, false)
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow("m") {
+			t.Fatalf("call %d: expected a zero rate to allow every call", i+1)
+		}
+	}
+
+	// Act
+	limiter.SetLimits(0.001, 2)
+
+	// Assert
+	if !limiter.Allow("m") || !limiter.Allow("m") {
+		t.Fatal("expected calls within the new burst to be allowed")
+	}
+	if limiter.Allow("m") {
+		t.Error("expected a call beyond the new burst to be rejected")
+	}
+}
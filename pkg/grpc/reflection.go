@@ -0,0 +1,43 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// reflectionServicePrefix prefixes the methods of every version of the server
+// reflection service
+const reflectionServicePrefix = "/grpc.reflection."
+
+// StreamServerReflectionInterceptor guards server reflection, which lists and
+// describes every service, so it is never more open than the services
+// themselves. With requireClientCert set, reflection calls must come over a
+// connection whose client presented a certificate the server verified, even
+// when the server's TLS config lets other calls through without one, and
+// plaintext connections can't use reflection at all. Other calls pass
+// through.
+func StreamServerReflectionInterceptor(requireClientCert bool) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if requireClientCert && strings.HasPrefix(info.FullMethod, reflectionServicePrefix) && !hasVerifiedClientCert(ss.Context()) {
+			return status.Error(codes.Unauthenticated, "server reflection requires a verified client certificate")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// hasVerifiedClientCert reports whether the caller's connection is TLS with a
+// client certificate verified against the server's client CAs
+func hasVerifiedClientCert(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	return ok && len(tlsInfo.State.VerifiedChains) > 0
+}
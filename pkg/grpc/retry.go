@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go-micro/pkg/metrics"
+)
+
+// RetryBudget is a token bucket shared across requests that caps retries as
+// a fraction of total traffic. Every request deposits ratio tokens and every
+// retry withdraws one, so during an outage retries cannot exceed roughly
+// ratio * requests and won't amplify load on the failing backend.
+type RetryBudget struct {
+	mu sync.Mutex
+	// Tokens are tracked in thousandths to avoid float rounding drift
+	deposit  int64
+	tokens   int64
+	capacity int64
+}
+
+const tokenScale = 1000
+
+// NewRetryBudget creates a retry budget. The bucket starts full so a few
+// retries are allowed before any traffic has been seen.
+func NewRetryBudget(ratio, capacity float64) *RetryBudget {
+	return &RetryBudget{
+		deposit:  int64(ratio * tokenScale),
+		tokens:   int64(capacity * tokenScale),
+		capacity: int64(capacity * tokenScale),
+	}
+}
+
+// Deposit records a request
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.deposit
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// Withdraw takes a token for a retry and reports whether the retry is allowed
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < tokenScale {
+		return false
+	}
+	b.tokens -= tokenScale
+	return true
+}
+
+// UnaryClientRetryInterceptor retries Unavailable errors up to maxAttempts
+// total attempts, as long as the budget allows it. Only the given idempotent
+// methods (full names, e.g. "/users.v1.UserService/GetUser") are retried: an
+// Unavailable error doesn't prove the call had no effect, as the backend may
+// have failed after writing, so retrying a create could apply it twice. It
+// must run inside UnaryClientInterceptor so it sees raw gRPC status errors.
+func UnaryClientRetryInterceptor(budget *RetryBudget, maxAttempts int, backoff time.Duration, idempotentMethods []string) grpc.UnaryClientInterceptor {
+	idempotent := make(map[string]bool, len(idempotentMethods))
+	for _, method := range idempotentMethods {
+		idempotent[method] = true
+	}
+
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if !idempotent[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		budget.Deposit()
+
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || status.Code(err) != codes.Unavailable || attempt >= maxAttempts {
+				return err
+			}
+
+			if !budget.Withdraw() {
+				metrics.GRPCClientRetries.WithLabelValues(method, "throttled").Inc()
+				return err
+			}
+			metrics.GRPCClientRetries.WithLabelValues(method, "attempted").Inc()
+
+			select {
+			case <-time.After(backoff * time.Duration(attempt)):
+			case <-ctx.Done():
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryBudget_CapsRetries(t *testing.T) {
+	// Arrange
+	budget := NewRetryBudget(0.1, 2)
+
+	// Act & Assert: the initial reserve is spent, then retries are throttled
+	if !budget.Withdraw() || !budget.Withdraw() {
+		t.Fatal("expected initial reserve to allow two retries")
+	}
+	if budget.Withdraw() {
+		t.Fatal("expected retry to be throttled once the budget is empty")
+	}
+
+	// Ten requests earn one retry
+	for i := 0; i < 10; i++ {
+		budget.Deposit()
+	}
+	if !budget.Withdraw() {
+		t.Error("expected deposits to refill the budget")
+	}
+}
+
+func TestUnaryClientRetryInterceptor(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         codes.Code
+		budget       *RetryBudget
+		wantAttempts int
+	}{
+		{"retries unavailable", codes.Unavailable, NewRetryBudget(0.1, 10), 3},
+		{"does not retry other errors", codes.NotFound, NewRetryBudget(0.1, 10), 1},
+		{"stops when budget is exhausted", codes.Unavailable, NewRetryBudget(0.1, 0), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			attempts := 0
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				attempts++
+				return status.Error(tt.code, "failure")
+			}
+			interceptor := UnaryClientRetryInterceptor(tt.budget, 3, time.Millisecond, []string{"/test.Service/Get"})
+
+			// Act
+			err := interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, invoker)
+
+			// Assert
+			if status.Code(err) != tt.code {
+				t.Errorf("expected code %v, got %v", tt.code, status.Code(err))
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestUnaryClientRetryInterceptor_SkipsNonIdempotentMethods(t *testing.T) {
+	// Arrange: the backend may have created the resource before failing
+	attempts := 0
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.Unavailable, "failure")
+	}
+	interceptor := UnaryClientRetryInterceptor(NewRetryBudget(0.1, 10), 3, time.Millisecond, []string{"/test.Service/Get"})
+
+	// Act
+	err := interceptor(context.Background(), "/test.Service/Create", nil, nil, nil, invoker)
+
+	// Assert
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected unavailable, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts)
+	}
+}
@@ -0,0 +1,110 @@
+// Package idcodec turns sequential numeric IDs into opaque strings, so public
+// APIs don't reveal how many records exist or let clients enumerate them.
+package idcodec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	stderrors "errors"
+	"math/bits"
+	"strings"
+)
+
+const (
+	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// encodedLen is the number of base62 digits needed for any uint64
+	encodedLen = 11
+	rounds     = 4
+)
+
+// ErrInvalid is returned when a string is not an ID encoded by the codec
+var ErrInvalid = stderrors.New("invalid encoded id")
+
+// Codec encodes IDs with a keyed permutation of the 64-bit space followed by
+// fixed-width base62, so every ID maps to a distinct 11-character string and
+// neighbouring IDs look unrelated. It is obfuscation, not encryption: the salt
+// must stay secret, and changing it invalidates every ID handed out before.
+type Codec struct {
+	keys [rounds]uint64
+}
+
+// New creates a codec keyed by salt
+func New(salt string) *Codec {
+	sum := sha256.Sum256([]byte(salt))
+	c := &Codec{}
+	for i := range c.keys {
+		c.keys[i] = binary.BigEndian.Uint64(sum[i*8:])
+	}
+	return c
+}
+
+// Encode returns the opaque form of id
+func (c *Codec) Encode(id uint64) string {
+	return toBase62(c.permute(id))
+}
+
+// Decode returns the ID encoded in s
+func (c *Codec) Decode(s string) (uint64, error) {
+	v, err := fromBase62(s)
+	if err != nil {
+		return 0, err
+	}
+	return c.unpermute(v), nil
+}
+
+// permute runs a balanced Feistel network over the two 32-bit halves of v
+func (c *Codec) permute(v uint64) uint64 {
+	l, r := uint32(v>>32), uint32(v)
+	for _, k := range c.keys {
+		l, r = r, l^round(r, k)
+	}
+	return uint64(l)<<32 | uint64(r)
+}
+
+// unpermute inverts permute by running the rounds backwards
+func (c *Codec) unpermute(v uint64) uint64 {
+	l, r := uint32(v>>32), uint32(v)
+	for i := rounds - 1; i >= 0; i-- {
+		l, r = r^round(l, c.keys[i]), l
+	}
+	return uint64(l)<<32 | uint64(r)
+}
+
+// round is the Feistel round function: a splitmix64 finalizer over the half
+// block mixed with the round key
+func round(half uint32, key uint64) uint32 {
+	z := uint64(half) ^ key
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return uint32(z ^ (z >> 31))
+}
+
+func toBase62(v uint64) string {
+	var buf [encodedLen]byte
+	for i := encodedLen - 1; i >= 0; i-- {
+		buf[i] = alphabet[v%62]
+		v /= 62
+	}
+	return string(buf[:])
+}
+
+func fromBase62(s string) (uint64, error) {
+	if len(s) != encodedLen {
+		return 0, ErrInvalid
+	}
+
+	var v uint64
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(alphabet, s[i])
+		if digit < 0 {
+			return 0, ErrInvalid
+		}
+		hi, lo := bits.Mul64(v, 62)
+		lo, carry := bits.Add64(lo, uint64(digit), 0)
+		if hi != 0 || carry != 0 {
+			return 0, ErrInvalid
+		}
+		v = lo
+	}
+	return v, nil
+}
@@ -0,0 +1,71 @@
+package idcodec
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := New("test-salt")
+
+	for _, id := range []uint64{0, 1, 2, 42, 1 << 32, math.MaxUint32, math.MaxUint64 - 1, math.MaxUint64} {
+		encoded := codec.Encode(id)
+		if len(encoded) != encodedLen {
+			t.Errorf("Encode(%d) = %q, want %d characters", id, encoded, encodedLen)
+		}
+
+		decoded, err := codec.Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode(%q) failed: %v", encoded, err)
+		}
+		if decoded != id {
+			t.Errorf("round trip of %d returned %d", id, decoded)
+		}
+	}
+}
+
+func TestCodec_IsOpaque(t *testing.T) {
+	codec := New("test-salt")
+
+	// Sequential IDs must not encode to sequential strings
+	seen := make(map[string]bool)
+	prev := codec.Encode(1)
+	for id := uint64(2); id <= 1000; id++ {
+		encoded := codec.Encode(id)
+		if seen[encoded] {
+			t.Fatalf("Encode(%d) = %q collides with an earlier ID", id, encoded)
+		}
+		if encoded[:8] == prev[:8] {
+			t.Errorf("Encode(%d) = %q shares a prefix with Encode(%d) = %q", id, encoded, id-1, prev)
+		}
+		seen[encoded] = true
+		prev = encoded
+	}
+
+	if New("other-salt").Encode(1) == codec.Encode(1) {
+		t.Error("expected different salts to produce different encodings")
+	}
+}
+
+func TestCodec_DecodeInvalid(t *testing.T) {
+	codec := New("test-salt")
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"plain number", "42"},
+		{"too long", codec.Encode(1) + "0"},
+		{"invalid character", "0000000000-"},
+		{"overflows uint64", "zzzzzzzzzzz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := codec.Decode(tt.input); err != ErrInvalid {
+				t.Errorf("Decode(%q) error = %v, want ErrInvalid", tt.input, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"go-micro/pkg/logger"
+)
+
+// RunFunc runs a background component until ctx is cancelled, then returns
+// once its in-flight work is done
+type RunFunc func(ctx context.Context) error
+
+// Manager runs background components (jobs, consumers, relays) and stops
+// them together on shutdown
+type Manager struct {
+	mu         sync.Mutex
+	components []*component
+	log        *logger.Logger
+}
+
+type component struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// NewManager creates a Manager with no components
+func NewManager(log *logger.Logger) *Manager {
+	return &Manager{log: log}
+}
+
+// Go starts run in its own goroutine with its own context. A component that
+// fails before shutdown is logged, and its error is reported again by Stop.
+func (m *Manager) Go(name string, run RunFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &component{name: name, cancel: cancel, done: make(chan struct{})}
+
+	m.mu.Lock()
+	m.components = append(m.components, c)
+	m.mu.Unlock()
+
+	go func() {
+		defer close(c.done)
+		c.err = run(ctx)
+		if c.err != nil && ctx.Err() == nil {
+			m.log.Error("background component failed",
+				zap.String("component", name),
+				zap.Error(c.err),
+			)
+		}
+	}()
+}
+
+// Stop stops the components one at a time, last started first like defers,
+// waiting for each to return before stopping the next. Once ctx is done the
+// remaining components are cancelled without waiting. Stop returns the
+// errors of every component that failed or did not stop in time.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components
+	m.components = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		c.cancel()
+
+		if !waitDone(ctx, c.done) {
+			errs = append(errs, fmt.Errorf("%s did not stop: %w", c.name, ctx.Err()))
+			continue
+		}
+
+		if c.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, c.err))
+			continue
+		}
+		m.log.Info("background component stopped", zap.String("component", c.name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// waitDone waits for done until ctx is done, and reports whether done closed.
+// A component that already returned counts as done even after ctx expired.
+func waitDone(ctx context.Context, done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+	}
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
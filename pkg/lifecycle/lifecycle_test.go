@@ -0,0 +1,69 @@
+package lifecycle
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro/pkg/logger"
+)
+
+func TestManager_StopsInReverseOrder(t *testing.T) {
+	// Arrange
+	manager := NewManager(logger.New("test", "error"))
+	var mu sync.Mutex
+	var stopped []string
+
+	for _, name := range []string{"consumer", "job"} {
+		name := name
+		manager.Go(name, func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			stopped = append(stopped, name)
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	// Act
+	err := manager.Stop(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.Join(stopped, ",") != "job,consumer" {
+		t.Errorf("expected job to stop before consumer, got %v", stopped)
+	}
+}
+
+func TestManager_CollectsErrors(t *testing.T) {
+	// Arrange
+	manager := NewManager(logger.New("test", "error"))
+	failure := stderrors.New("connection lost")
+	manager.Go("failing", func(ctx context.Context) error {
+		return failure
+	})
+	manager.Go("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := manager.Stop(ctx)
+
+	// Assert
+	if !stderrors.Is(err, failure) {
+		t.Errorf("expected the component failure, got %v", err)
+	}
+	if !stderrors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "stuck did not stop") {
+		t.Errorf("expected the stuck component to time out, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"os"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -12,31 +13,54 @@ type ctxKey string
 
 const (
 	traceIDKey ctxKey = "trace_id"
+	fieldsKey  ctxKey = "fields"
 )
 
 // Logger wraps zap.Logger with additional functionality
 type Logger struct {
 	*zap.Logger
 	service string
+	// level is shared by every logger derived from this one, so SetLevel
+	// takes effect everywhere at once
+	level zap.AtomicLevel
+}
+
+// Option configures a Logger created by New
+type Option func(*options)
+
+type options struct {
+	output             zapcore.WriteSyncer
+	samplingInitial    int
+	samplingThereafter int
+}
+
+// WithSampling samples repetitive logs: each second, the first initial
+// entries with the same level and message are logged, then only every
+// thereafter-th one. Errors and above are never sampled. initial <= 0
+// disables sampling.
+func WithSampling(initial, thereafter int) Option {
+	return func(o *options) {
+		o.samplingInitial = initial
+		o.samplingThereafter = thereafter
+	}
+}
+
+// withOutput redirects the logger, for tests
+func withOutput(output zapcore.WriteSyncer) Option {
+	return func(o *options) {
+		o.output = output
+	}
 }
 
 // New creates a new logger instance
-func New(service, level string) *Logger {
-	// Parse log level
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
-	case "warn":
-		zapLevel = zapcore.WarnLevel
-	case "error":
-		zapLevel = zapcore.ErrorLevel
-	default:
-		zapLevel = zapcore.InfoLevel
+func New(service, level string, opts ...Option) *Logger {
+	o := options{output: zapcore.AddSync(os.Stdout)}
+	for _, opt := range opts {
+		opt(&o)
 	}
 
+	atomicLevel := zap.NewAtomicLevelAt(parseLevel(level))
+
 	// Configure encoder
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "timestamp",
@@ -54,11 +78,25 @@ func New(service, level string) *Logger {
 	}
 
 	// Create core
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(os.Stdout),
-		zapLevel,
-	)
+	encoder := zapcore.NewJSONEncoder(encoderConfig)
+	core := zapcore.NewCore(encoder, o.output, atomicLevel)
+
+	if o.samplingInitial > 0 {
+		// Sample below error level only, so errors are always emitted
+		belowError := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return atomicLevel.Enabled(l) && l < zapcore.ErrorLevel
+		})
+		errorAndAbove := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return atomicLevel.Enabled(l) && l >= zapcore.ErrorLevel
+		})
+		core = zapcore.NewTee(
+			zapcore.NewSamplerWithOptions(
+				zapcore.NewCore(encoder, o.output, belowError),
+				time.Second, o.samplingInitial, o.samplingThereafter,
+			),
+			zapcore.NewCore(encoder, o.output, errorAndAbove),
+		)
+	}
 
 	// Create logger with service field
 	zapLogger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
@@ -67,6 +105,33 @@ func New(service, level string) *Logger {
 	return &Logger{
 		Logger:  zapLogger,
 		service: service,
+		level:   atomicLevel,
+	}
+}
+
+// SetLevel changes the minimum level logged, at runtime
+func (l *Logger) SetLevel(level string) {
+	l.level.SetLevel(parseLevel(level))
+}
+
+// Level returns the minimum level currently logged
+func (l *Logger) Level() string {
+	return l.level.Level().String()
+}
+
+// parseLevel maps a level name to a zap level, defaulting to info
+func parseLevel(level string) zapcore.Level {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
 	}
 }
 
@@ -78,15 +143,40 @@ func (l *Logger) WithTraceID(ctx context.Context) *zap.Logger {
 	return l.Logger
 }
 
-// WithContext returns a logger with context fields
+// WithContext returns a logger with context fields: the trace ID and any
+// fields added with WithField or WithFields
 func (l *Logger) WithContext(ctx context.Context) *zap.Logger {
 	logger := l.Logger
 	if traceID := GetTraceID(ctx); traceID != "" {
 		logger = logger.With(zap.String("trace_id", traceID))
 	}
+	if fields := Fields(ctx); len(fields) > 0 {
+		logger = logger.With(fields...)
+	}
 	return logger
 }
 
+// WithField returns a context whose WithContext loggers include key=value
+func WithField(ctx context.Context, key string, value interface{}) context.Context {
+	return WithFields(ctx, zap.Any(key, value))
+}
+
+// WithFields returns a context whose WithContext loggers include fields.
+// Fields already in ctx are kept; the parent context is not modified.
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := Fields(ctx)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey, merged)
+}
+
+// Fields returns the request-scoped fields stored in ctx
+func Fields(ctx context.Context) []zap.Field {
+	fields, _ := ctx.Value(fieldsKey).([]zap.Field)
+	return fields
+}
+
 // WithTraceIDContext adds a trace ID to the context
 func WithTraceIDContext(ctx context.Context, traceID string) context.Context {
 	return context.WithValue(ctx, traceIDKey, traceID)
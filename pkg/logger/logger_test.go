@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithContext_IncludesFields(t *testing.T) {
+	// Arrange
+	core, logs := observer.New(zap.DebugLevel)
+	log := &Logger{Logger: zap.New(core)}
+
+	ctx := WithTraceIDContext(context.Background(), "trace-1")
+	ctx = WithField(ctx, "route", "/api/v1/orders/:id")
+	child := WithField(ctx, "user_id", uint(7))
+
+	// Act
+	log.WithContext(child).Info("child")
+	log.WithContext(ctx).Info("parent")
+
+	// Assert
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+
+	childFields := entries[0].ContextMap()
+	if childFields["trace_id"] != "trace-1" || childFields["route"] != "/api/v1/orders/:id" || childFields["user_id"] != uint64(7) {
+		t.Errorf("unexpected child fields: %v", childFields)
+	}
+
+	parentFields := entries[1].ContextMap()
+	if _, ok := parentFields["user_id"]; ok {
+		t.Errorf("expected parent context to be unaffected by child fields, got %v", parentFields)
+	}
+	if parentFields["route"] != "/api/v1/orders/:id" {
+		t.Errorf("expected parent route field, got %v", parentFields)
+	}
+}
+
+func TestFields_Empty(t *testing.T) {
+	if fields := Fields(context.Background()); len(fields) != 0 {
+		t.Errorf("expected no fields, got %v", fields)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	// Arrange
+	log := New("test", "info")
+	child := log.With(zap.String("component", "child"))
+
+	// Act
+	log.SetLevel("debug")
+
+	// Assert: the change reaches loggers derived before it
+	if log.Level() != "debug" {
+		t.Errorf("expected level debug, got %s", log.Level())
+	}
+	if !child.Core().Enabled(zap.DebugLevel) {
+		t.Error("expected derived logger to log at debug level")
+	}
+
+	log.SetLevel("bogus")
+	if log.Level() != "info" {
+		t.Errorf("expected unknown level to fall back to info, got %s", log.Level())
+	}
+}
+
+func TestWithSampling(t *testing.T) {
+	// Arrange
+	out := &zaptest.Buffer{}
+	log := New("test", "info", WithSampling(2, 5), withOutput(out))
+
+	// Act
+	for i := 0; i < 10; i++ {
+		log.Info("http request")
+		log.Error("request failed")
+	}
+
+	// Assert: entries 1, 2 and 7 of the info burst, and every error
+	var infos, errs int
+	for _, line := range out.Lines() {
+		switch {
+		case strings.Contains(line, `"message":"http request"`):
+			infos++
+		case strings.Contains(line, `"message":"request failed"`):
+			errs++
+		}
+	}
+	if infos != 3 {
+		t.Errorf("expected 3 sampled info entries, got %d", infos)
+	}
+	if errs != 10 {
+		t.Errorf("expected every error to be logged, got %d", errs)
+	}
+}
+
+func TestNew_NoSamplingByDefault(t *testing.T) {
+	// Arrange
+	out := &zaptest.Buffer{}
+	log := New("test", "info", withOutput(out))
+
+	// Act
+	for i := 0; i < 200; i++ {
+		log.Info("http request")
+	}
+
+	// Assert
+	if n := len(out.Lines()); n != 200 {
+		t.Errorf("expected 200 entries, got %d", n)
+	}
+}
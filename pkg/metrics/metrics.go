@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+)
+
+const namespace = "gomicro"
+
+// Outcome label values, which group responses by who is at fault so error
+// rates can be computed without enumerating status codes
+const (
+	OutcomeSuccess     = "success"
+	OutcomeClientError = "client_error"
+	OutcomeServerError = "server_error"
+)
+
+// HTTP metrics
+var (
+	// HTTPRequests counts HTTP requests by method, route, status and outcome
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests, by method, route, status and outcome.",
+	}, []string{"method", "route", "status", "outcome"})
+
+	// HTTPRequestDuration records HTTP request latency by method, route and outcome
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency in seconds, by method, route and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "outcome"})
+
+	// RejectedRequests counts HTTP requests rejected by load shedding
+	RejectedRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "rejected_requests_total",
+		Help:      "Total number of HTTP requests rejected because the concurrency limit was reached.",
+	})
+)
+
+// gRPC metrics
+var (
+	// GRPCServerRequests counts handled gRPC calls by method, code and outcome
+	GRPCServerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "requests_total",
+		Help:      "Total number of gRPC requests handled, by method, code and outcome.",
+	}, []string{"method", "code", "outcome"})
+
+	// GRPCServerRequestDuration records gRPC handling latency by method and outcome
+	GRPCServerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC request latency in seconds, by method and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "outcome"})
+
+	// GRPCClientRetries counts client retries by method and outcome
+	// (attempted, or throttled by the retry budget)
+	GRPCClientRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_client",
+		Name:      "retries_total",
+		Help:      "Total number of gRPC client retries, by method and outcome.",
+	}, []string{"method", "outcome"})
+
+	// GRPCServerRequestBytes and GRPCServerResponseBytes record the size of
+	// the messages each server method receives and returns
+	GRPCServerRequestBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "request_bytes",
+		Help:      "Size of gRPC requests received, by method.",
+		Buckets:   payloadSizeBuckets,
+	}, []string{"method"})
+	GRPCServerResponseBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc_server",
+		Name:      "response_bytes",
+		Help:      "Size of successful gRPC responses sent, by method.",
+		Buckets:   payloadSizeBuckets,
+	}, []string{"method"})
+)
+
+// payloadSizeBuckets range from 64 bytes to 1 MiB
+var payloadSizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+
+// Messaging metrics
+var (
+	// DuplicateEvents counts redelivered events skipped by consumer deduplication
+	DuplicateEvents = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "duplicate_events_total",
+		Help:      "Total number of consumed events skipped because their event ID was already processed.",
+	})
+)
+
+func init() {
+	MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		RejectedRequests,
+		GRPCServerRequests,
+		GRPCServerRequestDuration,
+		GRPCClientRetries,
+		GRPCServerRequestBytes,
+		GRPCServerResponseBytes,
+		DuplicateEvents,
+	)
+}
+
+// Register registers c with the default registry. A collector that is
+// already registered is not an error: the registered one is returned for the
+// caller to use instead, so instrumenting twice in one process (as tests that
+// start several servers do) shares the metrics rather than failing.
+func Register(c prometheus.Collector) (prometheus.Collector, error) {
+	return RegisterWith(prometheus.DefaultRegisterer, c)
+}
+
+// RegisterWith is Register for registerer r
+func RegisterWith(r prometheus.Registerer, c prometheus.Collector) (prometheus.Collector, error) {
+	if err := r.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			return already.ExistingCollector, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// MustRegister registers cs with the default registry like Register, and
+// panics only if a collector conflicts with a different registered one
+func MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if _, err := Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ObserveHTTPRequest records a finished HTTP request in the request counter
+// and latency histogram, labelled with the outcome of its status
+func ObserveHTTPRequest(method, route string, status int, seconds float64) {
+	outcome := HTTPOutcome(status)
+	HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status), outcome).Inc()
+	HTTPRequestDuration.WithLabelValues(method, route, outcome).Observe(seconds)
+}
+
+// ObserveGRPCRequest records a handled gRPC call in the request counter and
+// latency histogram, labelled with the outcome of its code
+func ObserveGRPCRequest(method string, code codes.Code, seconds float64) {
+	outcome := GRPCOutcome(code)
+	GRPCServerRequests.WithLabelValues(method, code.String(), outcome).Inc()
+	GRPCServerRequestDuration.WithLabelValues(method, outcome).Observe(seconds)
+}
+
+// HTTPOutcome classifies an HTTP status: 5xx is a server error, 4xx a client
+// error and anything else (2xx, 3xx) a success
+func HTTPOutcome(status int) string {
+	switch {
+	case status >= 500:
+		return OutcomeServerError
+	case status >= 400:
+		return OutcomeClientError
+	default:
+		return OutcomeSuccess
+	}
+}
+
+// GRPCOutcome classifies a gRPC code the way its HTTP mapping would: codes
+// caused by the request or the caller are client errors, the rest server errors
+func GRPCOutcome(code codes.Code) string {
+	switch code {
+	case codes.OK:
+		return OutcomeSuccess
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted:
+		return OutcomeClientError
+	default:
+		return OutcomeServerError
+	}
+}
+
+// Handler returns the HTTP handler that exposes the registered metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
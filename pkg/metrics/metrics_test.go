@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+)
+
+func TestHTTPOutcome(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, OutcomeSuccess},
+		{http.StatusNoContent, OutcomeSuccess},
+		{http.StatusNotModified, OutcomeSuccess},
+		{http.StatusBadRequest, OutcomeClientError},
+		{http.StatusTooManyRequests, OutcomeClientError},
+		{http.StatusInternalServerError, OutcomeServerError},
+		{http.StatusServiceUnavailable, OutcomeServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if got := HTTPOutcome(tt.status); got != tt.want {
+				t.Errorf("HTTPOutcome(%d) = %q, want %q", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCOutcome(t *testing.T) {
+	tests := []struct {
+		code codes.Code
+		want string
+	}{
+		{codes.OK, OutcomeSuccess},
+		{codes.InvalidArgument, OutcomeClientError},
+		{codes.NotFound, OutcomeClientError},
+		{codes.Unauthenticated, OutcomeClientError},
+		{codes.ResourceExhausted, OutcomeClientError},
+		{codes.Internal, OutcomeServerError},
+		{codes.Unavailable, OutcomeServerError},
+		{codes.DeadlineExceeded, OutcomeServerError},
+		{codes.Unknown, OutcomeServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := GRPCOutcome(tt.code); got != tt.want {
+				t.Errorf("GRPCOutcome(%s) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterWith_AlreadyRegistered(t *testing.T) {
+	// Arrange
+	registry := prometheus.NewRegistry()
+	newCounter := func(help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total", Help: help}, labels)
+	}
+	first := newCounter("Test counter.", "method")
+	if _, err := RegisterWith(registry, first); err != nil {
+		t.Fatalf("expected first registration to succeed, got %v", err)
+	}
+
+	// Act: a second instance, as a second server in the process would create
+	existing, err := RegisterWith(registry, newCounter("Test counter.", "method"))
+
+	// Assert: the first one is handed back to be shared
+	if err != nil {
+		t.Fatalf("expected duplicate registration to be tolerated, got %v", err)
+	}
+	if existing != first {
+		t.Errorf("expected the registered collector back, got %v", existing)
+	}
+
+	// A collector conflicting with the registered one is still an error
+	if _, err := RegisterWith(registry, newCounter("Test counter.", "route")); err == nil {
+		t.Error("expected a conflicting registration to fail")
+	}
+}
+
+func TestMustRegister_Twice(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("expected re-registering the service metrics not to panic, got %v", r)
+		}
+	}()
+
+	// The package already registered these in init
+	MustRegister(HTTPRequests, GRPCServerRequests)
+}
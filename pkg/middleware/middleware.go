@@ -1,45 +1,392 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
 	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 
+	"go-micro/pkg/auth"
 	"go-micro/pkg/errors"
+	"go-micro/pkg/idcodec"
 	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
+	"go-micro/pkg/ratelimit"
+	"go-micro/pkg/tenant"
 )
 
 const (
 	// TraceIDHeader is the header name for trace ID
 	TraceIDHeader = "X-Trace-ID"
+	// UpstreamTraceIDHeader echoes a caller's trace ID that was not trusted
+	UpstreamTraceIDHeader = "X-Upstream-Trace-ID"
 	// TraceIDKey is the context key for trace ID
 	TraceIDKey = "trace_id"
+	// IDsAsStringKey is the context key set when IDs must be serialized as strings
+	IDsAsStringKey = "ids_as_string"
+	// CamelCaseKey is the context key set when JSON field names must be camelCase
+	CamelCaseKey = "camel_case"
+	// IDCodecKey is the context key holding the codec set by ObfuscateIDs
+	IDCodecKey = "id_codec"
+	// JSONBodyMaxBytesKey and JSONBodyTimeoutKey hold the limits set by JSONBodyLimit
+	JSONBodyMaxBytesKey = "json_body_max_bytes"
+	JSONBodyTimeoutKey  = "json_body_timeout"
+	// ClaimsKey is the context key holding the caller's claims set by Authenticate
+	ClaimsKey = "auth_claims"
 )
 
-// ErrorHandler is a middleware that handles errors and panics
+// Limits applied by BindJSON when JSONBodyLimit is not in the chain
+const (
+	DefaultJSONBodyMaxBytes = 1 << 20
+	DefaultJSONBodyTimeout  = 10 * time.Second
+)
+
+// SuccessResponse is the standard envelope for successful responses
+type SuccessResponse struct {
+	Data    interface{} `json:"data"`
+	TraceID string      `json:"trace_id"`
+}
+
+// RespondSuccess writes data in the standard success envelope with the trace ID.
+// When IDsAsString is active for the request, ID fields are written as strings;
+// when ObfuscateIDs is, they are written in their encoded form; when
+// CamelCaseJSON is, field names are written in camelCase.
+func RespondSuccess(c *gin.Context, status int, data interface{}) {
+	if codec := idCodec(c); codec != nil {
+		data = rewriteIDs(data, func(n json.Number) interface{} {
+			id, err := strconv.ParseUint(n.String(), 10, 64)
+			if err != nil {
+				return n
+			}
+			return codec.Encode(id)
+		})
+	} else if c.GetBool(IDsAsStringKey) {
+		data = rewriteIDs(data, func(n json.Number) interface{} {
+			return n.String()
+		})
+	}
+	var response interface{} = SuccessResponse{
+		Data:    data,
+		TraceID: c.GetString(TraceIDKey),
+	}
+	if c.GetBool(CamelCaseKey) {
+		response = camelCaseKeys(response)
+	}
+	c.JSON(status, response)
+}
+
+// RespondCreated writes data with 201 Created and a Location header pointing
+// to the new resource, i.e. the request path followed by id
+func RespondCreated(c *gin.Context, id uint64, data interface{}) {
+	idStr := strconv.FormatUint(id, 10)
+	if codec := idCodec(c); codec != nil {
+		idStr = codec.Encode(id)
+	}
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+idStr)
+	RespondSuccess(c, http.StatusCreated, data)
+}
+
+// RespondError records err and aborts the chain; ErrorHandler writes the
+// standard error envelope once the handlers return
+func RespondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// JSONBodyLimit sets the size and time limits BindJSON applies to request
+// bodies; a value <= 0 keeps the default
+func JSONBodyLimit(maxBytes int64, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxBytes > 0 {
+			c.Set(JSONBodyMaxBytesKey, maxBytes)
+		}
+		if timeout > 0 {
+			c.Set(JSONBodyTimeoutKey, timeout)
+		}
+		c.Next()
+	}
+}
+
+// BindJSON decodes and validates the JSON request body into obj like
+// ShouldBindJSON, but first reads the whole body within the limits set by
+// JSONBodyLimit. A body that is too large fails with a request too large
+// error (413), and one the client sends too slowly with a validation error,
+// instead of holding the handler for as long as the server's read timeout
+// allows.
+func BindJSON(c *gin.Context, obj interface{}) error {
+	body, err := readBody(c)
+	if err != nil {
+		return err
+	}
+	if err := binding.JSON.BindBody(body, obj); err != nil {
+		return bindingError("invalid request body", err, fieldPath)
+	}
+	return nil
+}
+
+// BindQuery decodes and validates the query string into obj like
+// ShouldBindQuery. Values that don't parse as their field's type are listed
+// under "fields" like rule violations, keyed by the parameter name (e.g.
+// "page_size": "must be an integer"), instead of failing with strconv's
+// message that doesn't say which parameter was wrong.
+func BindQuery(c *gin.Context, obj interface{}) error {
+	fields := map[string]string{}
+	queryTypeErrors(c.Request.URL.Query(), reflect.TypeOf(obj), fields)
+	if len(fields) > 0 {
+		return errors.NewValidation("invalid query parameters", map[string]interface{}{
+			"fields": fields,
+		})
+	}
+	if err := c.ShouldBindQuery(obj); err != nil {
+		return bindingError("invalid query parameters", err, queryParam)
+	}
+	return nil
+}
+
+// queryTypeErrors records in fields the query parameters of t's form-tagged
+// fields, including those of embedded structs, whose values don't parse as
+// the field's type. Empty values are skipped as gin binds them as zero.
+func queryTypeErrors(query url.Values, t reflect.Type, fields map[string]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			queryTypeErrors(query, field.Type, fields)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		kind := field.Type
+		for kind.Kind() == reflect.Pointer || kind.Kind() == reflect.Slice {
+			kind = kind.Elem()
+		}
+		for _, value := range query[name] {
+			if value == "" {
+				continue
+			}
+			if msg := parseError(value, kind); msg != "" {
+				fields[name] = msg
+				break
+			}
+		}
+	}
+}
+
+// parseError describes why value doesn't parse as a t, or returns "" if it does
+// or t isn't a basic type
+func parseError(value string, t reflect.Type) string {
+	var err error
+	msg := ""
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return ""
+		}
+		_, err = strconv.ParseInt(value, 10, t.Bits())
+		msg = "must be an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, err = strconv.ParseUint(value, 10, t.Bits())
+		msg = "must be a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(value, t.Bits())
+		msg = "must be a number"
+	case reflect.Bool:
+		_, err = strconv.ParseBool(value)
+		msg = "must be a boolean"
+	default:
+		return ""
+	}
+
+	if err == nil {
+		return ""
+	}
+	if stderrors.Is(err, strconv.ErrRange) {
+		return "is out of range"
+	}
+	return msg
+}
+
+func init() {
+	// Report invalid fields by their JSON or query names, as clients know them
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+// fieldName names a struct field after its json tag, or its form tag for
+// query parameters, falling back to the Go name for untagged fields
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name, _, _ = strings.Cut(field.Tag.Get("form"), ",")
+	}
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// bindingError turns a failed bind into a validation error with message.
+// Rule violations are listed under "fields", keyed by key (e.g. the path of
+// the invalid field within the body, "items[0].quantity") and valued with the
+// broken rule (e.g. "gt=0"); other failures such as malformed JSON keep the
+// decoder's message.
+func bindingError(message string, err error, key func(validator.FieldError) string) error {
+	var verrs validator.ValidationErrors
+	if !stderrors.As(err, &verrs) {
+		return errors.NewValidation(message, err.Error())
+	}
+
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		fields[key(fe)] = rule
+	}
+	return errors.NewValidation(message, map[string]interface{}{
+		"fields": fields,
+	})
+}
+
+// fieldPath drops the name of the top-level struct from a validator
+// namespace, leaving the field's path within the body
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// queryParam names a field by its query parameter alone: query strings are
+// flat, so fields of embedded structs have no path
+func queryParam(fe validator.FieldError) string {
+	return fe.Field()
+}
+
+// readBody reads the request body within the JSONBodyLimit limits and
+// restores it for later readers
+func readBody(c *gin.Context) ([]byte, error) {
+	maxBytes := int64(DefaultJSONBodyMaxBytes)
+	if v, ok := c.Get(JSONBodyMaxBytesKey); ok {
+		maxBytes = v.(int64)
+	}
+	timeout := DefaultJSONBodyTimeout
+	if v, ok := c.Get(JSONBodyTimeoutKey); ok {
+		timeout = v.(time.Duration)
+	}
+
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+
+	// The connection deadline unblocks the read below when the writer
+	// supports it; the timer bounds the wait when it does not
+	rc := http.NewResponseController(c.Writer)
+	deadlineSet := rc.SetReadDeadline(time.Now().Add(timeout)) == nil
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBytes+1))
+		done <- result{body, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			if stderrors.Is(r.err, os.ErrDeadlineExceeded) {
+				return nil, errors.NewValidation("timed out reading request body", nil)
+			}
+			return nil, errors.NewValidation("failed to read request body", nil)
+		}
+		if deadlineSet {
+			_ = rc.SetReadDeadline(time.Time{})
+		}
+		if int64(len(r.body)) > maxBytes {
+			return nil, errors.NewRequestTooLarge("request body too large", map[string]int64{"max_bytes": maxBytes})
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(r.body))
+		return r.body, nil
+	case <-timer.C:
+		return nil, errors.NewValidation("timed out reading request body", nil)
+	}
+}
+
+// ErrorHandler is a middleware that handles errors and panics.
+// Handlers should return errors via RespondError; panicking with an *AppError
+// is only meant for helpers that cannot return one (e.g. deep in a binding
+// callback) and is answered with that error's status instead of a 500.
 func ErrorHandler(log *logger.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if r := recover(); r != nil {
 				traceID := c.GetString(TraceIDKey)
-				log.WithContext(c.Request.Context()).Error("panic recovered",
-					zap.Any("panic", r),
-					zap.String("stack", string(debug.Stack())),
+				kind, err := classifyPanic(r)
+
+				// A deliberate panic with an *AppError keeps its status and code;
+				// anything else is reported as a generic 500.
+				statusCode, jsonResponse, marshalErr := errors.Encode(err, traceID)
+
+				fields := []zap.Field{
+					zap.String("panic_kind", kind),
+					zap.Int("status", statusCode),
 					zap.String("trace_id", traceID),
-				)
+				}
+				if marshalErr != nil {
+					fields = append(fields, zap.NamedError("marshal_error", marshalErr))
+				}
+				if err != nil {
+					fields = append(fields, zap.Error(err))
+				} else {
+					fields = append(fields, zap.Any("panic", r))
+				}
+				if kind != panicKindAppError {
+					fields = append(fields, zap.String("stack", string(debug.Stack())))
+				}
+				log.WithContext(c.Request.Context()).Error("panic recovered", fields...)
 
 				c.Header(TraceIDHeader, traceID)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, errors.ErrorResponse{
-					Error: errors.ErrorBody{
-						Code:    errors.CodeInternal,
-						Message: "An internal error occurred",
-					},
-					TraceID: traceID,
-				})
+				c.Abort()
+				c.Data(statusCode, "application/json", errorJSON(c, encodeErrorIDs(c, jsonResponse)))
 			}
 		}()
 
@@ -49,7 +396,13 @@ func ErrorHandler(log *logger.Logger) gin.HandlerFunc {
 		if len(c.Errors) > 0 {
 			err := c.Errors.Last().Err
 			traceID := c.GetString(TraceIDKey)
-			statusCode, jsonResponse := errors.ToJSON(err, traceID)
+			statusCode, jsonResponse, marshalErr := errors.Encode(err, traceID)
+			if marshalErr != nil {
+				log.WithContext(c.Request.Context()).Error("failed to marshal error response",
+					zap.Error(marshalErr),
+					zap.String("trace_id", traceID),
+				)
+			}
 
 			log.WithContext(c.Request.Context()).Error("request error",
 				zap.Error(err),
@@ -58,37 +411,186 @@ func ErrorHandler(log *logger.Logger) gin.HandlerFunc {
 			)
 
 			c.Header(TraceIDHeader, traceID)
-			c.Data(statusCode, "application/json", jsonResponse)
+			if seconds := errors.RetryAfterSeconds(err); seconds > 0 {
+				c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+			}
+			c.Data(statusCode, "application/json", errorJSON(c, encodeErrorIDs(c, jsonResponse)))
 		}
 	}
 }
 
-// TraceID is a middleware that generates or extracts trace ID
-func TraceID() gin.HandlerFunc {
+// errorJSON is the encoded error envelope body as the request wants it
+func errorJSON(c *gin.Context, body []byte) []byte {
+	if !c.GetBool(CamelCaseKey) {
+		return body
+	}
+	if camel, err := json.Marshal(camelCaseKeys(json.RawMessage(body))); err == nil {
+		return camel
+	}
+	return body
+}
+
+// notFoundID matches the ID in the message of errors.NewNotFound errors,
+// e.g. "order with id '42' not found"
+var notFoundID = regexp.MustCompile(`with id '(\d+)'`)
+
+// encodeErrorIDs encodes the IDs of an error body when ObfuscateIDs is
+// active, so errors don't reveal what successful responses hide: numeric ID
+// fields of the details, like RespondSuccess does for data, and the ID of a
+// not found message. Bodies that don't parse are returned unchanged.
+func encodeErrorIDs(c *gin.Context, body []byte) []byte {
+	codec := idCodec(c)
+	if codec == nil {
+		return body
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var response map[string]interface{}
+	if err := decoder.Decode(&response); err != nil {
+		return body
+	}
+	errorBody, ok := response["error"].(map[string]interface{})
+	if !ok {
+		return body
+	}
+
+	if message, ok := errorBody["message"].(string); ok {
+		errorBody["message"] = notFoundID.ReplaceAllStringFunc(message, func(match string) string {
+			id, err := strconv.ParseUint(notFoundID.FindStringSubmatch(match)[1], 10, 64)
+			if err != nil {
+				return match
+			}
+			return "with id '" + codec.Encode(id) + "'"
+		})
+	}
+	if details, ok := errorBody["details"]; ok {
+		errorBody["details"] = transformIDs(details, func(_ string, v interface{}) interface{} {
+			n, ok := v.(json.Number)
+			if !ok {
+				return v
+			}
+			id, err := strconv.ParseUint(n.String(), 10, 64)
+			if err != nil {
+				return v
+			}
+			return codec.Encode(id)
+		})
+	}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// Panic kinds reported by ErrorHandler
+const (
+	panicKindAppError = "app_error"
+	panicKindRuntime  = "runtime"
+	panicKindError    = "error"
+	panicKindValue    = "value"
+)
+
+// classifyPanic reports what kind of value was recovered and, when it is an
+// error, returns it
+func classifyPanic(r interface{}) (string, error) {
+	err, ok := r.(error)
+	if !ok {
+		return panicKindValue, nil
+	}
+
+	var appErr *errors.AppError
+	var runtimeErr runtime.Error
+	switch {
+	case stderrors.As(err, &appErr):
+		return panicKindAppError, err
+	case stderrors.As(err, &runtimeErr):
+		return panicKindRuntime, err
+	default:
+		return panicKindError, err
+	}
+}
+
+// TraceID is a middleware that generates or extracts trace ID. With
+// trustIncoming the caller's X-Trace-ID is adopted; otherwise every request
+// gets a fresh trace ID and the caller's one, if any, is kept for correlation
+// in the X-Upstream-Trace-ID response header and the upstream_trace_id log
+// field.
+func TraceID(trustIncoming bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traceID := c.GetHeader(TraceIDHeader)
-		if traceID == "" {
+		incoming := c.GetHeader(TraceIDHeader)
+		traceID := incoming
+		if !trustIncoming || traceID == "" {
 			traceID = uuid.New().String()
 		}
 
 		c.Set(TraceIDKey, traceID)
 		c.Header(TraceIDHeader, traceID)
 
-		// Add trace ID to request context
+		// Add trace ID and the matched route to the request context so every
+		// log line written with it carries them
 		ctx := logger.WithTraceIDContext(c.Request.Context(), traceID)
+		ctx = logger.WithField(ctx, "route", c.FullPath())
+		if incoming != "" && incoming != traceID {
+			c.Header(UpstreamTraceIDHeader, incoming)
+			ctx = logger.WithField(ctx, "upstream_trace_id", incoming)
+		}
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
 	}
 }
 
-// RequestLogger logs all HTTP requests
-func RequestLogger(log *logger.Logger) gin.HandlerFunc {
+// Tenant sets the tenant the request acts for: its context, and so its logs,
+// gRPC calls and events, act for that tenant. Callers authenticated with a
+// token by Authenticate (which must run earlier) act for the tenant of their
+// token, and an X-Tenant-ID header naming another one is forbidden; anonymous
+// callers and the admin API key name the tenant with the header. Malformed
+// tenant IDs are rejected, and so are requests without a tenant when
+// required; otherwise such requests act for the default tenant.
+func Tenant(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(tenant.Header)
+		if claims := Claims(c); claims != nil && claims.Subject != adminAPIKeySubject {
+			if id != "" && id != claims.Tenant {
+				RespondError(c, errors.NewForbidden(tenant.Header+" does not match the caller's tenant"))
+				return
+			}
+			id = claims.Tenant
+		}
+		if id == "" {
+			// Preflight requests carry no custom headers
+			if required && c.Request.Method != http.MethodOptions {
+				RespondError(c, errors.NewValidation("missing "+tenant.Header+" header", nil))
+			}
+			return
+		}
+		if err := tenant.Validate(id); err != nil {
+			RespondError(c, err)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithContext(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// RequestLogger logs all HTTP requests except those under excludePaths.
+// An entry excludes the path itself and its subpaths, so "/health" covers
+// "/health/ready" but not "/healthz".
+func RequestLogger(log *logger.Logger, excludePaths ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
 
+		if isExcludedPath(path, excludePaths) {
+			c.Next()
+			return
+		}
+
 		c.Next()
 
 		latency := time.Since(start)
@@ -107,19 +609,708 @@ func RequestLogger(log *logger.Logger) gin.HandlerFunc {
 	}
 }
 
-// CORS is a middleware that handles CORS
-func CORS() gin.HandlerFunc {
+// Metrics records each request in the HTTP request metrics, labelled by its
+// route template (so path parameters don't explode cardinality) and outcome.
+// Requests matching no route are recorded under the "unmatched" route.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start).Seconds())
+	}
+}
+
+// isExcludedPath reports whether path is one of prefixes or below one of them
+func isExcludedPath(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminAuth restricts access to requests carrying the admin API key in the
+// Authorization header ("Bearer <key>"). An empty key disables admin access.
+func AdminAuth(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
+			RespondError(c, errors.NewUnauthorized("invalid or missing admin credentials"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminAPIKeySubject is the subject of the claims granted to the admin API key
+const adminAPIKeySubject = "admin-api-key"
+
+// Authenticate identifies the caller from the Authorization header
+// ("Bearer <token>") and stores their claims under ClaimsKey and in the
+// request context (see auth.ClaimsFromContext): an access token verified by
+// tokens yields its claims, and the admin API key counts as the admin role.
+// Other callers stay anonymous rather than being rejected, so public routes
+// keep working; RequireRole guards the others. A nil tokens or an empty
+// apiKey disables that kind of credential.
+func Authenticate(tokens *auth.TokenIssuer, apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.Next()
+			return
+		}
+
+		var claims *auth.Claims
+		if apiKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) == 1 {
+			claims = &auth.Claims{Subject: adminAPIKeySubject, Role: auth.RoleAdmin}
+		} else if tokens != nil {
+			if verified, err := tokens.Verify(token); err == nil {
+				claims = verified
+			}
+		}
+		if claims != nil {
+			c.Set(ClaimsKey, claims)
+			c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), claims))
+		}
+		c.Next()
+	}
+}
+
+// Claims returns the caller's claims set by Authenticate, or nil for
+// anonymous callers
+func Claims(c *gin.Context) *auth.Claims {
+	if v, ok := c.Get(ClaimsKey); ok {
+		return v.(*auth.Claims)
+	}
+	return nil
+}
+
+// RequireRole restricts access to callers authenticated with role: anonymous
+// callers get an unauthorized error and callers with another role a forbidden
+// one. It needs Authenticate earlier in the chain.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := Claims(c)
+		if claims == nil {
+			RespondError(c, errors.NewUnauthorized("invalid or missing credentials"))
+			return
+		}
+		if claims.Role != role {
+			RespondError(c, errors.NewForbidden("requires the "+role+" role"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSubjectOrRole restricts access to callers with role and to callers
+// acting on themselves: those whose subject is the user ID userID extracts
+// from the request. A request without a user ID needs role. Like RequireRole,
+// anonymous callers get an unauthorized error and others a forbidden one.
+func RequireSubjectOrRole(role string, userID func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := Claims(c)
+		if claims == nil {
+			RespondError(c, errors.NewUnauthorized("invalid or missing credentials"))
+			return
+		}
+		if claims.Role != role && !sameUserID(claims.Subject, userID(c)) {
+			RespondError(c, errors.NewForbidden("requires the "+role+" role to access other users"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// sameUserID reports whether subject and id are the same user ID, whatever
+// their formatting ("42" and "042" match); non-numeric values never match
+func sameUserID(subject, id string) bool {
+	a, err := strconv.ParseUint(subject, 10, 64)
+	if err != nil {
+		return false
+	}
+	b, err := strconv.ParseUint(id, 10, 64)
+	return err == nil && a == b
+}
+
+// RateLimit gives every caller their own token bucket: callers authenticated
+// by Authenticate (which must run earlier) are limited by users, keyed by
+// their token's subject, and anonymous callers by anonymous, keyed by client
+// IP. Callers over their limit get a rate limited error. A nil limiter leaves
+// that kind of caller unlimited.
+func RateLimit(users, anonymous *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter, key := anonymous, c.ClientIP()
+		if claims := Claims(c); claims != nil {
+			limiter, key = users, claims.Subject
+		}
+		if limiter != nil && !limiter.Allow(key) {
+			RespondError(c, errors.NewRateLimited("rate limit exceeded, please retry later"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// ETag computes a weak entity tag from the JSON representation of data
+func ETag(data interface{}) string {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified sets the ETag header for data and, when the request's
+// If-None-Match matches it, writes 304 Not Modified. Handlers should return
+// without writing a body when it reports true.
+func NotModified(c *gin.Context, data interface{}) bool {
+	etag := ETag(data)
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// IDsAsString makes RespondSuccess serialize IDs ("id" and "*_id" fields) as
+// strings, so JavaScript clients don't lose precision on values above 2^53.
+// It applies to every request when enabled, or per request with ?ids_as_string=true.
+func IDsAsString(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		asString := enabled
+		if v := c.Query("ids_as_string"); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				asString = b
+			}
+		}
+		c.Set(IDsAsStringKey, asString)
+		c.Next()
+	}
+}
+
+// CamelCaseJSON sets whether responses write their JSON field names in
+// camelCase (e.g. "traceId", "createdAt") instead of the snake_case used by
+// the DTOs, for the success and error envelopes and everything nested in
+// them. Clients can override the default per request with ?camel_case=true
+// or false.
+func CamelCaseJSON(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		camel := enabled
+		if v := c.Query("camel_case"); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				camel = b
+			}
+		}
+		c.Set(CamelCaseKey, camel)
+		c.Next()
+	}
+}
+
+// camelCaseKeys returns data as generic JSON values with every object key in
+// camelCase, or data unchanged if it doesn't round-trip through JSON. Numbers
+// are kept exactly as encoded.
+func camelCaseKeys(data interface{}) interface{} {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+	return renameKeys(generic, snakeToCamel)
+}
+
+// renameKeys replaces every object key in v with rename's result, recursing
+// into nested objects and arrays
+func renameKeys(v interface{}, rename func(string) string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			renamed[rename(k)] = renameKeys(inner, rename)
+		}
+		return renamed
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = renameKeys(inner, rename)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// snakeToCamel converts a snake_case name to camelCase ("created_at" to
+// "createdAt"); names without underscores are returned as is
+func snakeToCamel(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for _, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// ObfuscateIDs hides sequential IDs behind codec at the API boundary: ID path
+// parameters, query parameters and JSON body fields ("id" and "*_id") are
+// decoded before handlers run, so handlers and backends keep using numbers,
+// and RespondSuccess, RespondCreated and ErrorHandler encode them on the way
+// out. Requests carrying an ID that does not decode are rejected with a
+// validation error. It must run before anything reads the query through gin's
+// cache (e.g. IDsAsString). A nil codec disables obfuscation.
+func ObfuscateIDs(codec *idcodec.Codec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if codec == nil {
+			c.Next()
+			return
+		}
+		c.Set(IDCodecKey, codec)
+
+		for i, param := range c.Params {
+			if !isIDField(param.Key) {
+				continue
+			}
+			id, err := codec.Decode(param.Value)
+			if err != nil {
+				RespondError(c, errors.NewValidation("invalid "+param.Key, nil))
+				return
+			}
+			c.Params[i].Value = strconv.FormatUint(id, 10)
+		}
+
+		query := c.Request.URL.Query()
+		for key, values := range query {
+			if !isIDField(key) {
+				continue
+			}
+			for i, value := range values {
+				id, err := codec.Decode(value)
+				if err != nil {
+					RespondError(c, errors.NewValidation("invalid "+key, nil))
+					return
+				}
+				values[i] = strconv.FormatUint(id, 10)
+			}
+		}
+		c.Request.URL.RawQuery = query.Encode()
+
+		if c.Request.Body != nil && c.ContentType() == "application/json" {
+			if err := decodeBodyIDs(c, codec); err != nil {
+				RespondError(c, err)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// decodeBodyIDs replaces the encoded ID fields of a JSON request body with
+// their numeric values. Bodies that are not valid JSON are left for the
+// handler's binding to reject.
+func decodeBodyIDs(c *gin.Context, codec *idcodec.Codec) error {
+	body, err := readBody(c)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil
+	}
+
+	var invalid string
+	decoded := transformIDs(generic, func(key string, v interface{}) interface{} {
+		s, ok := v.(string)
+		if !ok {
+			invalid = key
+			return v
+		}
+		id, err := codec.Decode(s)
+		if err != nil {
+			invalid = key
+			return v
+		}
+		return json.Number(strconv.FormatUint(id, 10))
+	})
+	if invalid != "" {
+		return errors.NewValidation("invalid "+invalid, nil)
+	}
+
+	body, err = json.Marshal(decoded)
+	if err != nil {
+		return errors.NewValidation("invalid request body", nil)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+	return nil
+}
+
+// idCodec returns the codec set by ObfuscateIDs, if any
+func idCodec(c *gin.Context) *idcodec.Codec {
+	codec, _ := c.Value(IDCodecKey).(*idcodec.Codec)
+	return codec
+}
+
+// isIDField reports whether a field or parameter name holds an ID
+func isIDField(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "_id")
+}
+
+// isIDListField reports whether a field name holds a list of IDs
+func isIDListField(key string) bool {
+	return key == "ids" || strings.HasSuffix(key, "_ids")
+}
+
+// rewriteIDs returns data with its numeric ID fields replaced by fn. Data
+// that cannot be round-tripped through JSON is returned unchanged.
+func rewriteIDs(data interface{}, fn func(json.Number) interface{}) interface{} {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return data
+	}
+	return transformIDs(generic, func(_ string, v interface{}) interface{} {
+		if n, ok := v.(json.Number); ok {
+			return fn(n)
+		}
+		return v
+	})
+}
+
+// transformIDs replaces the values of ID fields, and the elements of ID list
+// fields, with fn's result, recursing into nested values. Other nested
+// objects and arrays, even under an ID key, are not IDs and are walked instead.
+func transformIDs(v interface{}, fn func(key string, v interface{}) interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			switch list := inner.(type) {
+			case []interface{}:
+				if isIDListField(k) {
+					for i, elem := range list {
+						switch elem.(type) {
+						case map[string]interface{}, []interface{}:
+							list[i] = transformIDs(elem, fn)
+						case nil:
+							// null IDs are left alone
+						default:
+							list[i] = fn(k, elem)
+						}
+					}
+					continue
+				}
+				val[k] = transformIDs(inner, fn)
+			case map[string]interface{}:
+				val[k] = transformIDs(inner, fn)
+			case nil:
+				// null IDs are left alone
+			default:
+				if isIDField(k) {
+					val[k] = fn(k, inner)
+				}
+			}
+		}
+		return val
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = transformIDs(inner, fn)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// Routing sets how router treats paths matching no route. A path that only
+// differs from a route by a trailing slash, its case in static segments or
+// extra ./.. elements is redirected to that route (301 for GET, 307 otherwise,
+// so clients repeat the method and body; parameters such as IDs keep their
+// case). Anything else gets the standard 404 error envelope.
+func Routing(router *gin.Engine) {
+	router.RedirectTrailingSlash = true
+	router.RedirectFixedPath = true
+	router.NoRoute(func(c *gin.Context) {
+		RespondError(c, errors.New(errors.CodeNotFound, fmt.Sprintf("no route for %s %s", c.Request.Method, c.Request.URL.Path)))
+	})
+}
+
+// corsDeferredKey marks a preflight request the router-wide CORS left to a
+// route group's
+const corsDeferredKey = "cors_deferred"
+
+// corsMethods are the methods CORS advertises by default
+var corsMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// CORS handles CORS, advertising methods in Access-Control-Allow-Methods (by
+// default every method the APIs use) and answering preflight requests. Use it
+// on the router, and through CORSGroup on route groups allowing fewer methods:
+// the router-wide instance leaves the preflight requests for those groups'
+// routes to them.
+func CORS(methods ...string) gin.HandlerFunc {
+	if len(methods) == 0 {
+		methods = corsMethods
+	}
+	allowMethods := strings.Join(append(methods[:len(methods):len(methods)], http.MethodOptions), ", ")
+
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Trace-ID")
-		c.Header("Access-Control-Expose-Headers", "X-Trace-ID")
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Trace-ID, If-None-Match, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "X-Trace-ID, X-Upstream-Trace-ID, ETag, Location, Retry-After")
+
+		if c.Request.Method == http.MethodOptions {
+			// A preflight request matching a route was routed to a group's
+			// preflight handler (see CORSGroup): the group's CORS, which runs
+			// after this one, answers it
+			if c.FullPath() == "" || c.GetBool(corsDeferredKey) {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Set(corsDeferredKey, true)
+		}
+
+		c.Next()
+	}
+}
+
+// CORSGroup narrows the methods CORS advertises for group's routes to methods,
+// in their responses and in answers to preflight requests for them. Call it
+// before adding middleware to the group or registering its routes, as the
+// middleware added earlier sees preflight requests too: they carry no
+// credentials or custom headers.
+func CORSGroup(group *gin.RouterGroup, methods ...string) {
+	group.Use(CORS(methods...))
+	// Routes for preflight requests to reach the group; CORS answers them
+	preflight := func(c *gin.Context) {}
+	group.OPTIONS("", preflight)
+	group.OPTIONS("/*path", preflight)
+}
+
+// ConcurrencyLimit sheds load by rejecting requests with 503 once the number
+// of in-flight requests reaches limit. A limit <= 0 disables the check.
+func ConcurrencyLimit(limit int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	sem := make(chan struct{}, limit)
+
+	return func(c *gin.Context) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			c.Next()
+		default:
+			metrics.RejectedRequests.Inc()
+			RespondError(c, errors.NewUnavailable("server is at capacity, please retry later"))
+		}
+	}
+}
+
+// HeaderLimit rejects requests carrying more than maxCount header values, or
+// any header value longer than maxValueBytes, with a validation error before
+// handlers run. A limit <= 0 disables that check.
+func HeaderLimit(maxCount, maxValueBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count := 0
+		for name, values := range c.Request.Header {
+			count += len(values)
+			if maxCount > 0 && count > maxCount {
+				RespondError(c, errors.NewValidation("too many request headers", map[string]interface{}{
+					"max_headers": maxCount,
+				}))
+				return
+			}
+
+			if maxValueBytes <= 0 {
+				continue
+			}
+			for _, value := range values {
+				if len(value) > maxValueBytes {
+					RespondError(c, errors.NewValidation("request header value too large", map[string]interface{}{
+						"header":          name,
+						"max_value_bytes": maxValueBytes,
+					}))
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// redactedFields lists JSON keys whose values are never written to logs
+var redactedFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"secret":        true,
+	"authorization": true,
+}
+
+// bodyCaptureWriter tees the response body into a bounded buffer
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	buf      *bytes.Buffer
+	maxBytes int
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	if remaining := w.maxBytes - w.buf.Len(); remaining > 0 {
+		if len(b) > remaining {
+			w.buf.Write(b[:remaining])
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// BodyLogger logs request and response bodies at debug level for troubleshooting.
+// It is a no-op unless enabled. Bodies are truncated to maxBytes and sensitive
+// JSON fields are redacted; non-JSON or truncated bodies are not logged verbatim.
+func BodyLogger(log *logger.Logger, enabled bool, maxBytes int) gin.HandlerFunc {
+	flag := &atomic.Bool{}
+	flag.Store(enabled)
+	return SwitchableBodyLogger(log, flag, maxBytes)
+}
+
+// SwitchableBodyLogger is BodyLogger with a flag that can be flipped at
+// runtime, e.g. on a configuration reload
+func SwitchableBodyLogger(log *logger.Logger, enabled *atomic.Bool, maxBytes int) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	if enabled.Load() {
+		log.Warn("HTTP body logging is enabled; do not use in production",
+			zap.Int("max_bytes", maxBytes),
+		)
+	}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
+	return func(c *gin.Context) {
+		if !enabled.Load() {
+			c.Next()
 			return
 		}
 
+		var reqBody []byte
+		if c.Request.Body != nil {
+			// Read at most maxBytes+1 so truncation can be detected, then
+			// restore the body for downstream handlers.
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(maxBytes)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &bodyCaptureWriter{
+			ResponseWriter: c.Writer,
+			buf:            &bytes.Buffer{},
+			maxBytes:       maxBytes + 1,
+		}
+		c.Writer = writer
+
 		c.Next()
+
+		log.WithContext(c.Request.Context()).Debug("http payload",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("request_body", sanitizeBody(reqBody, maxBytes)),
+			zap.String("response_body", sanitizeBody(writer.buf.Bytes(), maxBytes)),
+			zap.String("trace_id", c.GetString(TraceIDKey)),
+		)
+	}
+}
+
+// sanitizeBody returns a loggable, redacted representation of a body
+func sanitizeBody(body []byte, maxBytes int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if len(body) > maxBytes {
+		return "[body omitted: exceeds " + strconv.Itoa(maxBytes) + " bytes]"
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "[non-JSON body omitted]"
+	}
+
+	redacted, err := json.Marshal(redact(data))
+	if err != nil {
+		return "[body omitted]"
+	}
+	return string(redacted)
+}
+
+// redact replaces the values of sensitive keys, recursing into nested values
+func redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if redactedFields[strings.ToLower(k)] {
+				val[k] = "[REDACTED]"
+				continue
+			}
+			val[k] = redact(inner)
+		}
+		return val
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = redact(inner)
+		}
+		return val
+	default:
+		return val
 	}
 }
@@ -0,0 +1,1284 @@
+package middleware
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"go-micro/pkg/auth"
+	"go-micro/pkg/errors"
+	"go-micro/pkg/idcodec"
+	"go-micro/pkg/logger"
+	"go-micro/pkg/metrics"
+	"go-micro/pkg/ratelimit"
+	"go-micro/pkg/tenant"
+)
+
+func TestIDsAsString(t *testing.T) {
+	type item struct {
+		ID     uint64  `json:"id"`
+		UserID uint64  `json:"user_id"`
+		Total  float64 `json:"total"`
+	}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		query   string
+		want    string
+	}{
+		{"disabled", false, "", `{"id":9007199254740993,"user_id":1,"total":9.5}`},
+		{"enabled by config", true, "", `{"id":"9007199254740993","total":9.5,"user_id":"1"}`},
+		{"enabled by query", false, "?ids_as_string=true", `{"id":"9007199254740993","total":9.5,"user_id":"1"}`},
+		{"disabled by query", true, "?ids_as_string=false", `{"id":9007199254740993,"user_id":1,"total":9.5}`},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(IDsAsString(tt.enabled))
+			router.GET("/item", func(c *gin.Context) {
+				RespondSuccess(c, http.StatusOK, item{ID: 9007199254740993, UserID: 1, Total: 9.5})
+			})
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/item"+tt.query, nil))
+
+			// Assert
+			if body := rec.Body.String(); !strings.Contains(body, `"data":`+tt.want) {
+				t.Errorf("expected data %s, got %s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestErrorHandler_PanicClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		panicValue interface{}
+		wantStatus int
+		wantCode   string
+	}{
+		{"app error", errors.NewNotFound("user", 1), http.StatusNotFound, errors.CodeNotFound},
+		{"plain error", stderrors.New("boom"), http.StatusInternalServerError, errors.CodeInternal},
+		{"arbitrary value", "boom", http.StatusInternalServerError, errors.CodeInternal},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "debug")))
+			router.GET("/panic", func(c *gin.Context) {
+				panic(tt.panicValue)
+			})
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("expected code %s, got %s", tt.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestClassifyPanic(t *testing.T) {
+	var runtimeErr error
+	func() {
+		defer func() { runtimeErr = recover().(error) }()
+		var m map[string]int
+		m["x"] = 1
+	}()
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"app error", errors.NewConflict("duplicate"), panicKindAppError},
+		{"runtime error", runtimeErr, panicKindRuntime},
+		{"plain error", stderrors.New("boom"), panicKindError},
+		{"arbitrary value", 42, panicKindValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := classifyPanic(tt.value); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHeaderLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxCount   int
+		maxValue   int
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"within limits", 3, 16, map[string]string{"X-A": "a", "X-B": "b"}, http.StatusOK},
+		{"too many headers", 1, 16, map[string]string{"X-A": "a", "X-B": "b"}, http.StatusBadRequest},
+		{"value too large", 3, 4, map[string]string{"X-A": "abcdef"}, http.StatusBadRequest},
+		{"limits disabled", 0, 0, map[string]string{"X-A": strings.Repeat("a", 64), "X-B": "b"}, http.StatusOK},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "debug")))
+			router.Use(HeaderLimit(tt.maxCount, tt.maxValue))
+			router.GET("/ping", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusOK && !strings.Contains(rec.Body.String(), `"code":"`+errors.CodeValidation+`"`) {
+				t.Errorf("expected code %s, got %s", errors.CodeValidation, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRespondCreated_SetsLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"collection path", "/api/v1/users", "/api/v1/users/42"},
+		{"trailing slash", "/api/v1/users/", "/api/v1/users/42"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.RedirectTrailingSlash = false
+			handler := func(c *gin.Context) {
+				RespondCreated(c, 42, gin.H{"id": 42})
+			}
+			router.POST("/api/v1/users", handler)
+			router.POST("/api/v1/users/", handler)
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+
+			// Assert
+			if rec.Code != http.StatusCreated {
+				t.Errorf("expected status %d, got %d", http.StatusCreated, rec.Code)
+			}
+			if got := rec.Header().Get("Location"); got != tt.want {
+				t.Errorf("expected Location %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestObfuscateIDs(t *testing.T) {
+	type item struct {
+		ID     uint64 `json:"id"`
+		UserID uint64 `json:"user_id"`
+	}
+
+	codec := idcodec.New("test-salt")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logger.New("test", "debug")))
+	router.Use(ObfuscateIDs(codec))
+	router.POST("/items", func(c *gin.Context) {
+		var req struct {
+			UserID uint64 `json:"user_id"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, errors.NewValidation("invalid request body", err.Error()))
+			return
+		}
+		RespondCreated(c, 7, item{ID: 7, UserID: req.UserID})
+	})
+	router.GET("/items/:id", func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+		userID, _ := strconv.ParseUint(c.Request.URL.Query().Get("user_id"), 10, 64)
+		RespondSuccess(c, http.StatusOK, item{ID: id, UserID: userID})
+	})
+	router.POST("/items/batch", func(c *gin.Context) {
+		var req struct {
+			IDs []uint64 `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, errors.NewValidation("invalid request body", err.Error()))
+			return
+		}
+		RespondSuccess(c, http.StatusOK, gin.H{"missing_ids": req.IDs})
+	})
+	router.GET("/missing/:id", func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+		err := errors.NewNotFound("order", id)
+		err.Details = map[string]interface{}{"user_id": 9, "count": 2}
+		RespondError(c, err)
+	})
+
+	t.Run("decodes input and encodes output", func(t *testing.T) {
+		// Act
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"user_id":"`+codec.Encode(3)+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+
+		// Assert
+		want := `"data":{"id":"` + codec.Encode(7) + `","user_id":"` + codec.Encode(3) + `"}`
+		if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected 201 with %s, got %d %s", want, rec.Code, rec.Body.String())
+		}
+		if location := rec.Header().Get("Location"); location != "/items/"+codec.Encode(7) {
+			t.Errorf("expected encoded Location, got %s", location)
+		}
+	})
+
+	t.Run("decodes path and query", func(t *testing.T) {
+		// Act
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/"+codec.Encode(5)+"?user_id="+codec.Encode(9), nil))
+
+		// Assert
+		want := `"data":{"id":"` + codec.Encode(5) + `","user_id":"` + codec.Encode(9) + `"}`
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected 200 with %s, got %d %s", want, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("decodes and encodes ID lists", func(t *testing.T) {
+		// Act
+		rec := httptest.NewRecorder()
+		body := `{"ids":["` + codec.Encode(4) + `","` + codec.Encode(8) + `"]}`
+		req := httptest.NewRequest(http.MethodPost, "/items/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(rec, req)
+
+		// Assert
+		want := `"data":{"missing_ids":["` + codec.Encode(4) + `","` + codec.Encode(8) + `"]}`
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected 200 with %s, got %d %s", want, rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("encodes IDs in errors", func(t *testing.T) {
+		// Act
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing/"+codec.Encode(5), nil))
+
+		// Assert
+		body := rec.Body.String()
+		for _, want := range []string{
+			`"message":"order with id '` + codec.Encode(5) + `' not found"`,
+			`"user_id":"` + codec.Encode(9) + `"`,
+			`"count":2`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %s in %s", want, body)
+			}
+		}
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("rejects raw IDs", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/items/5", nil),
+			httptest.NewRequest(http.MethodGet, "/items/"+codec.Encode(5)+"?user_id=9", nil),
+			httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(`{"user_id":3}`)),
+			httptest.NewRequest(http.MethodPost, "/items/batch", strings.NewReader(`{"ids":[3]}`)),
+		} {
+			req.Header.Set("Content-Type", "application/json")
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s %s: expected 400, got %d", req.Method, req.URL, rec.Code)
+			}
+		}
+	})
+}
+
+func TestBindJSON(t *testing.T) {
+	type request struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	tests := []struct {
+		name        string
+		body        func() io.Reader
+		wantStatus  int
+		wantMessage string
+	}{
+		{"valid", func() io.Reader { return strings.NewReader(`{"name":"John"}`) }, http.StatusOK, ""},
+		{"fails validation", func() io.Reader { return strings.NewReader(`{}`) }, http.StatusBadRequest, "invalid request body"},
+		{"too large", func() io.Reader { return strings.NewReader(`{"name":"` + strings.Repeat("x", 64) + `"}`) }, http.StatusRequestEntityTooLarge, "request body too large"},
+		{"never completes", func() io.Reader {
+			// A pipe nobody writes to behaves like a client trickling its body
+			r, _ := io.Pipe()
+			return r
+		}, http.StatusBadRequest, "timed out reading request body"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "debug")))
+			router.Use(JSONBodyLimit(32, 50*time.Millisecond))
+			router.POST("/items", func(c *gin.Context) {
+				var req request
+				if err := BindJSON(c, &req); err != nil {
+					RespondError(c, err)
+					return
+				}
+				RespondSuccess(c, http.StatusOK, req)
+			})
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/items", tt.body()))
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.wantMessage != "" && !strings.Contains(rec.Body.String(), tt.wantMessage) {
+				t.Errorf("expected message %q, got %s", tt.wantMessage, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBindJSON_RequestTooLarge(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logger.New("test", "error")))
+	router.Use(JSONBodyLimit(16, time.Second))
+	router.POST("/items", func(c *gin.Context) {
+		var req map[string]interface{}
+		if err := BindJSON(c, &req); err != nil {
+			RespondError(c, err)
+			return
+		}
+		RespondSuccess(c, http.StatusOK, req)
+	})
+
+	// Act
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/items",
+		strings.NewReader(`{"name":"`+strings.Repeat("x", 32)+`"}`)))
+
+	// Assert
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp errors.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected JSON error body, got %v", err)
+	}
+	if resp.Error.Code != errors.CodeRequestTooLarge {
+		t.Errorf("expected code %s, got %s", errors.CodeRequestTooLarge, resp.Error.Code)
+	}
+	if details, _ := resp.Error.Details.(map[string]interface{}); details["max_bytes"] != float64(16) {
+		t.Errorf("expected max_bytes 16 in details, got %v", resp.Error.Details)
+	}
+}
+
+func TestBindJSON_NestedFieldPaths(t *testing.T) {
+	type item struct {
+		SKU      string `json:"sku" binding:"required"`
+		Quantity int    `json:"quantity" binding:"gt=0"`
+	}
+	type address struct {
+		City string `json:"city" binding:"required"`
+	}
+	type request struct {
+		Items    []item  `json:"items" binding:"required,dive"`
+		Shipping address `json:"shipping"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields map[string]string
+	}{
+		{
+			name:       "invalid item",
+			body:       `{"items":[{"sku":"a","quantity":0},{"sku":"b","quantity":2}],"shipping":{"city":"Lima"}}`,
+			wantFields: map[string]string{"items[0].quantity": "gt=0"},
+		},
+		{
+			name: "several invalid fields",
+			body: `{"items":[{"sku":"a","quantity":1},{"quantity":1}],"shipping":{}}`,
+			wantFields: map[string]string{
+				"items[1].sku":  "required",
+				"shipping.city": "required",
+			},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+
+			// Act
+			var req request
+			err := BindJSON(c, &req)
+
+			// Assert
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) || appErr.Code != errors.CodeValidation {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			details, _ := appErr.Details.(map[string]interface{})
+			fields, _ := details["fields"].(map[string]string)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected fields %v, got %v", tt.wantFields, appErr.Details)
+			}
+		})
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	type page struct {
+		PageSize int32 `form:"page_size" binding:"omitempty,max=100"`
+	}
+	type query struct {
+		page
+		UserID uint64 `form:"user_id"`
+		Active bool   `form:"active"`
+		Name   string `form:"name"`
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantErr    bool
+		wantFields map[string]string
+	}{
+		{name: "valid", query: "page_size=20&user_id=1&active=true&name=abc"},
+		{name: "empty values", query: "page_size=&user_id="},
+		{
+			name:       "not an integer",
+			query:      "page_size=abc",
+			wantErr:    true,
+			wantFields: map[string]string{"page_size": "must be an integer"},
+		},
+		{
+			name:    "several malformed values",
+			query:   "page_size=1.5&user_id=-1&active=maybe",
+			wantErr: true,
+			wantFields: map[string]string{
+				"page_size": "must be an integer",
+				"user_id":   "must be a non-negative integer",
+				"active":    "must be a boolean",
+			},
+		},
+		{
+			name:       "out of range",
+			query:      "page_size=99999999999",
+			wantErr:    true,
+			wantFields: map[string]string{"page_size": "is out of range"},
+		},
+		{
+			name:       "rule violation",
+			query:      "page_size=500",
+			wantErr:    true,
+			wantFields: map[string]string{"page_size": "max=100"},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders?"+tt.query, nil)
+
+			// Act
+			var q query
+			err := BindQuery(c, &q)
+
+			// Assert
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) || appErr.Code != errors.CodeValidation {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if appErr.Message != "invalid query parameters" {
+				t.Errorf("unexpected message %q", appErr.Message)
+			}
+			details, _ := appErr.Details.(map[string]interface{})
+			fields, _ := details["fields"].(map[string]string)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected fields %v, got %v", tt.wantFields, appErr.Details)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		required   bool
+		wantStatus int
+		wantTenant string
+	}{
+		{"adopts the header", "acme", false, http.StatusOK, "acme"},
+		{"optional and missing", "", false, http.StatusOK, ""},
+		{"required and missing", "", true, http.StatusBadRequest, ""},
+		{"malformed", "acme corp", false, http.StatusBadRequest, ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "error")))
+			var gotTenant string
+			router.GET("/items", Tenant(tt.required), func(c *gin.Context) {
+				gotTenant = tenant.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("expected tenant %q, got %q", tt.wantTenant, gotTenant)
+			}
+		})
+	}
+}
+
+func TestTenant_FromToken(t *testing.T) {
+	tokens := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	acmeToken, _, _ := tokens.IssueForTenant("1", "user", "acme")
+	defaultToken, _, _ := tokens.Issue("2", "user")
+
+	tests := []struct {
+		name          string
+		authorization string
+		header        string
+		wantStatus    int
+		wantTenant    string
+	}{
+		{"token's tenant without header", "Bearer " + acmeToken, "", http.StatusOK, "acme"},
+		{"header matching the token", "Bearer " + acmeToken, "acme", http.StatusOK, "acme"},
+		{"header naming another tenant", "Bearer " + acmeToken, "globex", http.StatusForbidden, ""},
+		{"default tenant token naming a tenant", "Bearer " + defaultToken, "acme", http.StatusForbidden, ""},
+		{"admin API key names the tenant", "Bearer api-key", "globex", http.StatusOK, "globex"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "error")))
+			router.Use(Authenticate(tokens, "api-key"))
+			var gotTenant string
+			router.GET("/items", Tenant(false), func(c *gin.Context) {
+				gotTenant = tenant.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			req.Header.Set("Authorization", tt.authorization)
+			if tt.header != "" {
+				req.Header.Set(tenant.Header, tt.header)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("expected tenant %q, got %q", tt.wantTenant, gotTenant)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tokens := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	adminToken, _, _ := tokens.Issue("1", auth.RoleAdmin)
+	userToken, _, _ := tokens.Issue("2", "user")
+	foreignToken, _, _ := auth.NewTokenIssuer("other-secret", time.Hour).Issue("1", auth.RoleAdmin)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"admin token", "Bearer " + adminToken, http.StatusOK},
+		{"admin API key", "Bearer api-key", http.StatusOK},
+		{"user token", "Bearer " + userToken, http.StatusForbidden},
+		{"token from another issuer", "Bearer " + foreignToken, http.StatusUnauthorized},
+		{"anonymous", "", http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "debug")))
+			router.Use(Authenticate(tokens, "api-key"))
+			router.GET("/admin", RequireRole(auth.RoleAdmin), func(c *gin.Context) {
+				RespondSuccess(c, http.StatusOK, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireSubjectOrRole(t *testing.T) {
+	tokens := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	adminToken, _, _ := tokens.Issue("1", auth.RoleAdmin)
+	userToken, _, _ := tokens.Issue("2", "user")
+
+	tests := []struct {
+		name          string
+		authorization string
+		query         string
+		wantStatus    int
+	}{
+		{"own user ID", "Bearer " + userToken, "?user_id=2", http.StatusOK},
+		{"another user's ID", "Bearer " + userToken, "?user_id=3", http.StatusForbidden},
+		{"no user ID", "Bearer " + userToken, "", http.StatusForbidden},
+		{"admin with another user's ID", "Bearer " + adminToken, "?user_id=3", http.StatusOK},
+		{"admin without user ID", "Bearer " + adminToken, "", http.StatusOK},
+		{"anonymous", "", "?user_id=2", http.StatusUnauthorized},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			router := gin.New()
+			router.Use(ErrorHandler(logger.New("test", "debug")))
+			router.Use(Authenticate(tokens, ""))
+			router.GET("/orders", RequireSubjectOrRole(auth.RoleAdmin, func(c *gin.Context) string {
+				return c.Query("user_id")
+			}), func(c *gin.Context) {
+				RespondSuccess(c, http.StatusOK, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders"+tt.query, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	// Arrange: a burst of 2 and a refill too slow to matter during the test
+	tokens := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	aliceToken, _, _ := tokens.Issue("1", "user")
+	bobToken, _, _ := tokens.Issue("2", "user")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logger.New("test", "debug")))
+	router.Use(Authenticate(tokens, ""))
+	router.Use(RateLimit(ratelimit.New(0.001, 2), ratelimit.New(0.001, 1)))
+	router.GET("/items", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusOK, nil)
+	})
+
+	call := func(token, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Act & Assert: alice exhausts her bucket from two addresses, which
+	// leaves bob's and the anonymous ones untouched
+	for i, addr := range []string{"10.0.0.1:1000", "10.0.0.2:1000"} {
+		if code := call(aliceToken, addr); code != http.StatusOK {
+			t.Fatalf("alice call %d: expected 200, got %d", i+1, code)
+		}
+	}
+	if code := call(aliceToken, "10.0.0.3:1000"); code != http.StatusTooManyRequests {
+		t.Errorf("expected alice to be limited past her burst, got %d", code)
+	}
+	if code := call(bobToken, "10.0.0.1:1000"); code != http.StatusOK {
+		t.Errorf("expected bob to have his own bucket, got %d", code)
+	}
+	if code := call("", "10.0.0.1:1000"); code != http.StatusOK {
+		t.Errorf("expected anonymous caller to be allowed, got %d", code)
+	}
+	if code := call("", "10.0.0.1:1000"); code != http.StatusTooManyRequests {
+		t.Errorf("expected anonymous caller to be limited by IP, got %d", code)
+	}
+	if code := call("", "10.0.0.2:1000"); code != http.StatusOK {
+		t.Errorf("expected another IP to have its own bucket, got %d", code)
+	}
+}
+
+func TestTraceID_TrustPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		trustIncoming bool
+		incoming      string
+		wantAdopted   bool
+		wantUpstream  string
+	}{
+		{"trusted", true, "proxy-trace-1", true, ""},
+		{"regenerated", false, "proxy-trace-1", false, "proxy-trace-1"},
+		{"none sent", false, "", false, ""},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var ctxTraceID string
+			var fields map[string]bool
+			router := gin.New()
+			router.Use(TraceID(tt.trustIncoming))
+			router.GET("/ping", func(c *gin.Context) {
+				ctxTraceID = logger.GetTraceID(c.Request.Context())
+				fields = map[string]bool{}
+				for _, f := range logger.Fields(c.Request.Context()) {
+					fields[f.Key] = true
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.incoming != "" {
+				req.Header.Set(TraceIDHeader, tt.incoming)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			traceID := rec.Header().Get(TraceIDHeader)
+			if traceID == "" || traceID != ctxTraceID {
+				t.Fatalf("expected response and context trace IDs to match, got %q and %q", traceID, ctxTraceID)
+			}
+			if adopted := traceID == tt.incoming; adopted != tt.wantAdopted {
+				t.Errorf("expected adopted=%v, got trace ID %q", tt.wantAdopted, traceID)
+			}
+			if upstream := rec.Header().Get(UpstreamTraceIDHeader); upstream != tt.wantUpstream {
+				t.Errorf("expected upstream trace ID %q, got %q", tt.wantUpstream, upstream)
+			}
+			if fields["upstream_trace_id"] != (tt.wantUpstream != "") {
+				t.Errorf("unexpected upstream_trace_id log field presence: %v", fields)
+			}
+		})
+	}
+}
+
+func TestRequestLogger_ExcludePaths(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantLog bool
+	}{
+		{"/health", false},
+		{"/health/ready", false},
+		{"/metrics", false},
+		{"/healthz", true},
+		{"/api/v1/users", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			// Arrange
+			core, logs := observer.New(zapcore.InfoLevel)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(RequestLogger(&logger.Logger{Logger: zap.New(core)}, "/health", "/metrics/"))
+			router.NoRoute(func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			// Act
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			if got := logs.FilterMessage("http request").Len() == 1; got != tt.wantLog {
+				t.Errorf("expected logged=%v for %s, got %v", tt.wantLog, tt.path, got)
+			}
+		})
+	}
+}
+
+func TestMetrics_RecordsRouteAndOutcome(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/metrics-test/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	counter := func(route, status, outcome string) float64 {
+		return promtestutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, route, status, outcome))
+	}
+
+	// Act
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test/0", "/metrics-missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Assert: requests are grouped by route template, not by path
+	if got := counter("/metrics-test/:id", "200", metrics.OutcomeSuccess); got != 2 {
+		t.Errorf("expected 2 successful requests, got %v", got)
+	}
+	if got := counter("/metrics-test/:id", "404", metrics.OutcomeClientError); got != 1 {
+		t.Errorf("expected 1 client error, got %v", got)
+	}
+	if got := counter("unmatched", "404", metrics.OutcomeClientError); got != 1 {
+		t.Errorf("expected 1 unmatched request, got %v", got)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Routing(router)
+	router.Use(ErrorHandler(logger.New("test", "debug")))
+	api := router.Group("/api/v1")
+	api.POST("/orders", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusCreated, nil)
+	})
+	api.GET("/orders/:id", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusOK, c.Param("id"))
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		wantStatus   int
+		wantLocation string
+	}{
+		{"exact route", http.MethodPost, "/api/v1/orders", http.StatusCreated, ""},
+		{"trailing slash keeps the method", http.MethodPost, "/api/v1/orders/", http.StatusTemporaryRedirect, "/api/v1/orders"},
+		{"mixed case keeps the method", http.MethodPost, "/API/v1/Orders", http.StatusTemporaryRedirect, "/api/v1/orders"},
+		{"GET with trailing slash", http.MethodGet, "/api/v1/orders/aB3/", http.StatusMovedPermanently, "/api/v1/orders/aB3"},
+		{"mixed case keeps the parameter's case", http.MethodGet, "/Api/V1/ORDERS/aB3", http.StatusMovedPermanently, "/api/v1/orders/aB3"},
+		{"unknown path", http.MethodGet, "/api/v1/nothing", http.StatusNotFound, ""},
+		{"unknown method", http.MethodDelete, "/api/v1/orders", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("expected Location %q, got %q", tt.wantLocation, got)
+			}
+			if tt.wantStatus != http.StatusNotFound {
+				return
+			}
+			var resp errors.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("expected the JSON error envelope, got %q", rec.Body.String())
+			}
+			if resp.Error.Code != errors.CodeNotFound {
+				t.Errorf("expected code %s, got %+v", errors.CodeNotFound, resp.Error)
+			}
+		})
+	}
+}
+
+// newHoldingRouter serves /hold behind ConcurrencyLimit(limit); each request
+// signals entered once inside the handler and stays there until release is
+// closed
+func newHoldingRouter(limit int, entered chan<- struct{}, release <-chan struct{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logger.New("test", "error")))
+	router.Use(ConcurrencyLimit(limit))
+	router.GET("/hold", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// holdRequests starts n requests to /hold and waits until all of them are
+// inside the handler; the returned WaitGroup is done once they finish
+func holdRequests(t *testing.T, router *gin.Engine, n int, entered <-chan struct{}) *sync.WaitGroup {
+	t.Helper()
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hold", nil))
+		}()
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-entered:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of %d requests reached the handler", i, n)
+		}
+	}
+	return &wg
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	// Arrange: fill both slots
+	entered := make(chan struct{}, 3)
+	release := make(chan struct{})
+	router := newHoldingRouter(2, entered, release)
+	held := holdRequests(t, router, 2, entered)
+	rejectedBefore := promtestutil.ToFloat64(metrics.RejectedRequests)
+
+	// Act
+	rejected := httptest.NewRecorder()
+	router.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/hold", nil))
+
+	close(release)
+	held.Wait()
+	freed := httptest.NewRecorder()
+	router.ServeHTTP(freed, httptest.NewRequest(http.MethodGet, "/hold", nil))
+
+	// Assert
+	if rejected.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 at capacity, got %d", rejected.Code)
+	}
+	if !strings.Contains(rejected.Body.String(), `"code":"`+errors.CodeUnavailable+`"`) {
+		t.Errorf("expected code %s, got %s", errors.CodeUnavailable, rejected.Body.String())
+	}
+	if got := promtestutil.ToFloat64(metrics.RejectedRequests) - rejectedBefore; got != 1 {
+		t.Errorf("expected 1 rejected request recorded, got %v", got)
+	}
+	// Finished requests give their slots back
+	if freed.Code != http.StatusOK {
+		t.Errorf("expected 200 once a slot is free, got %d", freed.Code)
+	}
+}
+
+func TestConcurrencyLimit_Disabled(t *testing.T) {
+	// Arrange
+	entered := make(chan struct{}, 3)
+	release := make(chan struct{})
+	router := newHoldingRouter(0, entered, release)
+
+	// Act: every request reaches the handler at once, or this fails
+	held := holdRequests(t, router, 3, entered)
+
+	// Assert
+	close(release)
+	held.Wait()
+}
+
+func TestCORS_PerGroupMethods(t *testing.T) {
+	// Arrange: a read-only admin group behind a required tenant and admin
+	// credentials, which preflight requests don't carry
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logger.New("test", "debug")))
+	router.Use(CORS())
+	api := router.Group("/api/v1", Tenant(true))
+	admin := api.Group("/admin")
+	CORSGroup(admin, http.MethodGet)
+	admin.Use(AdminAuth("admin-key"))
+	admin.GET("/items", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusOK, nil)
+	})
+	api.POST("/items", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusCreated, nil)
+	})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantMethods string
+	}{
+		{"group route", http.MethodGet, "/api/v1/admin/items", http.StatusOK, "GET, OPTIONS"},
+		{"group preflight", http.MethodOptions, "/api/v1/admin/items", http.StatusNoContent, "GET, OPTIONS"},
+		{"group root preflight", http.MethodOptions, "/api/v1/admin", http.StatusNoContent, "GET, OPTIONS"},
+		{"other route", http.MethodPost, "/api/v1/items", http.StatusCreated, "GET, POST, PUT, PATCH, DELETE, OPTIONS"},
+		{"other preflight", http.MethodOptions, "/api/v1/items", http.StatusNoContent, "GET, POST, PUT, PATCH, DELETE, OPTIONS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.method != http.MethodOptions {
+				req.Header.Set("Authorization", "Bearer admin-key")
+				req.Header.Set(tenant.Header, "acme")
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("expected allowed methods %q, got %q", tt.wantMethods, got)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_StoresClaimsInRequestContext(t *testing.T) {
+	// Arrange
+	tokens := auth.NewTokenIssuer("jwt-secret", time.Hour)
+	token, _, _ := tokens.Issue("42", "user")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Authenticate(tokens, "admin-key"))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, auth.SubjectFromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantSubject   string
+	}{
+		{"access token", "Bearer " + token, "42"},
+		{"admin API key", "Bearer admin-key", adminAPIKeySubject},
+		{"invalid token", "Bearer not-a-token", ""},
+		{"anonymous", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			// Act
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			// Assert
+			if got := rec.Body.String(); got != tt.wantSubject {
+				t.Errorf("expected subject %q in the request context, got %q", tt.wantSubject, got)
+			}
+		})
+	}
+}
+
+func TestSnakeToCamel(t *testing.T) {
+	tests := map[string]string{
+		"id":                   "id",
+		"trace_id":             "traceId",
+		"ids_as_string":        "idsAsString",
+		"total_spent":          "totalSpent",
+		"already_camelCase":    "alreadyCamelCase",
+		"double__underscore":   "doubleUnderscore",
+		"trailing_underscore_": "trailingUnderscore",
+	}
+
+	for name, want := range tests {
+		if got := snakeToCamel(name); got != want {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCamelCaseJSON_RoundTrip(t *testing.T) {
+	// Arrange: nested structs, a slice of them and a large ID that must
+	// survive the rewrite exactly
+	type item struct {
+		ItemID    uint64 `json:"item_id"`
+		CreatedAt string `json:"created_at"`
+	}
+	type payload struct {
+		UserID     uint64  `json:"user_id"`
+		TotalSpent float64 `json:"total_spent"`
+		Items      []item  `json:"recent_items"`
+	}
+	data := payload{
+		UserID:     1<<63 + 1,
+		TotalSpent: 149.97,
+		Items:      []item{{ItemID: 7, CreatedAt: "2024-01-15T10:30:00Z"}},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(TraceID(true))
+	router.Use(ErrorHandler(&logger.Logger{Logger: zap.NewNop()}))
+	router.Use(CamelCaseJSON(false))
+	router.GET("/data", func(c *gin.Context) {
+		RespondSuccess(c, http.StatusOK, data)
+	})
+	router.GET("/error", func(c *gin.Context) {
+		RespondError(c, errors.NewValidation("invalid request", map[string]string{"user_id": "is required"}))
+	})
+
+	get := func(path string) map[string]interface{} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		decoder := json.NewDecoder(rec.Body)
+		decoder.UseNumber()
+		var body map[string]interface{}
+		if err := decoder.Decode(&body); err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		return body
+	}
+	camelToSnake := func(name string) string {
+		var b strings.Builder
+		for _, r := range name {
+			if r >= 'A' && r <= 'Z' {
+				b.WriteByte('_')
+				r += 'a' - 'A'
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+
+	for _, path := range []string{"/data", "/error"} {
+		t.Run(path, func(t *testing.T) {
+			// Act
+			snake := get(path)
+			camel := get(path + "?camel_case=true")
+
+			// Assert: mapping the camelCase keys back gives the default body
+			// (trace IDs aside, as every request gets its own)
+			if _, ok := camel["traceId"]; !ok {
+				t.Errorf("expected a traceId field, got %v", camel)
+			}
+			delete(snake, "trace_id")
+			delete(camel, "traceId")
+			if back := renameKeys(camel, camelToSnake); !reflect.DeepEqual(back, renameKeys(snake, camelToSnake)) {
+				t.Errorf("expected %v to map back to %v", camel, snake)
+			}
+		})
+	}
+
+	// Assert the nested names themselves
+	camel := get("/data?camel_case=true")
+	got := camel["data"].(map[string]interface{})
+	if got["userId"] != json.Number("9223372036854775809") {
+		t.Errorf("expected userId to survive exactly, got %v", got["userId"])
+	}
+	if item := got["recentItems"].([]interface{})[0].(map[string]interface{}); item["createdAt"] == nil || item["itemId"] == nil {
+		t.Errorf("expected nested camelCase fields, got %v", item)
+	}
+	errBody := get("/error?camel_case=true")["error"].(map[string]interface{})
+	if details := errBody["details"].(map[string]interface{}); details["userId"] != "is required" {
+		t.Errorf("expected camelCase error details, got %v", details)
+	}
+}
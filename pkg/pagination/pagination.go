@@ -0,0 +1,66 @@
+// Package pagination provides page-token based pagination shared by the
+// gRPC services and the REST endpoints in front of them.
+package pagination
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"go-micro/pkg/errors"
+)
+
+// Page size limits
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+const tokenPrefix = "page:"
+
+// ErrInvalidPageToken is returned when a page token cannot be decoded
+var ErrInvalidPageToken = errors.NewValidation("invalid page token", nil)
+
+// NormalizePageSize applies the default and caps the page size
+func NormalizePageSize(pageSize int) int {
+	if pageSize < 1 {
+		return DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		return MaxPageSize
+	}
+	return pageSize
+}
+
+// EncodeToken encodes a 1-based page number as an opaque page token
+func EncodeToken(page int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(tokenPrefix + strconv.Itoa(page)))
+}
+
+// DecodeToken decodes a page token into a 1-based page number.
+// An empty token refers to the first page.
+func DecodeToken(token string) (int, error) {
+	if token == "" {
+		return 1, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), tokenPrefix) {
+		return 0, ErrInvalidPageToken
+	}
+
+	page, err := strconv.Atoi(strings.TrimPrefix(string(raw), tokenPrefix))
+	if err != nil || page < 1 {
+		return 0, ErrInvalidPageToken
+	}
+
+	return page, nil
+}
+
+// NextToken returns the token for the page after page, or "" if it is the last one
+func NextToken(page, pageSize int, total int64) string {
+	if int64(page)*int64(pageSize) >= total {
+		return ""
+	}
+	return EncodeToken(page + 1)
+}
@@ -0,0 +1,48 @@
+package pagination
+
+import (
+	"testing"
+
+	"go-micro/pkg/errors"
+)
+
+func TestTokenRoundTrip(t *testing.T) {
+	page, err := DecodeToken(EncodeToken(3))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if page != 3 {
+		t.Errorf("expected page 3, got %d", page)
+	}
+
+	page, err = DecodeToken("")
+	if err != nil || page != 1 {
+		t.Errorf("expected empty token to decode to page 1, got %d (%v)", page, err)
+	}
+}
+
+func TestDecodeToken_Invalid(t *testing.T) {
+	for _, token := range []string{"not-base64!", EncodeToken(0), "cGFnZTphYmM"} {
+		if _, err := DecodeToken(token); !errors.Is(err, errors.CodeValidation) {
+			t.Errorf("expected validation error for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestNextToken(t *testing.T) {
+	if token := NextToken(1, 20, 45); token != EncodeToken(2) {
+		t.Errorf("expected token for page 2, got %q", token)
+	}
+	if token := NextToken(3, 20, 45); token != "" {
+		t.Errorf("expected empty token on last page, got %q", token)
+	}
+}
+
+func TestNormalizePageSize(t *testing.T) {
+	if got := NormalizePageSize(0); got != DefaultPageSize {
+		t.Errorf("expected default %d, got %d", DefaultPageSize, got)
+	}
+	if got := NormalizePageSize(500); got != MaxPageSize {
+		t.Errorf("expected max %d, got %d", MaxPageSize, got)
+	}
+}
@@ -0,0 +1,47 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Delivery is a message observed by an Awaiter
+type Delivery struct {
+	Body []byte
+	Err  error
+}
+
+// Awaiter lets tests wait deterministically until a consumer has processed a
+// message, instead of sleeping and hoping the message arrived.
+type Awaiter struct {
+	deliveries chan Delivery
+}
+
+// NewAwaiter creates an Awaiter that buffers up to buffer processed messages
+func NewAwaiter(buffer int) *Awaiter {
+	return &Awaiter{deliveries: make(chan Delivery, buffer)}
+}
+
+// Wrap returns a handler that runs handler and then signals the outcome.
+// The signal is dropped if the buffer is full so the consumer never blocks.
+func (a *Awaiter) Wrap(handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, body []byte) error {
+		err := handler(ctx, body)
+		select {
+		case a.deliveries <- Delivery{Body: body, Err: err}:
+		default:
+		}
+		return err
+	}
+}
+
+// Wait blocks until the next message has been processed or timeout elapses
+func (a *Awaiter) Wait(timeout time.Duration) (Delivery, error) {
+	select {
+	case d := <-a.deliveries:
+		return d, nil
+	case <-time.After(timeout):
+		return Delivery{}, fmt.Errorf("no message processed within %s", timeout)
+	}
+}
@@ -0,0 +1,44 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAwaiter_SignalsProcessedMessages(t *testing.T) {
+	awaiter := NewAwaiter(1)
+	handlerErr := errors.New("boom")
+	handler := awaiter.Wrap(func(ctx context.Context, body []byte) error {
+		if string(body) == "bad" {
+			return handlerErr
+		}
+		return nil
+	})
+
+	go handler(context.Background(), []byte("ok"))
+	d, err := awaiter.Wait(time.Second)
+	if err != nil {
+		t.Fatalf("expected delivery, got %v", err)
+	}
+	if string(d.Body) != "ok" || d.Err != nil {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+
+	go handler(context.Background(), []byte("bad"))
+	d, err = awaiter.Wait(time.Second)
+	if err != nil {
+		t.Fatalf("expected delivery, got %v", err)
+	}
+	if !errors.Is(d.Err, handlerErr) {
+		t.Errorf("expected handler error, got %v", d.Err)
+	}
+}
+
+func TestAwaiter_Timeout(t *testing.T) {
+	awaiter := NewAwaiter(1)
+	if _, err := awaiter.Wait(10 * time.Millisecond); err == nil {
+		t.Error("expected timeout error, got nil")
+	}
+}
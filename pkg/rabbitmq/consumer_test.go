@@ -0,0 +1,156 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"go-micro/pkg/logger"
+)
+
+// recordingAcknowledger counts the acknowledgments sent for deliveries
+type recordingAcknowledger struct {
+	acks, nacks int
+	// deadLettered counts the nacks that did not requeue
+	deadLettered int
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acks++
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.nacks++
+	if !requeue {
+		a.deadLettered++
+	}
+	return nil
+}
+
+func (a *recordingAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.nacks++
+	return nil
+}
+
+func TestConsumer_DeliverAckModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoAck    bool
+		handlerErr error
+		wantAcks   int
+	}{
+		{"manual ack on success", false, nil, 1},
+		{"auto ack on success", true, nil, 0},
+		{"auto ack drops failures", true, errors.New("boom"), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ack := &recordingAcknowledger{}
+			msgs := make(chan amqp.Delivery, 1)
+			msgs <- amqp.Delivery{Acknowledger: ack, Body: []byte(`{}`)}
+			close(msgs)
+
+			consumer := &Consumer{queue: "test", log: logger.New("test", "debug")}
+			WithAutoAck(tt.autoAck)(consumer)
+
+			handled := 0
+			handler := func(ctx context.Context, body []byte) error {
+				handled++
+				return tt.handlerErr
+			}
+
+			// Act
+			consumer.deliver(context.Background(), msgs, handler)
+
+			// Assert
+			if handled != 1 {
+				t.Errorf("expected the handler to run once, ran %d times", handled)
+			}
+			if ack.acks != tt.wantAcks || ack.nacks != 0 {
+				t.Errorf("expected %d acks and no nacks, got %d acks and %d nacks", tt.wantAcks, ack.acks, ack.nacks)
+			}
+		})
+	}
+}
+
+func TestConsumer_HandleFailure(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxRetries       int
+		retries          int32
+		err              error
+		wantDeadLettered bool
+		wantRepublished  bool
+	}{
+		{"permanent error", 0, 0, Permanent(errors.New("malformed")), true, false},
+		{"unlimited retries requeue", 0, 0, errors.New("boom"), false, false},
+		{"retry left", 3, 2, errors.New("boom"), false, true},
+		{"out of retries", 3, 3, errors.New("boom"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ack := &recordingAcknowledger{}
+			msg := amqp.Delivery{
+				Acknowledger: ack,
+				Headers:      amqp.Table{retryCountHeader: tt.retries, "x-trace-id": "trace"},
+				Body:         []byte(`{}`),
+			}
+
+			var republished []amqp.Publishing
+			consumer := &Consumer{queue: "test", log: logger.New("test", "debug")}
+			WithMaxRetries(tt.maxRetries)(consumer)
+			consumer.republish = func(ctx context.Context, msg amqp.Publishing) error {
+				republished = append(republished, msg)
+				return nil
+			}
+
+			// Act
+			consumer.handleFailure(context.Background(), msg, tt.err)
+
+			// Assert
+			if got := ack.deadLettered == 1; got != tt.wantDeadLettered {
+				t.Errorf("expected dead-lettered=%v, got %d dead-lettering nacks", tt.wantDeadLettered, ack.deadLettered)
+			}
+			if got := len(republished) == 1; got != tt.wantRepublished {
+				t.Fatalf("expected republished=%v, got %d copies", tt.wantRepublished, len(republished))
+			}
+			if tt.wantRepublished {
+				if n := retryCount(republished[0].Headers); n != int(tt.retries)+1 {
+					t.Errorf("expected the copy to count %d retries, got %d", tt.retries+1, n)
+				}
+				if republished[0].Headers["x-trace-id"] != "trace" {
+					t.Errorf("expected the copy to keep the other headers, got %v", republished[0].Headers)
+				}
+				if ack.acks != 1 {
+					t.Errorf("expected the original to be acked once the copy is queued, got %d acks", ack.acks)
+				}
+			}
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	// Arrange
+	cause := errors.New("malformed")
+
+	// Act
+	err := Permanent(cause)
+
+	// Assert
+	if !IsPermanent(err) || !errors.Is(err, cause) {
+		t.Errorf("expected a permanent error wrapping the cause, got %v", err)
+	}
+	if IsPermanent(cause) {
+		t.Error("expected an unwrapped error not to be permanent")
+	}
+	if Permanent(nil) != nil {
+		t.Error("expected Permanent(nil) to be nil")
+	}
+}
@@ -0,0 +1,110 @@
+package rabbitmq
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go-micro/pkg/metrics"
+)
+
+// Deduplicator remembers the EventIDs of the most recently processed messages
+// so redeliveries are acknowledged without being handled again. It is a
+// bounded LRU: once size IDs are cached the least recently seen is evicted,
+// so only duplicates arriving within that window are caught.
+//
+// It is safe for concurrent use. A message whose ID is still being handled is
+// treated as a duplicate too; if the first delivery fails it is requeued, so
+// skipping the second loses nothing.
+type Deduplicator struct {
+	mu       sync.Mutex
+	size     int
+	order    *list.List // most recently seen at the front
+	seen     map[string]*list.Element
+	inFlight map[string]struct{}
+}
+
+// NewDeduplicator creates a Deduplicator caching up to size event IDs.
+// A size <= 0 disables deduplication and returns nil.
+func NewDeduplicator(size int) *Deduplicator {
+	if size <= 0 {
+		return nil
+	}
+	return &Deduplicator{
+		size:     size,
+		order:    list.New(),
+		seen:     make(map[string]*list.Element, size),
+		inFlight: make(map[string]struct{}),
+	}
+}
+
+// Wrap returns a handler that skips messages whose EventID was already
+// processed. Messages without an EventID are always handled. A nil
+// Deduplicator returns handler unchanged.
+func (d *Deduplicator) Wrap(handler MessageHandler) MessageHandler {
+	if d == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, body []byte) error {
+		id := eventID(body)
+		if id == "" {
+			return handler(ctx, body)
+		}
+
+		if !d.begin(id) {
+			metrics.DuplicateEvents.Inc()
+			return nil
+		}
+
+		err := handler(ctx, body)
+		d.finish(id, err == nil)
+		return err
+	}
+}
+
+// begin reserves id for processing and reports false if it is a duplicate
+func (d *Deduplicator) begin(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if elem, ok := d.seen[id]; ok {
+		d.order.MoveToFront(elem)
+		return false
+	}
+	if _, ok := d.inFlight[id]; ok {
+		return false
+	}
+	d.inFlight[id] = struct{}{}
+	return true
+}
+
+// finish releases id and, when it was processed successfully, remembers it
+func (d *Deduplicator) finish(id string, processed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, id)
+	if !processed {
+		return
+	}
+
+	d.seen[id] = d.order.PushFront(id)
+	if d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(string))
+	}
+}
+
+// eventID extracts the event_id field shared by all events, or "" if absent
+func eventID(body []byte) string {
+	var envelope struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return ""
+	}
+	return envelope.EventID
+}
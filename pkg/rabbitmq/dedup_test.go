@@ -0,0 +1,126 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeduplicator_SkipsRedelivery(t *testing.T) {
+	var processed int32
+	handler := NewDeduplicator(10).Wrap(func(ctx context.Context, body []byte) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+
+	body := []byte(`{"event_id":"evt-1","event_type":"user.created"}`)
+	for i := 0; i < 2; i++ {
+		if err := handler(context.Background(), body); err != nil {
+			t.Fatalf("delivery %d: expected no error, got %v", i+1, err)
+		}
+	}
+
+	if processed != 1 {
+		t.Errorf("expected 1 processing, got %d", processed)
+	}
+}
+
+func TestDeduplicator_RetriesFailedMessages(t *testing.T) {
+	var processed int32
+	handlerErr := errors.New("boom")
+	handler := NewDeduplicator(10).Wrap(func(ctx context.Context, body []byte) error {
+		if atomic.AddInt32(&processed, 1) == 1 {
+			return handlerErr
+		}
+		return nil
+	})
+
+	body := []byte(`{"event_id":"evt-1"}`)
+	if err := handler(context.Background(), body); !errors.Is(err, handlerErr) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if err := handler(context.Background(), body); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+
+	if processed != 2 {
+		t.Errorf("expected failed message to be processed again, got %d processings", processed)
+	}
+}
+
+func TestDeduplicator_EvictsLeastRecentlySeen(t *testing.T) {
+	var processed int32
+	handler := NewDeduplicator(2).Wrap(func(ctx context.Context, body []byte) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+
+	for _, id := range []string{"a", "b", "a", "c", "a", "b"} {
+		_ = handler(context.Background(), []byte(`{"event_id":"`+id+`"}`))
+	}
+
+	// a, b, c are new; a is kept warm so c evicts b, which is then processed again
+	if processed != 4 {
+		t.Errorf("expected 4 processings, got %d", processed)
+	}
+}
+
+func TestDeduplicator_ConcurrentDuplicates(t *testing.T) {
+	var processed int32
+	handler := NewDeduplicator(10).Wrap(func(ctx context.Context, body []byte) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = handler(context.Background(), []byte(`{"event_id":"evt-1"}`))
+		}()
+	}
+	wg.Wait()
+
+	if processed != 1 {
+		t.Errorf("expected 1 processing, got %d", processed)
+	}
+}
+
+func TestDeduplicator_WithoutEventID(t *testing.T) {
+	var processed int32
+	handler := NewDeduplicator(10).Wrap(func(ctx context.Context, body []byte) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		_ = handler(context.Background(), []byte("not json"))
+	}
+
+	if processed != 2 {
+		t.Errorf("expected messages without an ID to always be processed, got %d", processed)
+	}
+}
+
+func TestNewDeduplicator_Disabled(t *testing.T) {
+	if d := NewDeduplicator(0); d != nil {
+		t.Fatalf("expected nil deduplicator for size 0, got %v", d)
+	}
+
+	var processed int32
+	var d *Deduplicator
+	handler := d.Wrap(func(ctx context.Context, body []byte) error {
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		_ = handler(context.Background(), []byte(`{"event_id":"evt-1"}`))
+	}
+
+	if processed != 2 {
+		t.Errorf("expected disabled deduplicator to process every message, got %d", processed)
+	}
+}
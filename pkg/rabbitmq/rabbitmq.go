@@ -3,7 +3,10 @@ package rabbitmq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,11 +14,13 @@ import (
 	"go.uber.org/zap"
 
 	"go-micro/pkg/logger"
+	"go-micro/pkg/tenant"
 )
 
 // Connection manages a RabbitMQ connection with reconnect capability
 type Connection struct {
 	url        string
+	name       string
 	conn       *amqp.Connection
 	channel    *amqp.Channel
 	log        *logger.Logger
@@ -24,26 +29,75 @@ type Connection struct {
 	reconnects int
 }
 
-// NewConnection creates a new RabbitMQ connection
-func NewConnection(url string, log *logger.Logger) (*Connection, error) {
+// NewConnection creates a new RabbitMQ connection for service, which names
+// the connection on the broker (see ConnectionName)
+func NewConnection(url, service string, log *logger.Logger) (*Connection, error) {
+	return NewConnectionWithRetry(url, service, 1, 0, log)
+}
+
+// NewConnectionWithRetry creates a new RabbitMQ connection, making up to
+// attempts connection attempts so a broker that is still starting up is
+// waited for. The n-th retry waits n times backoff.
+func NewConnectionWithRetry(url, service string, attempts int, backoff time.Duration, log *logger.Logger) (*Connection, error) {
 	c := &Connection{
 		url:       url,
+		name:      ConnectionName(service),
 		log:       log,
 		closeChan: make(chan struct{}),
 	}
 
-	if err := c.connect(); err != nil {
-		return nil, err
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = c.connect(); err == nil {
+			return c, nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		delay := backoff * time.Duration(attempt)
+		log.Warn("RabbitMQ not reachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("retry_in", delay),
+			zap.Error(err),
+		)
+		time.Sleep(delay)
 	}
 
-	return c, nil
+	if attempts > 1 {
+		return nil, fmt.Errorf("%w (gave up after %d attempts)", err, attempts)
+	}
+	return nil, err
+}
+
+// ConnectionName is the name a service's connections show under in the
+// broker's management UI: the service and the host it runs on, such as
+// "orders@orders-7d9f" (just the service if the host is unknown)
+func ConnectionName(service string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return service
+	}
+	return service + "@" + host
 }
 
 func (c *Connection) connect() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	conn, err := amqp.Dial(c.url)
+	// Same heartbeat and locale as amqp.Dial
+	props := amqp.NewConnectionProperties()
+	props["connection_name"] = c.name
+	conn, err := amqp.DialConfig(c.url, amqp.Config{
+		Heartbeat:  10 * time.Second,
+		Locale:     "en_US",
+		Properties: props,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
@@ -57,7 +111,7 @@ func (c *Connection) connect() error {
 	c.conn = conn
 	c.channel = ch
 
-	c.log.Info("connected to RabbitMQ")
+	c.log.Info("connected to RabbitMQ", zap.String("connection_name", c.name))
 	return nil
 }
 
@@ -91,17 +145,53 @@ type Publisher struct {
 	log      *logger.Logger
 }
 
-// NewPublisher creates a new publisher
+// Exchange types
+const (
+	ExchangeTopic  = "topic"
+	ExchangeDirect = "direct"
+	ExchangeFanout = "fanout"
+)
+
+// ValidateBinding checks that routing keys can be bound to an exchange of the given type
+func ValidateBinding(exchangeType string, routingKeys []string) error {
+	switch exchangeType {
+	case ExchangeTopic:
+		return nil
+	case ExchangeDirect:
+		for _, key := range routingKeys {
+			if strings.ContainsAny(key, "*#") {
+				return fmt.Errorf("routing key %q uses wildcards, which direct exchanges do not support", key)
+			}
+		}
+		return nil
+	case ExchangeFanout:
+		return nil
+	default:
+		return fmt.Errorf("unsupported exchange type %q", exchangeType)
+	}
+}
+
+// NewPublisher creates a new publisher on a topic exchange
 func NewPublisher(conn *Connection, exchange string, log *logger.Logger) (*Publisher, error) {
+	return NewPublisherWithType(conn, exchange, ExchangeTopic, log)
+}
+
+// NewPublisherWithType creates a new publisher, declaring the exchange with
+// the given type (topic, direct or fanout)
+func NewPublisherWithType(conn *Connection, exchange, exchangeType string, log *logger.Logger) (*Publisher, error) {
+	if err := ValidateBinding(exchangeType, nil); err != nil {
+		return nil, err
+	}
+
 	// Declare exchange
 	err := conn.Channel().ExchangeDeclare(
-		exchange, // name
-		"topic",  // type
-		true,     // durable
-		false,    // auto-deleted
-		false,    // internal
-		false,    // no-wait
-		nil,      // arguments
+		exchange,     // name
+		exchangeType, // type
+		true,         // durable
+		false,        // auto-deleted
+		false,        // internal
+		false,        // no-wait
+		nil,          // arguments
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to declare exchange: %w", err)
@@ -135,9 +225,7 @@ func (p *Publisher) Publish(ctx context.Context, routingKey string, message inte
 			DeliveryMode:  amqp.Persistent,
 			Timestamp:     time.Now(),
 			CorrelationId: traceID,
-			Headers: amqp.Table{
-				"x-trace-id": traceID,
-			},
+			Headers:       publishHeaders(ctx, traceID),
 		},
 	)
 	if err != nil {
@@ -153,18 +241,104 @@ func (p *Publisher) Publish(ctx context.Context, routingKey string, message inte
 	return nil
 }
 
+// publishHeaders carries the trace ID, and the tenant if any, to consumers
+func publishHeaders(ctx context.Context, traceID string) amqp.Table {
+	headers := amqp.Table{"x-trace-id": traceID}
+	if tenantID := tenant.FromContext(ctx); tenantID != "" {
+		headers[tenant.MetadataKey] = tenantID
+	}
+	return headers
+}
+
 // Consumer consumes messages from RabbitMQ
 type Consumer struct {
 	conn        *Connection
 	queue       string
 	exchange    string
 	routingKeys []string
+	dedup       *Deduplicator
+	autoAck     bool
+	maxRetries  int
 	log         *logger.Logger
+
+	// republish sends a failed message back to the queue for another attempt
+	republish func(ctx context.Context, msg amqp.Publishing) error
 }
 
-// NewConsumer creates a new consumer
-func NewConsumer(conn *Connection, queue, exchange string, routingKeys []string, log *logger.Logger) (*Consumer, error) {
+// ConsumerOption configures a Consumer created by NewConsumer
+type ConsumerOption func(*Consumer)
+
+// WithAutoAck makes the broker consider each message acknowledged as soon as
+// it is delivered, trading the default at-least-once delivery for
+// throughput: a message whose handler fails, or that is in flight when the
+// process dies, is lost instead of redelivered. Use it only for events that
+// are cheap to lose.
+func WithAutoAck(autoAck bool) ConsumerOption {
+	return func(c *Consumer) {
+		c.autoAck = autoAck
+	}
+}
+
+// WithMaxRetries caps how many times a failed message is retried: after
+// maxRetries failed retries it is dead-lettered instead of requeued. Retries
+// are counted in the x-retry-count header, as the broker does not count
+// requeues on classic queues. Zero, the default, retries forever.
+func WithMaxRetries(maxRetries int) ConsumerOption {
+	return func(c *Consumer) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// retryCountHeader counts the retries of a message republished by a consumer
+// with WithMaxRetries
+const retryCountHeader = "x-retry-count"
+
+// DeadLetterQueueName is the queue collecting the messages dead-lettered from
+// queue, e.g. rejected with Permanent or out of retries
+func DeadLetterQueueName(queue string) string {
+	return queue + ".dead-letter"
+}
+
+// QueueName prefixes a queue name (e.g. "staging.orders.user-created") so
+// environments sharing a broker don't consume each other's messages.
+// An empty prefix returns the name unchanged.
+func QueueName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// NewConsumer creates a new consumer bound to a topic exchange. The queue is
+// declared as QueueName(queuePrefix, queue).
+//
+// Queues are durable, so changing the prefix declares a new queue while the
+// old one stays bound to the exchange and keeps accumulating messages until
+// it is deleted from the broker.
+func NewConsumer(conn *Connection, queuePrefix, queue, exchange string, routingKeys []string, log *logger.Logger, opts ...ConsumerOption) (*Consumer, error) {
+	return NewConsumerWithType(conn, queuePrefix, queue, exchange, ExchangeTopic, routingKeys, log, opts...)
+}
+
+// NewConsumerWithType creates a new consumer bound to an exchange of the given
+// type. Routing keys are validated against the type; fanout exchanges ignore
+// them and the queue is bound once with an empty key.
+//
+// Messages are acknowledged manually by default: after the handler succeeds,
+// or requeued when it fails (at-least-once). See WithAutoAck and
+// WithMaxRetries. Messages failing with a Permanent error are dead-lettered
+// to the exchange's ".dlx" exchange, which routes them to
+// DeadLetterQueueName(queue) with the same routing keys. Dead letters of other
+// queues bound with the same keys end up there too.
+func NewConsumerWithType(conn *Connection, queuePrefix, queue, exchange, exchangeType string, routingKeys []string, log *logger.Logger, opts ...ConsumerOption) (*Consumer, error) {
+	if err := ValidateBinding(exchangeType, routingKeys); err != nil {
+		return nil, err
+	}
+	if exchangeType == ExchangeFanout {
+		routingKeys = []string{""}
+	}
+
 	ch := conn.Channel()
+	queue = QueueName(queuePrefix, queue)
 
 	// Declare queue
 	_, err := ch.QueueDeclare(
@@ -189,75 +363,243 @@ func NewConsumer(conn *Connection, queue, exchange string, routingKeys []string,
 		}
 	}
 
-	return &Consumer{
+	// Keep dead letters instead of letting the broker drop them for lack of
+	// a route
+	if err := declareDeadLetterQueue(ch, queue, exchange, exchangeType, routingKeys); err != nil {
+		return nil, err
+	}
+
+	consumer := &Consumer{
 		conn:        conn,
 		queue:       queue,
 		exchange:    exchange,
 		routingKeys: routingKeys,
 		log:         log,
-	}, nil
+	}
+	consumer.republish = func(ctx context.Context, msg amqp.Publishing) error {
+		// The default exchange routes by queue name
+		return conn.Channel().PublishWithContext(ctx, "", queue, false, false, msg)
+	}
+	for _, opt := range opts {
+		opt(consumer)
+	}
+	return consumer, nil
+}
+
+// declareDeadLetterQueue declares the exchange's dead-letter exchange and
+// the queue's dead-letter queue, bound with the queue's routing keys
+func declareDeadLetterQueue(ch *amqp.Channel, queue, exchange, exchangeType string, routingKeys []string) error {
+	dlx := exchange + ".dlx"
+	if err := ch.ExchangeDeclare(dlx, exchangeType, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+
+	dlq := DeadLetterQueueName(queue)
+	if _, err := ch.QueueDeclare(dlq, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	for _, key := range routingKeys {
+		if err := ch.QueueBind(dlq, key, dlx, false, nil); err != nil {
+			return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+		}
+	}
+	return nil
 }
 
 // MessageHandler is a function that handles a message
 type MessageHandler func(ctx context.Context, body []byte) error
 
-// Consume starts consuming messages
+// permanentError marks a handler failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a handler error to dead-letter the message instead of
+// requeueing it, e.g. for a malformed body or an event that can never be
+// applied. Permanent(nil) is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// SetDeduplicator makes Consume and Run acknowledge already-processed events
+// without handling them again. It must be called before either; nil disables it.
+func (c *Consumer) SetDeduplicator(d *Deduplicator) {
+	c.dedup = d
+}
+
+// Consume starts consuming messages in the background until ctx is cancelled
 func (c *Consumer) Consume(ctx context.Context, handler MessageHandler) error {
+	msgs, err := c.subscribe()
+	if err != nil {
+		return err
+	}
+
+	go c.deliver(ctx, msgs, c.dedup.Wrap(handler))
+	return nil
+}
+
+// Run consumes messages until ctx is cancelled or the channel closes. Unlike
+// Consume it blocks, and returns only once the message in flight has been
+// handled, so callers can wait for it on shutdown.
+func (c *Consumer) Run(ctx context.Context, handler MessageHandler) error {
+	msgs, err := c.subscribe()
+	if err != nil {
+		return err
+	}
+
+	c.deliver(ctx, msgs, c.dedup.Wrap(handler))
+	return nil
+}
+
+// subscribe registers the consumer on its queue
+func (c *Consumer) subscribe() (<-chan amqp.Delivery, error) {
 	msgs, err := c.conn.Channel().Consume(
-		c.queue, // queue
-		"",      // consumer
-		false,   // auto-ack
-		false,   // exclusive
-		false,   // no-local
-		false,   // no-wait
-		nil,     // args
+		c.queue,   // queue
+		"",        // consumer
+		c.autoAck, // auto-ack
+		false,     // exclusive
+		false,     // no-local
+		false,     // no-wait
+		nil,       // args
 	)
 	if err != nil {
-		return fmt.Errorf("failed to start consuming: %w", err)
+		return nil, fmt.Errorf("failed to start consuming: %w", err)
 	}
 
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case msg, ok := <-msgs:
-				if !ok {
-					return
-				}
+	c.log.Info("consumer started",
+		zap.String("queue", c.queue),
+		zap.Strings("routing_keys", c.routingKeys),
+		zap.Bool("auto_ack", c.autoAck),
+	)
 
-				// Extract trace ID from headers
-				traceID := ""
-				if tid, ok := msg.Headers["x-trace-id"].(string); ok {
-					traceID = tid
-				}
-				msgCtx := logger.WithTraceIDContext(ctx, traceID)
+	return msgs, nil
+}
 
-				c.log.WithContext(msgCtx).Debug("message received",
-					zap.String("queue", c.queue),
-					zap.String("routing_key", msg.RoutingKey),
-					zap.String("trace_id", traceID),
-				)
+// deliver hands each message to handler until ctx is cancelled or msgs closes
+func (c *Consumer) deliver(ctx context.Context, msgs <-chan amqp.Delivery, handler MessageHandler) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
 
-				if err := handler(msgCtx, msg.Body); err != nil {
-					c.log.WithContext(msgCtx).Error("failed to handle message",
+			// Extract trace ID from headers
+			traceID := ""
+			if tid, ok := msg.Headers["x-trace-id"].(string); ok {
+				traceID = tid
+			}
+			msgCtx := logger.WithTraceIDContext(ctx, traceID)
+			if tenantID, ok := msg.Headers[tenant.MetadataKey].(string); ok {
+				msgCtx = tenant.WithContext(msgCtx, tenantID)
+			}
+
+			c.log.WithContext(msgCtx).Debug("message received",
+				zap.String("queue", c.queue),
+				zap.String("routing_key", msg.RoutingKey),
+				zap.String("trace_id", traceID),
+			)
+
+			err := handler(msgCtx, msg.Body)
+			if c.autoAck {
+				// The broker already forgot the message, so a failure drops it
+				if err != nil {
+					c.log.WithContext(msgCtx).Error("failed to handle message, dropping it (auto-ack)",
 						zap.Error(err),
 						zap.String("queue", c.queue),
 					)
-					// Retry with delay (basic retry)
-					time.Sleep(time.Second)
-					msg.Nack(false, true)
-				} else {
-					msg.Ack(false)
 				}
+				continue
+			}
+
+			if err != nil {
+				c.handleFailure(msgCtx, msg, err)
+			} else {
+				msg.Ack(false)
 			}
 		}
-	}()
+	}
+}
 
-	c.log.Info("consumer started",
+// handleFailure requeues a message whose handler failed, or dead-letters it
+// when the error is permanent or the message is out of retries
+func (c *Consumer) handleFailure(ctx context.Context, msg amqp.Delivery, err error) {
+	retries := retryCount(msg.Headers)
+	if IsPermanent(err) || (c.maxRetries > 0 && retries >= c.maxRetries) {
+		c.log.WithContext(ctx).Error("failed to handle message, dead-lettering it",
+			zap.Error(err),
+			zap.String("queue", c.queue),
+			zap.Int("retries", retries),
+		)
+		msg.Nack(false, false)
+		return
+	}
+
+	c.log.WithContext(ctx).Error("failed to handle message",
+		zap.Error(err),
 		zap.String("queue", c.queue),
-		zap.Strings("routing_keys", c.routingKeys),
+		zap.Int("retries", retries),
 	)
+	// Retry with delay (basic retry)
+	time.Sleep(time.Second)
 
-	return nil
+	if c.maxRetries == 0 {
+		msg.Nack(false, true)
+		return
+	}
+
+	// Requeueing does not change the message, so count the retry on a copy
+	// and drop the original once the copy is queued
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[retryCountHeader] = int32(retries + 1)
+	if err := c.republish(ctx, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  msg.DeliveryMode,
+		CorrelationId: msg.CorrelationId,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		Body:          msg.Body,
+	}); err != nil {
+		c.log.WithContext(ctx).Error("failed to republish message for retry, requeueing it",
+			zap.Error(err),
+			zap.String("queue", c.queue),
+		)
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
+}
+
+// retryCount reads the x-retry-count header, zero if absent
+func retryCount(headers amqp.Table) int {
+	switch n := headers[retryCountHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
+	}
 }
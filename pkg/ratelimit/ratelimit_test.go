@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_KeysHaveOwnBuckets(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	limiter := New(1, 2)
+
+	// Act & Assert: exhausting one key leaves the other untouched
+	for i := 0; i < 2; i++ {
+		if !limiter.AllowAt("a", now) {
+			t.Fatalf("call %d: expected a to be allowed", i+1)
+		}
+	}
+	if limiter.AllowAt("a", now) {
+		t.Error("expected a to be rejected beyond its burst")
+	}
+	if !limiter.AllowAt("b", now) {
+		t.Error("expected b to be allowed")
+	}
+	if !limiter.AllowAt("a", now.Add(time.Second)) {
+		t.Error("expected a token for a after a second at 1 call/s")
+	}
+}
+
+func TestLimiter_DropsIdleBuckets(t *testing.T) {
+	// Arrange: a is drained, b has just been used
+	now := time.Now()
+	limiter := New(1, 2)
+	limiter.AllowAt("a", now)
+	limiter.AllowAt("a", now)
+
+	// Act: a refills completely before the next sweep
+	limiter.AllowAt("b", now.Add(sweepInterval))
+
+	// Assert
+	if _, ok := limiter.buckets["a"]; ok {
+		t.Error("expected the refilled bucket to be dropped")
+	}
+	if _, ok := limiter.buckets["b"]; !ok {
+		t.Error("expected the active bucket to be kept")
+	}
+}
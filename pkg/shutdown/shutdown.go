@@ -0,0 +1,52 @@
+package shutdown
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go-micro/pkg/logger"
+)
+
+// Step is a named teardown action
+type Step struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Sequence runs teardown steps in the order they were added, so servers can
+// drain before consumers stop and before outbound connections are closed.
+type Sequence struct {
+	steps []Step
+	log   *logger.Logger
+}
+
+// NewSequence creates an empty shutdown sequence
+func NewSequence(log *logger.Logger) *Sequence {
+	return &Sequence{log: log}
+}
+
+// Add appends a step to the sequence
+func (s *Sequence) Add(name string, fn func(ctx context.Context) error) {
+	s.steps = append(s.steps, Step{Name: name, Fn: fn})
+}
+
+// Run executes every step in order. A failing step is logged and does not
+// prevent later steps from running.
+func (s *Sequence) Run(ctx context.Context) {
+	for _, step := range s.steps {
+		start := time.Now()
+		if err := step.Fn(ctx); err != nil {
+			s.log.Error("shutdown step failed",
+				zap.String("step", step.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+		s.log.Info("shutdown step completed",
+			zap.String("step", step.Name),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+}
@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrShuttingDown is returned by Register once shutdown has started
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Stream is a handle for a single long-lived connection (SSE or similar).
+// Handlers select on Done, write a final close event, and call Release.
+type Stream struct {
+	id       uint64
+	registry *Registry
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Done is closed when the server starts shutting down
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Release removes the stream from the registry. It is safe to call more than once.
+func (s *Stream) Release() {
+	s.once.Do(func() {
+		s.registry.release(s.id)
+	})
+}
+
+// Registry tracks active streams so shutdown can close them within the
+// drain timeout. http.Server.Shutdown waits for connections to go idle,
+// which a stream never does on its own.
+type Registry struct {
+	mu      sync.Mutex
+	streams map[uint64]*Stream
+	nextID  uint64
+	closing bool
+	drained chan struct{}
+}
+
+// NewRegistry creates an empty stream registry
+func NewRegistry() *Registry {
+	return &Registry{
+		streams: make(map[uint64]*Stream),
+	}
+}
+
+// Register adds a new stream to the registry
+func (r *Registry) Register() (*Stream, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closing {
+		return nil, ErrShuttingDown
+	}
+
+	r.nextID++
+	s := &Stream{
+		id:       r.nextID,
+		registry: r,
+		done:     make(chan struct{}),
+	}
+	r.streams[s.id] = s
+	return s, nil
+}
+
+// Active returns the number of registered streams
+func (r *Registry) Active() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.streams)
+}
+
+// Shutdown signals every stream to close and waits until all of them have
+// been released or ctx is done.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closing {
+		r.closing = true
+		r.drained = make(chan struct{})
+		for _, s := range r.streams {
+			close(s.done)
+		}
+		if len(r.streams) == 0 {
+			close(r.drained)
+		}
+	}
+	drained := r.drained
+	r.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d streams still open: %w", r.Active(), ctx.Err())
+	}
+}
+
+func (r *Registry) release(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.streams, id)
+	if r.closing && len(r.streams) == 0 {
+		close(r.drained)
+	}
+}
+
+// WriteCloseEvent writes a final SSE "close" event and flushes it, so clients
+// can tell a deliberate shutdown from a dropped connection.
+func WriteCloseEvent(w http.ResponseWriter) error {
+	if _, err := fmt.Fprint(w, "event: close\ndata: {}\n\n"); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ShutdownClosesStreams(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	s, err := registry.Register()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		<-s.Done()
+		s.Release()
+	}()
+
+	// Act
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = registry.Shutdown(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+	if registry.Active() != 0 {
+		t.Errorf("expected no active streams, got %d", registry.Active())
+	}
+	if _, err := registry.Register(); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown, got %v", err)
+	}
+}
+
+func TestRegistry_ShutdownTimeout(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	if _, err := registry.Register(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Act
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := registry.Shutdown(ctx)
+
+	// Assert
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWriteCloseEvent(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	if err := WriteCloseEvent(rec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := rec.Body.String(); got != "event: close\ndata: {}\n\n" {
+		t.Errorf("unexpected body %q", got)
+	}
+	if !rec.Flushed {
+		t.Error("expected response to be flushed")
+	}
+}
@@ -0,0 +1,67 @@
+// Package tenant carries the tenant a request acts for, from the gateway's
+// X-Tenant-ID header (or the caller's token) through gRPC metadata and
+// RabbitMQ headers down to the repositories, which only see that tenant's
+// rows. Acting for no tenant means acting for the default, empty one.
+package tenant
+
+import (
+	"context"
+	"regexp"
+
+	"go-micro/pkg/errors"
+	"go-micro/pkg/logger"
+)
+
+const (
+	// Header is the HTTP header naming the tenant of a request
+	Header = "X-Tenant-ID"
+	// MetadataKey is the gRPC metadata key and RabbitMQ header for the tenant
+	MetadataKey = "x-tenant-id"
+)
+
+type ctxKey struct{}
+
+// allTenantsKey marks contexts acting for every tenant at once
+type allTenantsKey struct{}
+
+// idPattern is what a tenant ID may look like: short and safe to log
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Validate checks that id is a well-formed tenant ID
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return errors.NewValidation("invalid tenant id", map[string]string{
+			"tenant_id": "must be 1-64 letters, digits, '-' or '_', starting with a letter or digit",
+		})
+	}
+	return nil
+}
+
+// WithContext returns a context acting for tenant id, whose WithContext
+// loggers include it as tenant_id. An empty id leaves ctx unchanged.
+func WithContext(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, ctxKey{}, id)
+	return logger.WithField(ctx, "tenant_id", id)
+}
+
+// FromContext returns the tenant ctx acts for, or "" if none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// AllTenants returns a context acting for every tenant at once, for
+// background jobs that sweep all rows. Requests never get one: without a
+// tenant they act for the default one.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+// IsAllTenants reports whether ctx acts for every tenant (see AllTenants)
+func IsAllTenants(ctx context.Context) bool {
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all
+}